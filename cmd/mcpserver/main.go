@@ -9,10 +9,12 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/BearHuddleston/mcp-server-template/internal/server"
 	"github.com/BearHuddleston/mcp-server-template/pkg/config"
 	"github.com/BearHuddleston/mcp-server-template/pkg/handlers"
+	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
 	"github.com/BearHuddleston/mcp-server-template/pkg/spec"
 	"github.com/BearHuddleston/mcp-server-template/pkg/transport"
 )
@@ -30,7 +32,8 @@ func main() {
 }
 
 func execute(parseFlags func() (*config.Config, error), runServer func(*config.Config) error, stderr io.Writer) int {
-	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	// Records logged with a session context also reach that session's client.
+	logger := slog.New(mcp.NewSlogHandler(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelInfo}), "mcpserver"))
 	slog.SetDefault(logger)
 
 	cfg, err := parseFlags()
@@ -60,12 +63,22 @@ func run(cfg *config.Config) error {
 		if err != nil {
 			return fmt.Errorf("failed to create catalog from spec: %w", err)
 		}
+
+		cfg.Apply(config.SourceSpec, specLayer(sp))
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration after applying spec: %w", err)
+		}
+	}
+	if cfg != nil {
+		slog.Info("effective configuration", cfg.LogAttrs()...)
 	}
 
 	mcpServer, err := server.New(cfg, catalogHandler, catalogHandler, catalogHandler)
 	if err != nil {
 		return fmt.Errorf("failed to create server: %w", err)
 	}
+	mcpServer.Use(mcp.Recover())
+	mcpServer.RegisterMethod("catalog/stats", mcp.TypedHandler(catalogHandler.Stats), server.Capability{Name: "catalog", Value: map[string]any{"stats": true}})
 
 	transport, err := createTransport(cfg)
 	if err != nil {
@@ -83,6 +96,26 @@ func run(cfg *config.Config) error {
 		cancel()
 	}()
 
+	if cfg.SpecPath != "" {
+		reloadChan := make(chan os.Signal, 1)
+		signal.Notify(reloadChan, syscall.SIGHUP)
+		defer signal.Stop(reloadChan)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-reloadChan:
+					if err := reloadSpec(catalogHandler, cfg.SpecPath); err != nil {
+						slog.Error("spec reload failed; keeping current catalog", "error", err)
+						continue
+					}
+					slog.Info("reloaded spec", "path", cfg.SpecPath)
+				}
+			}
+		}()
+	}
+
 	if err := transport.Start(ctx, mcpServer); err != nil {
 		return fmt.Errorf("transport start failed: %w", err)
 	}
@@ -90,13 +123,68 @@ func run(cfg *config.Config) error {
 	return nil
 }
 
+// reloadSpec reloads the spec at path into the catalog
+func reloadSpec(catalog *handlers.Catalog, path string) error {
+	sp, err := spec.LoadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to load spec: %w", err)
+	}
+	return catalog.Reload(sp)
+}
+
+// specLayer converts the spec's server and runtime sections into a config layer
+func specLayer(sp *spec.Spec) config.Layer {
+	layer := config.Layer{
+		TransportType:   strings.ToLower(strings.TrimSpace(sp.Runtime.TransportType)),
+		HTTPPort:        sp.Runtime.HTTPPort,
+		AllowedOrigins:  sp.Runtime.AllowedOrigins,
+		ServerName:      strings.TrimSpace(sp.Server.Name),
+		ServerVersion:   strings.TrimSpace(sp.Server.Version),
+		PageSize:        sp.Runtime.PageSize,
+		EventRetention:  sp.Runtime.EventRetention,
+		EventStoreDir:   strings.TrimSpace(sp.Runtime.EventStoreDir),
+		MaxSessions:     sp.Runtime.MaxSessions,
+		SessionStoreDir: strings.TrimSpace(sp.Runtime.SessionStoreDir),
+		Stateless:       sp.Runtime.Stateless,
+	}
+	layer.RequestTimeout = specDuration(sp.Runtime.RequestTimeout)
+	layer.EventMaxAge = specDuration(sp.Runtime.EventMaxAge)
+	layer.SessionIdleTimeout = specDuration(sp.Runtime.SessionIdleTimeout)
+	layer.SessionMaxLifetime = specDuration(sp.Runtime.SessionMaxLifetime)
+	return layer
+}
+
+// specDuration parses an optional spec duration; empty or invalid values are unset
+func specDuration(value string) *time.Duration {
+	duration, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+	return &duration
+}
+
 // createTransport creates the appropriate transport based on configuration
 func createTransport(cfg *config.Config) (transport.Transport, error) {
 	switch strings.ToLower(cfg.TransportType) {
 	case "stdio":
 		return transport.NewStdio(cfg), nil
 	case "http":
-		return transport.NewHTTP(cfg), nil
+		httpTransport := transport.NewHTTP(cfg)
+		if cfg.EventStoreDir != "" {
+			store, err := transport.NewFileEventStore(cfg.EventStoreDir, cfg.EventRetention, cfg.EventMaxAge)
+			if err != nil {
+				return nil, err
+			}
+			httpTransport.SetEventStore(store)
+		}
+		if cfg.SessionStoreDir != "" {
+			store, err := transport.NewFileSessionStore(cfg.SessionStoreDir)
+			if err != nil {
+				return nil, err
+			}
+			httpTransport.SetSessionStore(store)
+		}
+		return httpTransport, nil
 	default:
 		return nil, fmt.Errorf("invalid transport type: %s (must be 'stdio' or 'http')", cfg.TransportType)
 	}
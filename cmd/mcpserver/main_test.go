@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"os"
 	"strings"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/BearHuddleston/mcp-server-template/pkg/config"
+	"github.com/BearHuddleston/mcp-server-template/pkg/spec"
 	"github.com/BearHuddleston/mcp-server-template/pkg/transport"
 )
 
@@ -176,3 +178,75 @@ func TestExecute(t *testing.T) {
 		})
 	}
 }
+
+func TestSpecLayerAppliedBelowExplicitSettings(t *testing.T) {
+	specPort, stateless := 9090, true
+	sp := &spec.Spec{
+		Server: spec.ServerSpec{Name: "Edge Secure MCP", Version: "1.0.0"},
+		Runtime: spec.RuntimeSpec{
+			TransportType:  "HTTP",
+			HTTPPort:       &specPort,
+			RequestTimeout: "20s",
+			AllowedOrigins: []string{"https://ops.example.com"},
+			Stateless:      &stateless,
+		},
+	}
+
+	cfg := config.New()
+	flagPort := 7000
+	cfg.Apply(config.SourceFlag, config.Layer{HTTPPort: &flagPort})
+	cfg.Apply(config.SourceSpec, specLayer(sp))
+
+	if cfg.TransportType != "http" || cfg.Source(config.SettingTransport) != config.SourceSpec {
+		t.Fatalf("expected spec transport, got %s from %s", cfg.TransportType, cfg.Source(config.SettingTransport))
+	}
+	if cfg.HTTPPort != 7000 {
+		t.Fatalf("expected flag port to take precedence, got %d", cfg.HTTPPort)
+	}
+	if cfg.RequestTimeout != 20*time.Second {
+		t.Fatalf("expected spec request timeout, got %v", cfg.RequestTimeout)
+	}
+	if len(cfg.AllowedOrigins) != 1 || cfg.AllowedOrigins[0] != "https://ops.example.com" {
+		t.Fatalf("expected spec allowed origins, got %v", cfg.AllowedOrigins)
+	}
+	if cfg.ServerName != "Edge Secure MCP" || cfg.ServerVersion != "1.0.0" {
+		t.Fatalf("expected spec server info, got %s %s", cfg.ServerName, cfg.ServerVersion)
+	}
+	if !cfg.Stateless || cfg.Source(config.SettingStateless) != config.SourceSpec {
+		t.Fatalf("expected spec stateless mode, got %v from %s", cfg.Stateless, cfg.Source(config.SettingStateless))
+	}
+}
+
+func TestSpecLayerExplicitZero(t *testing.T) {
+	sp := &spec.Spec{}
+	if err := json.Unmarshal([]byte(`{"runtime": {"maxSessions": 0, "sessionIdleTimeout": "0s"}}`), sp); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+
+	cfg := config.New()
+	cfg.Apply(config.SourceSpec, specLayer(sp))
+
+	if cfg.MaxSessions != 0 || cfg.Source(config.SettingMaxSessions) != config.SourceSpec {
+		t.Fatalf("expected spec to disable the session limit, got %d from %s", cfg.MaxSessions, cfg.Source(config.SettingMaxSessions))
+	}
+	if cfg.SessionIdleTimeout != 0 || cfg.Source(config.SettingSessionIdle) != config.SourceSpec {
+		t.Fatalf("expected spec to disable the idle timeout, got %v from %s", cfg.SessionIdleTimeout, cfg.Source(config.SettingSessionIdle))
+	}
+	if cfg.Source(config.SettingPort) != config.SourceDefault {
+		t.Fatalf("expected an omitted port to stay unset, got %s", cfg.Source(config.SettingPort))
+	}
+}
+
+func TestSpecLayerStatelessFalse(t *testing.T) {
+	sp := &spec.Spec{}
+	if err := json.Unmarshal([]byte(`{"runtime": {"stateless": false}}`), sp); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+
+	cfg := config.New()
+	cfg.Apply(config.SourceSpec, specLayer(sp))
+
+	if cfg.Stateless || cfg.Source(config.SettingStateless) != config.SourceSpec {
+		t.Fatalf("expected spec to set stateless mode off explicitly, got %v from %s", cfg.Stateless, cfg.Source(config.SettingStateless))
+	}
+}
@@ -3,10 +3,16 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/BearHuddleston/mcp-server-template/pkg/config"
 	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
+	"github.com/BearHuddleston/mcp-server-template/pkg/schema"
 )
 
 // Server implements the core MCP server logic
@@ -15,6 +21,35 @@ type Server struct {
 	resourceHandler mcp.ResourceHandler
 	promptHandler   mcp.PromptHandler
 	serverInfo      mcp.ServerInfo
+
+	completionHandler mcp.CompletionHandler
+
+	cursors  *mcp.CursorCodec
+	pageSize int
+	// stateless servers handle every request without a session, so nothing
+	// that is delivered to a session later is offered
+	stateless bool
+
+	notifications        *mcp.NotificationBus
+	toolsListChanged     bool
+	resourcesListChanged bool
+	promptsListChanged   bool
+	resourceSubscriber   mcp.ResourceSubscriber
+
+	methods              map[string]mcp.Handler
+	methodCapabilities   map[string][]Capability
+	notificationHandlers map[string]mcp.NotificationFunc
+	middleware           []mcp.Middleware
+	handler              mcp.Handler
+
+	mu       sync.Mutex
+	sessions map[*mcp.Session]struct{}
+
+	// tools caches the listed tools with their compiled schemas until the tool
+	// handler publishes a list change, which also advances toolsEpoch
+	toolsMu    sync.Mutex
+	tools      map[string]*toolSchemas
+	toolsEpoch uint64
 }
 
 // New creates a new MCP server with the given handlers
@@ -32,7 +67,18 @@ func New(cfg *config.Config, toolHandler mcp.ToolHandler, resourceHandler mcp.Re
 		return nil, fmt.Errorf("promptHandler cannot be nil")
 	}
 
-	return &Server{
+	// A shared key lets every replica accept the cursors of the others.
+	cursors, err := mcp.NewCursorCodec([]byte(cfg.CursorKey))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Stateless && cfg.CursorKey == "" {
+		// Stateless requests are usually spread across replicas, which reject
+		// each other's cursors unless they share a key.
+		slog.Warn("stateless mode without a cursor key; list cursors only work on this process", "env", config.EnvCursorKey)
+	}
+
+	s := &Server{
 		toolHandler:     toolHandler,
 		resourceHandler: resourceHandler,
 		promptHandler:   promptHandler,
@@ -40,44 +86,330 @@ func New(cfg *config.Config, toolHandler mcp.ToolHandler, resourceHandler mcp.Re
 			Name:    cfg.ServerName,
 			Version: cfg.ServerVersion,
 		},
-	}, nil
+		cursors:       cursors,
+		pageSize:      cfg.PageSize,
+		stateless:     cfg.Stateless,
+		notifications: mcp.NewNotificationBus(),
+		sessions:      make(map[*mcp.Session]struct{}),
+	}
+
+	s.handler = s.dispatch
+
+	toolsListChanged := s.bindNotifier(toolHandler)
+	resourcesListChanged := s.bindNotifier(resourceHandler)
+	promptsListChanged := s.bindNotifier(promptHandler)
+	s.notifications.Subscribe(s.invalidateTools)
+
+	// List changes and resource updates are delivered to sessions, so a
+	// stateless server does not advertise them.
+	if !s.stateless {
+		s.toolsListChanged = toolsListChanged
+		s.resourcesListChanged = resourcesListChanged
+		s.promptsListChanged = promptsListChanged
+		s.notifications.Subscribe(s.broadcast)
+
+		if subscriber, ok := resourceHandler.(mcp.ResourceSubscriber); ok {
+			s.resourceSubscriber = subscriber
+		}
+	}
+
+	for _, handler := range []any{promptHandler, resourceHandler, toolHandler} {
+		if completer, ok := handler.(mcp.CompletionHandler); ok {
+			s.completionHandler = completer
+			break
+		}
+	}
+
+	s.registerBuiltins()
+	return s, nil
+}
+
+// registerBuiltins registers the standard MCP methods and notifications.
+// Optional methods are only registered when a handler supports them, so
+// their capabilities are only advertised then. Stateless servers leave out the
+// methods that configure a session.
+func (s *Server) registerBuiltins() {
+	s.methods = map[string]mcp.Handler{
+		"initialize":               s.handleInitialize,
+		"ping":                     s.handlePing,
+		"tools/list":               s.handleToolsList,
+		"tools/call":               s.handleToolsCall,
+		"resources/list":           s.handleResourcesList,
+		"resources/templates/list": s.handleResourceTemplatesList,
+		"resources/read":           s.handleResourcesRead,
+		"prompts/list":             s.handlePromptsList,
+		"prompts/get":              s.handlePromptsGet,
+	}
+	// The log level is kept per session
+	if !s.stateless {
+		s.methods["logging/setLevel"] = s.handleSetLevel
+	}
+	if s.resourceSubscriber != nil {
+		s.methods["resources/subscribe"] = s.handleResourcesSubscribe
+		s.methods["resources/unsubscribe"] = s.handleResourcesUnsubscribe
+	}
+	if s.completionHandler != nil {
+		s.methods["completion/complete"] = s.handleComplete
+	}
+
+	s.notificationHandlers = map[string]mcp.NotificationFunc{
+		"notifications/initialized": s.handleInitialized,
+		mcp.NotificationCancelled:   s.handleCancelled,
+	}
 }
 
-// Initialize handles the MCP initialization handshake
-func (s *Server) Initialize(ctx context.Context) (*mcp.InitializeResponse, error) {
+// Capability is an experimental capability advertised at initialize while
+// the method registered with it is present. A nil Value is advertised as an
+// empty object.
+type Capability struct {
+	Name  string
+	Value any
+}
+
+// RegisterMethod routes requests for name to handler, replacing any built-in
+// method of that name. Use mcp.TypedHandler for typed params. The given
+// capabilities are advertised under the experimental capability, replacing
+// those of an earlier registration of name. RegisterMethod must be called
+// before the server starts handling requests; it panics on an empty name,
+// nil handler or unnamed capability.
+func (s *Server) RegisterMethod(name string, handler mcp.Handler, capabilities ...Capability) {
+	if name == "" || handler == nil {
+		panic("server: RegisterMethod requires a name and handler")
+	}
+	for _, capability := range capabilities {
+		if capability.Name == "" {
+			panic("server: RegisterMethod requires named capabilities")
+		}
+	}
+	s.methods[name] = handler
+	if len(capabilities) == 0 {
+		delete(s.methodCapabilities, name)
+		return
+	}
+	if s.methodCapabilities == nil {
+		s.methodCapabilities = make(map[string][]Capability)
+	}
+	s.methodCapabilities[name] = capabilities
+}
+
+// RegisterNotification routes client notifications for name to handler,
+// replacing any built-in handler. Use mcp.TypedNotification for typed params.
+// It must be called before the server starts handling requests and panics on
+// an empty name or nil handler.
+func (s *Server) RegisterNotification(name string, handler mcp.NotificationFunc) {
+	if name == "" || handler == nil {
+		panic("server: RegisterNotification requires a name and handler")
+	}
+	s.notificationHandlers[name] = handler
+}
+
+// Notifications returns the bus that handlers publish server-initiated notifications to
+func (s *Server) Notifications() *mcp.NotificationBus {
+	return s.notifications
+}
+
+// bindNotifier connects handler to the notification bus and reports whether
+// it can publish list changes. A handler serving several capabilities is bound
+// once per capability with the same bus.
+func (s *Server) bindNotifier(handler any) bool {
+	binder, ok := handler.(mcp.NotifierBinder)
+	if !ok {
+		return false
+	}
+	binder.BindNotifier(s.notifications)
+	return true
+}
+
+// broadcast fans a published notification out to every ready session.
+// Resource updates only go to sessions subscribed to the resource.
+func (s *Server) broadcast(notification mcp.Notification) {
+	updated, isUpdate := notification.Params.(mcp.ResourceUpdatedParams)
+	isUpdate = isUpdate && notification.Method == mcp.NotificationResourcesUpdated
+
+	for _, session := range s.liveSessions() {
+		if !session.Initialized() {
+			continue
+		}
+		if isUpdate && !session.Subscribed(updated.URI) {
+			continue
+		}
+		if err := session.Notify(notification.Method, notification.Params); err != nil {
+			slog.Warn("failed to deliver notification", "session", session.ID(), "method", notification.Method, "error", err)
+		}
+	}
+}
+
+// TrackSession adds session to the sessions that receive broadcast
+// notifications. Sessions are tracked when they initialize; transports call it
+// for sessions restored from another process.
+func (s *Server) TrackSession(session *mcp.Session) {
+	s.mu.Lock()
+	s.sessions[session] = struct{}{}
+	s.mu.Unlock()
+}
+
+// liveSessions returns tracked sessions and forgets the ones that have closed
+func (s *Server) liveSessions() []*mcp.Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	live := make([]*mcp.Session, 0, len(s.sessions))
+	for session := range s.sessions {
+		if session.State() == mcp.SessionClosed {
+			delete(s.sessions, session)
+			continue
+		}
+		live = append(live, session)
+	}
+	return live
+}
+
+// Initialize handles the MCP initialization handshake.
+// The protocol version is negotiated from the version requested by the client
+// and capabilities are advertised for the registered methods.
+func (s *Server) Initialize(ctx context.Context, params mcp.InitializeParams) (*mcp.InitializeResponse, error) {
 	return &mcp.InitializeResponse{
-		ProtocolVersion: mcp.ProtocolVersion,
-		Capabilities: map[string]any{
-			"tools":     map[string]bool{"listChanged": true},
-			"resources": map[string]bool{"listChanged": true},
-			"prompts":   map[string]bool{"listChanged": true},
-		},
-		ServerInfo: s.serverInfo,
+		ProtocolVersion: mcp.NegotiateProtocolVersion(params.ProtocolVersion),
+		Capabilities:    s.capabilities(),
+		ServerInfo:      s.serverInfo,
 	}, nil
 }
 
-// HandleRequest processes a JSON-RPC request
+// capabilities derives the advertised capabilities from the registered methods
+func (s *Server) capabilities() map[string]any {
+	capabilities := map[string]any{}
+	experimental := map[string]any{}
+	for method := range s.methods {
+		namespace, _, _ := strings.Cut(method, "/")
+		switch namespace {
+		case "initialize", "ping":
+		case "tools":
+			capabilities["tools"] = listCapability(s.toolsListChanged)
+		case "resources":
+			resources := listCapability(s.resourcesListChanged)
+			if _, ok := s.methods["resources/subscribe"]; ok {
+				resources["subscribe"] = true
+			}
+			capabilities["resources"] = resources
+		case "prompts":
+			capabilities["prompts"] = listCapability(s.promptsListChanged)
+		case "completion":
+			capabilities["completions"] = map[string]any{}
+		case "logging":
+			capabilities["logging"] = map[string]any{}
+		}
+		for _, capability := range s.methodCapabilities[method] {
+			if capability.Value == nil {
+				experimental[capability.Name] = map[string]any{}
+				continue
+			}
+			experimental[capability.Name] = capability.Value
+		}
+	}
+	if len(experimental) > 0 {
+		capabilities["experimental"] = experimental
+	}
+	return capabilities
+}
+
+// listCapability only advertises listChanged when the handler is bound to the notification bus
+func listCapability(listChanged bool) map[string]bool {
+	if !listChanged {
+		return map[string]bool{}
+	}
+	return map[string]bool{"listChanged": true}
+}
+
+// Use appends middleware to the chain every request passes through. The first
+// middleware registered is the outermost. Use must be called before the server
+// starts handling requests.
+func (s *Server) Use(middleware ...mcp.Middleware) {
+	s.middleware = append(s.middleware, middleware...)
+	s.handler = mcp.Chain(s.dispatch, s.middleware...)
+}
+
+// HandleRequest processes a JSON-RPC request through the middleware chain and
+// sends its result or error with the ResponseSender in ctx.
 func (s *Server) HandleRequest(ctx context.Context, req mcp.Request) error {
-	switch req.Method {
-	case "initialize":
-		return s.handleInitialize(ctx, req.ID)
-	case "tools/list":
-		return s.handleToolsList(ctx, req.ID)
-	case "tools/call":
-		return s.handleToolsCall(ctx, req.ID, req)
-	case "resources/list":
-		return s.handleResourcesList(ctx, req.ID)
-	case "resources/read":
-		return s.handleResourcesRead(ctx, req.ID, req)
-	case "prompts/list":
-		return s.handlePromptsList(ctx, req.ID)
-	case "prompts/get":
-		return s.handlePromptsGet(ctx, req.ID, req)
-	case "ping":
-		return s.handlePing(ctx, req.ID)
-	default:
-		return s.sendError(ctx, req.ID, mcp.ErrorCodeMethodNotFound, fmt.Sprintf("Method %s not found", req.Method), nil)
+	// initialize must not be cancelled by the client
+	if session := mcp.SessionFromContext(ctx); session != nil && req.Method != "initialize" {
+		var done func()
+		ctx, done = session.BeginRequest(ctx, req.ID)
+		defer done()
+	}
+
+	result, err := s.handler(ctx, req)
+	if err != nil {
+		if protocolErr, ok := mcp.AsProtocolError(err); ok {
+			return s.sendError(ctx, req.ID, protocolErr.Code, protocolErr.Message, protocolErr.Data)
+		}
+		slog.ErrorContext(ctx, "request failed", "method", req.Method, "error", err)
+		return s.sendError(ctx, req.ID, mcp.ErrorCodeInternalError, "Internal error", err.Error())
 	}
+	return s.sendResponse(ctx, req.ID, result)
+}
+
+// dispatch is the innermost handler. When ctx carries a session, requests are
+// checked against its lifecycle state before they are routed to the
+// registered method.
+func (s *Server) dispatch(ctx context.Context, req mcp.Request) (any, error) {
+	if session := mcp.SessionFromContext(ctx); session != nil {
+		if err := session.CheckRequest(req.Method); err != nil {
+			return nil, mcp.NewError(mcp.ErrorCodeInvalidRequest, fmt.Sprintf("Cannot handle %s: %s", req.Method, err.Error()), map[string]string{"state": session.State().String()})
+		}
+	}
+
+	handler, ok := s.methods[req.Method]
+	if !ok {
+		return nil, mcp.NewError(mcp.ErrorCodeMethodNotFound, fmt.Sprintf("Method %s not found", req.Method), nil)
+	}
+	return handler(ctx, req)
+}
+
+// HandleNotification routes a JSON-RPC notification to its registered handler
+func (s *Server) HandleNotification(ctx context.Context, req mcp.Request) error {
+	handler, ok := s.notificationHandlers[req.Method]
+	if !ok {
+		slog.Info("received notification", "method", req.Method)
+		return nil
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) handleInitialized(ctx context.Context, req mcp.Request) error {
+	session := mcp.SessionFromContext(ctx)
+	if session == nil {
+		return nil
+	}
+	if err := session.MarkInitialized(); err != nil {
+		slog.Warn("unexpected initialized notification", "session", session.ID(), "state", session.State().String(), "error", err)
+		return nil
+	}
+	slog.Info("session initialized", "session", session.ID())
+	return nil
+}
+
+// handleCancelled cancels the in-flight request named by a notifications/cancelled
+func (s *Server) handleCancelled(ctx context.Context, req mcp.Request) error {
+	params, err := parseParamsMap(req.Params)
+	if err != nil {
+		slog.Warn("invalid cancellation notification", "error", err)
+		return nil
+	}
+	requestID, ok := params["requestId"]
+	if !ok || requestID == nil {
+		slog.Warn("invalid cancellation notification", "error", "requestId parameter is required")
+		return nil
+	}
+	reason, _ := params["reason"].(string)
+
+	session := mcp.SessionFromContext(ctx)
+	if session == nil || !session.CancelRequest(requestID) {
+		slog.Debug("ignoring cancellation for unknown or finished request", "requestId", requestID, "reason", reason)
+		return nil
+	}
+	slog.Info("request cancelled by client", "session", session.ID(), "requestId", requestID, "reason", reason)
+	return nil
 }
 
 // Helper methods for sending responses
@@ -91,102 +423,477 @@ func (s *Server) sendResponse(ctx context.Context, id any, result any) error {
 }
 
 func (s *Server) sendError(ctx context.Context, id any, code int, message string, data any) error {
-	if sender := ctx.Value(mcp.ResponseSenderKey); sender != nil {
-		if rs, ok := sender.(mcp.ResponseSender); ok {
-			return rs.SendError(id, code, message, data)
-		}
+	if mcp.RequestCancelled(ctx) {
+		slog.Info("suppressed response to cancelled request", "id", id)
+		return nil
+	}
+	if sender := mcp.ResponseSenderFromContext(ctx); sender != nil {
+		return sender.SendError(id, code, message, data)
 	}
 	// This shouldn't happen in normal operation
 	return fmt.Errorf("no response sender in context")
 }
 
 func (s *Server) sendResponseDirect(ctx context.Context, response mcp.Response) error {
-	if sender := ctx.Value(mcp.ResponseSenderKey); sender != nil {
-		if rs, ok := sender.(mcp.ResponseSender); ok {
-			return rs.SendResponse(response)
-		}
+	if mcp.RequestCancelled(ctx) {
+		slog.Info("suppressed response to cancelled request", "id", response.ID)
+		return nil
+	}
+	if sender := mcp.ResponseSenderFromContext(ctx); sender != nil {
+		return sender.SendResponse(response)
 	}
 	// This shouldn't happen in normal operation
 	return fmt.Errorf("no response sender in context")
 }
 
 // Request handlers
-func (s *Server) handleInitialize(ctx context.Context, id any) error {
-	result, err := s.Initialize(ctx)
+func (s *Server) handleInitialize(ctx context.Context, req mcp.Request) (any, error) {
+	params, err := s.parseInitializeParams(req.Params)
+	if err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid initialize parameters", err.Error())
+	}
+
+	result, err := s.Initialize(ctx, params)
 	if err != nil {
-		return s.sendError(ctx, id, mcp.ErrorCodeInternalError, "Failed to initialize", err.Error())
+		return nil, mcp.NewError(mcp.ErrorCodeInternalError, "Failed to initialize", err.Error())
 	}
-	return s.sendResponse(ctx, id, result)
+	if session := mcp.SessionFromContext(ctx); session != nil {
+		if err := session.BeginInitialize(); err != nil {
+			return nil, mcp.NewError(mcp.ErrorCodeInvalidRequest, "Failed to initialize", err.Error())
+		}
+		session.SetClient(result.ProtocolVersion, params.ClientInfo, params.Capabilities)
+		s.TrackSession(session)
+		slog.Info("negotiated protocol version",
+			"session", session.ID(),
+			"requested", params.ProtocolVersion,
+			"negotiated", result.ProtocolVersion,
+			"client", params.ClientInfo.Name,
+			"clientVersion", params.ClientInfo.Version,
+		)
+	}
+	return result, nil
 }
 
-func (s *Server) handleToolsList(ctx context.Context, id any) error {
-	tools, err := s.toolHandler.ListTools(ctx)
+func (s *Server) handleToolsList(ctx context.Context, req mcp.Request) (any, error) {
+	page, err := s.pageRequest("tools", req.Params)
 	if err != nil {
-		return s.sendError(ctx, id, mcp.ErrorCodeInternalError, "Failed to list tools", err.Error())
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid list parameters", err.Error())
 	}
-	return s.sendResponse(ctx, id, map[string][]mcp.Tool{"tools": tools})
+
+	var tools []mcp.Tool
+	var next string
+	if pager, ok := s.toolHandler.(mcp.ToolPager); ok {
+		tools, next, err = pager.ListToolsPage(ctx, page)
+	} else {
+		tools, next, err = listPage(ctx, page, s.toolHandler.ListTools)
+	}
+	if err != nil {
+		return nil, listError("Failed to list tools", err)
+	}
+	if !supportsStructuredOutput(ctx) {
+		tools = withoutOutputSchemas(tools)
+	}
+	return mcp.ListToolsResult{Tools: tools, NextCursor: s.cursors.Encode("tools", next)}, nil
 }
 
-func (s *Server) handleToolsCall(ctx context.Context, id any, req mcp.Request) error {
+func (s *Server) handleToolsCall(ctx context.Context, req mcp.Request) (any, error) {
 	params, err := s.parseToolCallParams(req.Params)
 	if err != nil {
-		return s.sendError(ctx, id, mcp.ErrorCodeInvalidParams, "Invalid tool call parameters", err.Error())
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid tool call parameters", err.Error())
+	}
+
+	tool, err := s.lookupTool(ctx, params.Name)
+	if err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInternalError, "Failed to look up tool", err.Error())
+	}
+	if err := validateArguments(ctx, tool, params.Arguments); err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid tool arguments", err.Error())
+	}
+
+	if params.Meta != nil {
+		ctx = mcp.WithProgressToken(ctx, params.Meta.ProgressToken)
 	}
 
 	response, err := s.toolHandler.CallTool(ctx, params)
 	if err != nil {
-		return s.sendError(ctx, id, mcp.ErrorCodeInvalidParams, fmt.Sprintf("Tool call failed: %s", err.Error()), nil)
+		if protocolErr, ok := mcp.AsProtocolError(err); ok {
+			slog.WarnContext(ctx, "tool call rejected", "tool", params.Name, "error", err)
+			return nil, protocolErr
+		}
+		// Execution failures are results the model can act on.
+		slog.WarnContext(ctx, "tool call failed", "tool", params.Name, "error", err)
+		return mcp.NewToolErrorResponse(err), nil
+	}
+	if response.IsError {
+		return response, nil
+	}
+
+	if err := validateStructuredContent(tool, response); err != nil {
+		slog.ErrorContext(ctx, "tool returned invalid structured content", "tool", params.Name, "error", err)
+		return nil, mcp.NewError(mcp.ErrorCodeInternalError, "Invalid tool output", invalidOutputData(params.Name, err))
+	}
+	if !supportsStructuredOutput(ctx) {
+		response.StructuredContent = nil
+	}
+	return response, nil
+}
+
+// toolSchemas is a listed tool with its schemas compiled for validation. A
+// nil schema accepts any value unless compiling it failed.
+type toolSchemas struct {
+	tool  mcp.Tool
+	input *schema.Schema
+	// elicitable is input without the required arguments the handler elicits
+	elicitable *schema.Schema
+	output     *schema.Schema
+	inputErr   error
+	outputErr  error
+}
+
+// lookupTool returns the named tool with its compiled schemas, or nil if the
+// handler does not list it and will report the unknown tool itself. Tools are
+// listed and compiled on first use and again after the handler publishes
+// tools/list_changed, so handlers without a notifier must list a fixed set.
+func (s *Server) lookupTool(ctx context.Context, name string) (*toolSchemas, error) {
+	s.toolsMu.Lock()
+	tools, epoch := s.tools, s.toolsEpoch
+	s.toolsMu.Unlock()
+
+	if tools == nil {
+		listed, err := s.toolHandler.ListTools(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tools = s.compileTools(listed)
+
+		s.toolsMu.Lock()
+		// A list change while listing leaves the cache empty for the next call.
+		if s.toolsEpoch == epoch {
+			s.tools = tools
+		}
+		s.toolsMu.Unlock()
+	}
+	return tools[name], nil
+}
+
+// invalidateTools drops the cached tools when the tool handler's list changes
+func (s *Server) invalidateTools(notification mcp.Notification) {
+	if notification.Method != mcp.NotificationToolsListChanged {
+		return
+	}
+	s.toolsMu.Lock()
+	s.tools = nil
+	s.toolsEpoch++
+	s.toolsMu.Unlock()
+}
+
+func (s *Server) compileTools(tools []mcp.Tool) map[string]*toolSchemas {
+	eliciter, _ := s.toolHandler.(mcp.ArgumentEliciter)
+	compiled := make(map[string]*toolSchemas, len(tools))
+	for _, tool := range tools {
+		entry := &toolSchemas{tool: tool}
+		// Tools without a schema type accept any arguments.
+		if tool.InputSchema.Type != "" {
+			entry.input, entry.inputErr = schema.Compile(tool.InputSchema)
+			if elicited := elicitedArguments(eliciter, tool.Name); entry.inputErr == nil && len(elicited) > 0 {
+				relaxed := tool.InputSchema
+				relaxed.Required = slices.DeleteFunc(slices.Clone(relaxed.Required), func(name string) bool {
+					return slices.Contains(elicited, name)
+				})
+				entry.elicitable, entry.inputErr = schema.Compile(relaxed)
+			}
+		}
+		if tool.OutputSchema != nil {
+			entry.output, entry.outputErr = schema.Compile(tool.OutputSchema)
+		}
+		compiled[tool.Name] = entry
+	}
+	return compiled
+}
+
+func elicitedArguments(eliciter mcp.ArgumentEliciter, tool string) []string {
+	if eliciter == nil {
+		return nil
+	}
+	return eliciter.ElicitedArguments(tool)
+}
+
+// validateArguments checks call arguments against the tool's input schema.
+// Required arguments the handler elicits may be missing when the session can
+// elicit.
+func validateArguments(ctx context.Context, tool *toolSchemas, arguments map[string]any) error {
+	if tool == nil {
+		return nil
+	}
+	if tool.inputErr != nil {
+		return tool.inputErr
+	}
+	compiled := tool.input
+	if tool.elicitable != nil && mcp.CanElicit(mcp.SessionFromContext(ctx)) {
+		compiled = tool.elicitable
+	}
+	if compiled == nil {
+		return nil
+	}
+	if arguments == nil {
+		arguments = map[string]any{}
+	}
+	return compiled.Validate(arguments)
+}
+
+// validateStructuredContent checks a tool result against the tool's output schema, if it declares one
+func validateStructuredContent(tool *toolSchemas, response mcp.ToolResponse) error {
+	if tool == nil || (tool.output == nil && tool.outputErr == nil) {
+		return nil
+	}
+	if tool.outputErr != nil {
+		return tool.outputErr
+	}
+	if response.StructuredContent == nil {
+		return fmt.Errorf("tool %s declares an output schema but returned no structured content", tool.tool.Name)
+	}
+	return tool.output.Validate(response.StructuredContent)
+}
+
+// invalidOutputData describes an output schema violation for the client
+func invalidOutputData(tool string, err error) map[string]any {
+	data := map[string]any{"tool": tool, "error": err.Error()}
+	var validationErr *schema.ValidationError
+	if errors.As(err, &validationErr) {
+		data["path"] = validationErr.Path
+		data["error"] = validationErr.Message
+	}
+	return data
+}
+
+// supportsStructuredOutput reports whether the session negotiated a protocol
+// version with tool output schemas and structured content
+func supportsStructuredOutput(ctx context.Context) bool {
+	session := mcp.SessionFromContext(ctx)
+	return session == nil || session.SupportsProtocol(mcp.StructuredProtocolVersion)
+}
+
+// withoutOutputSchemas returns copies of tools with their output schemas removed
+func withoutOutputSchemas(tools []mcp.Tool) []mcp.Tool {
+	stripped := make([]mcp.Tool, len(tools))
+	for i, tool := range tools {
+		tool.OutputSchema = nil
+		stripped[i] = tool
+	}
+	return stripped
+}
+
+func (s *Server) handleResourcesList(ctx context.Context, req mcp.Request) (any, error) {
+	page, err := s.pageRequest("resources", req.Params)
+	if err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid list parameters", err.Error())
+	}
+
+	var resources []mcp.Resource
+	var next string
+	if pager, ok := s.resourceHandler.(mcp.ResourcePager); ok {
+		resources, next, err = pager.ListResourcesPage(ctx, page)
+	} else {
+		resources, next, err = listPage(ctx, page, s.resourceHandler.ListResources)
+	}
+	if err != nil {
+		return nil, listError("Failed to list resources", err)
 	}
-	return s.sendResponse(ctx, id, response)
+	return mcp.ListResourcesResult{Resources: resources, NextCursor: s.cursors.Encode("resources", next)}, nil
 }
 
-func (s *Server) handleResourcesList(ctx context.Context, id any) error {
-	resources, err := s.resourceHandler.ListResources(ctx)
+func (s *Server) handleResourceTemplatesList(ctx context.Context, req mcp.Request) (any, error) {
+	page, err := s.pageRequest("resourceTemplates", req.Params)
 	if err != nil {
-		return s.sendError(ctx, id, mcp.ErrorCodeInternalError, "Failed to list resources", err.Error())
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid list parameters", err.Error())
+	}
+
+	templates := []mcp.ResourceTemplate{}
+	var next string
+	if lister, ok := s.resourceHandler.(mcp.ResourceTemplateHandler); ok {
+		templates, next, err = listPage(ctx, page, lister.ListResourceTemplates)
+		if err != nil {
+			return nil, listError("Failed to list resource templates", err)
+		}
 	}
-	return s.sendResponse(ctx, id, map[string][]mcp.Resource{"resources": resources})
+	return mcp.ListResourceTemplatesResult{ResourceTemplates: templates, NextCursor: s.cursors.Encode("resourceTemplates", next)}, nil
 }
 
-func (s *Server) handleResourcesRead(ctx context.Context, id any, req mcp.Request) error {
+func (s *Server) handleResourcesRead(ctx context.Context, req mcp.Request) (any, error) {
 	params, err := s.parseResourceParams(req.Params)
 	if err != nil {
-		return s.sendError(ctx, id, mcp.ErrorCodeInvalidParams, "Invalid resource read parameters", err.Error())
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid resource read parameters", err.Error())
 	}
 
 	response, err := s.resourceHandler.ReadResource(ctx, params)
 	if err != nil {
-		return s.sendError(ctx, id, mcp.ErrorCodeInvalidParams, fmt.Sprintf("Resource read failed: %s", err.Error()), nil)
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Resource read failed: %s", err.Error()), nil)
 	}
-	return s.sendResponse(ctx, id, response)
+	return response, nil
 }
 
-func (s *Server) handlePromptsList(ctx context.Context, id any) error {
-	prompts, err := s.promptHandler.ListPrompts(ctx)
+func (s *Server) handleResourcesSubscribe(ctx context.Context, req mcp.Request) (any, error) {
+	params, err := s.parseResourceParams(req.Params)
 	if err != nil {
-		return s.sendError(ctx, id, mcp.ErrorCodeInternalError, "Failed to list prompts", err.Error())
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid subscription parameters", err.Error())
+	}
+	if err := s.resourceSubscriber.CheckSubscription(ctx, params.URI); err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Subscription failed: %s", err.Error()), nil)
+	}
+
+	if session := mcp.SessionFromContext(ctx); session != nil {
+		session.Subscribe(params.URI)
+		slog.Info("resource subscribed", "session", session.ID(), "uri", params.URI)
 	}
-	return s.sendResponse(ctx, id, map[string][]mcp.Prompt{"prompts": prompts})
+	return map[string]any{}, nil
 }
 
-func (s *Server) handlePromptsGet(ctx context.Context, id any, req mcp.Request) error {
+func (s *Server) handleResourcesUnsubscribe(ctx context.Context, req mcp.Request) (any, error) {
+	params, err := s.parseResourceParams(req.Params)
+	if err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid subscription parameters", err.Error())
+	}
+
+	if session := mcp.SessionFromContext(ctx); session != nil {
+		session.Unsubscribe(params.URI)
+		slog.Info("resource unsubscribed", "session", session.ID(), "uri", params.URI)
+	}
+	return map[string]any{}, nil
+}
+
+func (s *Server) handlePromptsList(ctx context.Context, req mcp.Request) (any, error) {
+	page, err := s.pageRequest("prompts", req.Params)
+	if err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid list parameters", err.Error())
+	}
+
+	var prompts []mcp.Prompt
+	var next string
+	if pager, ok := s.promptHandler.(mcp.PromptPager); ok {
+		prompts, next, err = pager.ListPromptsPage(ctx, page)
+	} else {
+		prompts, next, err = listPage(ctx, page, s.promptHandler.ListPrompts)
+	}
+	if err != nil {
+		return nil, listError("Failed to list prompts", err)
+	}
+	return mcp.ListPromptsResult{Prompts: prompts, NextCursor: s.cursors.Encode("prompts", next)}, nil
+}
+
+// pageRequest decodes the optional cursor of a list request for the named list
+func (s *Server) pageRequest(list string, params any) (mcp.PageRequest, error) {
+	page := mcp.PageRequest{Limit: s.pageSize}
+	if page.Limit <= 0 {
+		page.Limit = mcp.DefaultPageSize
+	}
+	if params == nil {
+		return page, nil
+	}
+
+	paramsMap, err := parseParamsMap(params)
+	if err != nil {
+		return page, err
+	}
+	cursor, err := optionalStringParam(paramsMap, "cursor")
+	if err != nil {
+		return page, err
+	}
+	page.Cursor, err = s.cursors.Decode(list, cursor)
+	return page, err
+}
+
+// listError reports stale cursors as invalid params and anything else as an internal error
+func listError(message string, err error) error {
+	if errors.Is(err, mcp.ErrInvalidCursor) {
+		return mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid list parameters", err.Error())
+	}
+	return mcp.NewError(mcp.ErrorCodeInternalError, message, err.Error())
+}
+
+// listPage pages a handler list that is only available in full
+func listPage[T any](ctx context.Context, page mcp.PageRequest, list func(context.Context) ([]T, error)) ([]T, string, error) {
+	items, err := list(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return mcp.Paginate(items, page)
+}
+
+func (s *Server) handlePromptsGet(ctx context.Context, req mcp.Request) (any, error) {
 	params, err := s.parsePromptParams(req.Params)
 	if err != nil {
-		return s.sendError(ctx, id, mcp.ErrorCodeInvalidParams, "Invalid prompt parameters", err.Error())
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid prompt parameters", err.Error())
 	}
 
 	response, err := s.promptHandler.GetPrompt(ctx, params)
 	if err != nil {
-		return s.sendError(ctx, id, mcp.ErrorCodeInvalidParams, fmt.Sprintf("Prompt call failed: %s", err.Error()), nil)
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Prompt call failed: %s", err.Error()), nil)
+	}
+	return response, nil
+}
+
+func (s *Server) handleComplete(ctx context.Context, req mcp.Request) (any, error) {
+	params, err := s.parseCompleteParams(req.Params)
+	if err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid completion parameters", err.Error())
 	}
-	return s.sendResponse(ctx, id, response)
+
+	result, err := s.completionHandler.Complete(ctx, params)
+	if err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Completion failed: %s", err.Error()), nil)
+	}
+	return mcp.CompleteResponse{Completion: result}, nil
 }
 
-func (s *Server) handlePing(ctx context.Context, id any) error {
-	return s.sendResponse(ctx, id, map[string]any{})
+func (s *Server) handleSetLevel(ctx context.Context, req mcp.Request) (any, error) {
+	paramsMap, err := parseParamsMap(req.Params)
+	if err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid logging parameters", err.Error())
+	}
+	value, err := requiredStringParam(paramsMap, "level")
+	if err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid logging parameters", err.Error())
+	}
+	level, err := mcp.ParseLoggingLevel(value)
+	if err != nil {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid logging parameters", err.Error())
+	}
+
+	if session := mcp.SessionFromContext(ctx); session != nil {
+		session.SetLogLevel(level)
+		slog.Info("client log level set", "session", session.ID(), "level", string(level))
+	}
+	return map[string]any{}, nil
+}
+
+func (s *Server) handlePing(ctx context.Context, req mcp.Request) (any, error) {
+	return map[string]any{}, nil
 }
 
 // Parameter parsing helpers
+func (s *Server) parseInitializeParams(params any) (mcp.InitializeParams, error) {
+	paramsMap, err := parseParamsMap(params)
+	if err != nil {
+		return mcp.InitializeParams{}, err
+	}
+
+	version, err := requiredStringParam(paramsMap, "protocolVersion")
+	if err != nil {
+		return mcp.InitializeParams{}, err
+	}
+
+	result := mcp.InitializeParams{ProtocolVersion: version}
+	if capabilities, ok := paramsMap["capabilities"].(map[string]any); ok {
+		result.Capabilities = capabilities
+	}
+	if clientInfo, ok := paramsMap["clientInfo"].(map[string]any); ok {
+		result.ClientInfo.Name, _ = clientInfo["name"].(string)
+		result.ClientInfo.Version, _ = clientInfo["version"].(string)
+	}
+
+	return result, nil
+}
+
 func (s *Server) parseToolCallParams(params any) (mcp.ToolCallParams, error) {
 	paramsMap, err := parseParamsMap(params)
 	if err != nil {
@@ -198,11 +905,15 @@ func (s *Server) parseToolCallParams(params any) (mcp.ToolCallParams, error) {
 		return mcp.ToolCallParams{}, err
 	}
 
-	args := optionalArguments(paramsMap)
+	args, err := optionalArguments(paramsMap)
+	if err != nil {
+		return mcp.ToolCallParams{}, err
+	}
 
 	return mcp.ToolCallParams{
 		Name:      name,
 		Arguments: args,
+		Meta:      optionalMeta(paramsMap),
 	}, nil
 }
 
@@ -231,7 +942,10 @@ func (s *Server) parsePromptParams(params any) (mcp.PromptParams, error) {
 		return mcp.PromptParams{}, err
 	}
 
-	args := optionalArguments(paramsMap)
+	args, err := optionalArguments(paramsMap)
+	if err != nil {
+		return mcp.PromptParams{}, err
+	}
 
 	return mcp.PromptParams{
 		Name:      name,
@@ -239,6 +953,65 @@ func (s *Server) parsePromptParams(params any) (mcp.PromptParams, error) {
 	}, nil
 }
 
+func (s *Server) parseCompleteParams(params any) (mcp.CompleteParams, error) {
+	paramsMap, err := parseParamsMap(params)
+	if err != nil {
+		return mcp.CompleteParams{}, err
+	}
+
+	ref, ok := paramsMap["ref"].(map[string]any)
+	if !ok {
+		return mcp.CompleteParams{}, fmt.Errorf("ref parameter is required and must be an object")
+	}
+	refType, err := requiredStringParam(ref, "type")
+	if err != nil {
+		return mcp.CompleteParams{}, err
+	}
+
+	argument, ok := paramsMap["argument"].(map[string]any)
+	if !ok {
+		return mcp.CompleteParams{}, fmt.Errorf("argument parameter is required and must be an object")
+	}
+	argName, err := requiredStringParam(argument, "name")
+	if err != nil {
+		return mcp.CompleteParams{}, err
+	}
+	argValue, err := requiredStringParam(argument, "value")
+	if err != nil {
+		return mcp.CompleteParams{}, err
+	}
+
+	result := mcp.CompleteParams{
+		Ref:      mcp.CompletionReference{Type: refType},
+		Argument: mcp.CompletionArgument{Name: argName, Value: argValue},
+	}
+	switch refType {
+	case mcp.CompletionRefPrompt:
+		if result.Ref.Name, err = requiredStringParam(ref, "name"); err != nil {
+			return mcp.CompleteParams{}, err
+		}
+	case mcp.CompletionRefResource:
+		if result.Ref.URI, err = requiredStringParam(ref, "uri"); err != nil {
+			return mcp.CompleteParams{}, err
+		}
+	default:
+		return mcp.CompleteParams{}, fmt.Errorf("unsupported ref type %q", refType)
+	}
+
+	if completionContext, ok := paramsMap["context"].(map[string]any); ok {
+		if arguments, ok := completionContext["arguments"].(map[string]any); ok {
+			result.Context = &mcp.CompletionContext{Arguments: make(map[string]string, len(arguments))}
+			for name, value := range arguments {
+				if text, ok := value.(string); ok {
+					result.Context.Arguments[name] = text
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
 func parseParamsMap(params any) (map[string]any, error) {
 	if params == nil {
 		return nil, fmt.Errorf("params cannot be nil")
@@ -261,12 +1034,40 @@ func requiredStringParam(paramsMap map[string]any, key string) (string, error) {
 	return value, nil
 }
 
-func optionalArguments(paramsMap map[string]any) map[string]any {
-	if arguments, exists := paramsMap["arguments"]; exists {
-		if argsMap, ok := arguments.(map[string]any); ok {
-			return argsMap
-		}
+func optionalStringParam(paramsMap map[string]any, key string) (string, error) {
+	value, ok := paramsMap[key]
+	if !ok || value == nil {
+		return "", nil
 	}
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s parameter must be a string", key)
+	}
+	return str, nil
+}
 
-	return nil
+// optionalMeta reads the _meta object; only string and number progress tokens are kept
+func optionalMeta(paramsMap map[string]any) *mcp.RequestMeta {
+	meta, ok := paramsMap["_meta"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	switch token := meta["progressToken"].(type) {
+	case string, float64:
+		return &mcp.RequestMeta{ProgressToken: token}
+	default:
+		return &mcp.RequestMeta{}
+	}
+}
+
+func optionalArguments(paramsMap map[string]any) (map[string]any, error) {
+	arguments, exists := paramsMap["arguments"]
+	if !exists || arguments == nil {
+		return nil, nil
+	}
+	argsMap, ok := arguments.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("arguments must be an object")
+	}
+	return argsMap, nil
 }
@@ -3,6 +3,9 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/BearHuddleston/mcp-server-template/pkg/config"
@@ -150,7 +153,7 @@ func TestHandleRequestDispatchSuccess(t *testing.T) {
 	srv, tool, resource, prompt := newServerWithHandlers(t)
 
 	tests := []mcp.Request{
-		{JSONRPC: mcp.JSONRPCVersion, Method: "initialize", ID: 1},
+		{JSONRPC: mcp.JSONRPCVersion, Method: "initialize", ID: 1, Params: map[string]any{"protocolVersion": mcp.ProtocolVersion}},
 		{JSONRPC: mcp.JSONRPCVersion, Method: "tools/list", ID: 2},
 		{JSONRPC: mcp.JSONRPCVersion, Method: "tools/call", ID: 3, Params: map[string]any{"name": "toolA", "arguments": map[string]any{"k": "v"}}},
 		{JSONRPC: mcp.JSONRPCVersion, Method: "resources/list", ID: 4},
@@ -223,11 +226,14 @@ func TestHandleRequestToolsListWithSpecBackedCatalog(t *testing.T) {
 		t.Fatal("expected tools/list response")
 	}
 
-	result, ok := sender.response.Result.(map[string][]mcp.Tool)
+	result, ok := sender.response.Result.(mcp.ListToolsResult)
 	if !ok {
-		t.Fatalf("expected map[string][]mcp.Tool result, got %T", sender.response.Result)
+		t.Fatalf("expected mcp.ListToolsResult result, got %T", sender.response.Result)
 	}
-	toolsAny := result["tools"]
+	if result.NextCursor != "" {
+		t.Fatalf("expected a single page, got next cursor %q", result.NextCursor)
+	}
+	toolsAny := result.Tools
 	if len(toolsAny) != 2 {
 		t.Fatalf("expected 2 tools, got %d", len(toolsAny))
 	}
@@ -312,8 +318,1140 @@ func TestParamHelpers(t *testing.T) {
 		t.Fatal("expected error for non-string required param")
 	}
 
-	args := optionalArguments(map[string]any{"arguments": "not-a-map"})
-	if len(args) != 0 {
-		t.Fatalf("expected empty arguments for non-map input, got %+v", args)
+	if _, err := optionalArguments(map[string]any{"arguments": "not-a-map"}); err == nil {
+		t.Fatal("expected error for non-map arguments")
+	}
+	if args, err := optionalArguments(map[string]any{}); err != nil || args != nil {
+		t.Fatalf("expected absent arguments to be nil, got %+v (%v)", args, err)
+	}
+}
+
+func TestHandleRequestEnforcesSessionLifecycle(t *testing.T) {
+	srv, tool, _, _ := newServerWithHandlers(t)
+	session := mcp.NewSession("session-1")
+
+	send := func(req mcp.Request) *captureSender {
+		t.Helper()
+		sender := &captureSender{}
+		ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+		ctx = mcp.WithSession(ctx, session)
+		if err := srv.HandleRequest(ctx, req); err != nil {
+			t.Fatalf("HandleRequest failed for %s: %v", req.Method, err)
+		}
+		return sender
+	}
+
+	sender := send(mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/call", ID: 1, Params: map[string]any{"name": "toolA"}})
+	if sender.errorCode != mcp.ErrorCodeInvalidRequest {
+		t.Fatalf("expected invalid request before initialize, got %d", sender.errorCode)
+	}
+	if tool.last.Name != "" {
+		t.Fatal("expected tool not to be called before initialize")
+	}
+
+	if sender := send(mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "ping", ID: 2}); sender.response == nil {
+		t.Fatal("expected ping to succeed before initialize")
+	}
+	if sender := send(mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "initialize", ID: 3, Params: map[string]any{"protocolVersion": mcp.ProtocolVersion}}); sender.response == nil {
+		t.Fatal("expected initialize response")
+	}
+	if session.State() != mcp.SessionInitializing {
+		t.Fatalf("expected initializing state, got %s", session.State())
+	}
+	if sender := send(mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "initialize", ID: 4, Params: map[string]any{"protocolVersion": mcp.ProtocolVersion}}); sender.errorCode != mcp.ErrorCodeInvalidRequest {
+		t.Fatalf("expected duplicate initialize to fail, got %d", sender.errorCode)
+	}
+
+	ctx := mcp.WithSession(context.Background(), session)
+	if err := srv.HandleNotification(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "notifications/initialized"}); err != nil {
+		t.Fatalf("HandleNotification failed: %v", err)
+	}
+	if !session.Initialized() {
+		t.Fatal("expected session to be ready after initialized notification")
+	}
+
+	if sender := send(mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/list", ID: 5}); sender.response == nil {
+		t.Fatal("expected tools/list to succeed once ready")
+	}
+
+	session.Close()
+	if sender := send(mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "ping", ID: 6}); sender.errorCode != mcp.ErrorCodeInvalidRequest {
+		t.Fatalf("expected closed session to reject requests, got %d", sender.errorCode)
+	}
+}
+
+func TestInitializeNegotiatesProtocolVersion(t *testing.T) {
+	srv, _, _, _ := newServerWithHandlers(t)
+
+	tests := []struct {
+		name      string
+		requested string
+		want      string
+	}{
+		{name: "latest", requested: mcp.ProtocolVersion, want: mcp.ProtocolVersion},
+		{name: "older supported", requested: mcp.StructuredProtocolVersion, want: mcp.StructuredProtocolVersion},
+		{name: "legacy", requested: mcp.LegacyProtocolVersion, want: mcp.LegacyProtocolVersion},
+		{name: "unknown falls back to latest", requested: "2099-01-01", want: mcp.ProtocolVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := mcp.NewSession("session-1")
+			sender := &captureSender{}
+			ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+			ctx = mcp.WithSession(ctx, session)
+
+			req := mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "initialize", ID: 1, Params: map[string]any{
+				"protocolVersion": tt.requested,
+				"capabilities":    map[string]any{"sampling": map[string]any{}},
+				"clientInfo":      map[string]any{"name": "test-client", "version": "0.1.0"},
+			}}
+			if err := srv.HandleRequest(ctx, req); err != nil {
+				t.Fatalf("HandleRequest failed: %v", err)
+			}
+			if sender.response == nil {
+				t.Fatalf("expected initialize response, got error %q", sender.errorMsg)
+			}
+			result, ok := sender.response.Result.(*mcp.InitializeResponse)
+			if !ok {
+				t.Fatalf("expected *mcp.InitializeResponse, got %T", sender.response.Result)
+			}
+			if result.ProtocolVersion != tt.want {
+				t.Fatalf("expected protocol version %s, got %s", tt.want, result.ProtocolVersion)
+			}
+			if session.ProtocolVersion() != tt.want {
+				t.Fatalf("expected session protocol version %s, got %s", tt.want, session.ProtocolVersion())
+			}
+			if session.ClientInfo().Name != "test-client" || !session.HasClientCapability("sampling") {
+				t.Fatalf("expected client info and capabilities on session, got %+v", session.ClientInfo())
+			}
+		})
+	}
+}
+
+func TestInitializeRequiresProtocolVersion(t *testing.T) {
+	srv, _, _, _ := newServerWithHandlers(t)
+	sender := &captureSender{}
+	ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+
+	req := mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "initialize", ID: 1, Params: map[string]any{"capabilities": map[string]any{}}}
+	if err := srv.HandleRequest(ctx, req); err != nil {
+		t.Fatalf("HandleRequest failed: %v", err)
+	}
+	if sender.errorCode != mcp.ErrorCodeInvalidParams {
+		t.Fatalf("expected invalid params, got %d", sender.errorCode)
+	}
+}
+
+type recordingWriter struct {
+	messages []any
+}
+
+func (w *recordingWriter) WriteMessage(msg any) error {
+	w.messages = append(w.messages, msg)
+	return nil
+}
+
+func TestListChangedAdvertisedOnlyWithNotifier(t *testing.T) {
+	srv, _, _, _ := newServerWithHandlers(t)
+	result, err := srv.Initialize(context.Background(), mcp.InitializeParams{ProtocolVersion: mcp.ProtocolVersion})
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	for _, name := range []string{"tools", "resources", "prompts"} {
+		capability, ok := result.Capabilities[name].(map[string]bool)
+		if !ok || capability["listChanged"] {
+			t.Fatalf("expected %s capability without listChanged, got %#v", name, result.Capabilities[name])
+		}
+	}
+
+	catalog := handlers.NewCatalog()
+	srv, err = New(newTestConfig(), catalog, catalog, catalog)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	result, err = srv.Initialize(context.Background(), mcp.InitializeParams{ProtocolVersion: mcp.ProtocolVersion})
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	for _, name := range []string{"tools", "resources", "prompts"} {
+		capability, ok := result.Capabilities[name].(map[string]bool)
+		if !ok || !capability["listChanged"] {
+			t.Fatalf("expected %s capability with listChanged, got %#v", name, result.Capabilities[name])
+		}
+	}
+}
+
+func TestStatelessServerOmitsSessionCapabilities(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Stateless = true
+	catalog := handlers.NewCatalog()
+	srv, err := New(cfg, catalog, catalog, catalog)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	result, err := srv.Initialize(context.Background(), mcp.InitializeParams{ProtocolVersion: mcp.ProtocolVersion})
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	for _, name := range []string{"tools", "resources", "prompts"} {
+		capability, ok := result.Capabilities[name].(map[string]bool)
+		if !ok || capability["listChanged"] || capability["subscribe"] {
+			t.Fatalf("expected %s capability without listChanged or subscribe, got %#v", name, result.Capabilities[name])
+		}
+	}
+	if _, ok := result.Capabilities["logging"]; ok {
+		t.Fatal("expected no logging capability in stateless mode")
+	}
+
+	for _, method := range []string{"resources/subscribe", "logging/setLevel"} {
+		sender := &captureSender{}
+		ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: method, ID: 1}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		if sender.errorCode != mcp.ErrorCodeMethodNotFound {
+			t.Fatalf("expected %s to be unavailable, got code %d", method, sender.errorCode)
+		}
+	}
+}
+
+func TestNotificationsBroadcastToReadySessions(t *testing.T) {
+	srv, _, _, _ := newServerWithHandlers(t)
+
+	initialize := func(id string, ready bool) (*mcp.Session, *recordingWriter) {
+		t.Helper()
+		session := mcp.NewSession(id)
+		writer := &recordingWriter{}
+		session.SetWriter(writer)
+
+		ctx := mcp.WithSession(context.WithValue(context.Background(), mcp.ResponseSenderKey, &captureSender{}), session)
+		req := mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "initialize", ID: 1, Params: map[string]any{"protocolVersion": mcp.ProtocolVersion}}
+		if err := srv.HandleRequest(ctx, req); err != nil {
+			t.Fatalf("initialize failed: %v", err)
+		}
+		if ready {
+			if err := srv.HandleNotification(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "notifications/initialized"}); err != nil {
+				t.Fatalf("initialized notification failed: %v", err)
+			}
+		}
+		return session, writer
+	}
+
+	_, readyWriter := initialize("ready", true)
+	_, pendingWriter := initialize("pending", false)
+	closed, closedWriter := initialize("closed", true)
+	closed.Close()
+
+	srv.Notifications().Notify(mcp.NotificationToolsListChanged, nil)
+
+	if len(readyWriter.messages) != 1 {
+		t.Fatalf("expected ready session to receive one notification, got %d", len(readyWriter.messages))
+	}
+	notification, ok := readyWriter.messages[0].(mcp.Notification)
+	if !ok || notification.Method != mcp.NotificationToolsListChanged {
+		t.Fatalf("unexpected notification: %#v", readyWriter.messages[0])
+	}
+	if len(pendingWriter.messages) != 0 {
+		t.Fatalf("expected no notification before initialized, got %d", len(pendingWriter.messages))
+	}
+	if len(closedWriter.messages) != 0 {
+		t.Fatalf("expected no notification for closed session, got %d", len(closedWriter.messages))
+	}
+	if sessions := srv.liveSessions(); len(sessions) != 2 {
+		t.Fatalf("expected closed session to be forgotten, got %d sessions", len(sessions))
+	}
+}
+
+type blockingToolHandler struct {
+	testToolHandler
+	started chan struct{}
+	cause   chan error
+}
+
+func (h *blockingToolHandler) CallTool(ctx context.Context, params mcp.ToolCallParams) (mcp.ToolResponse, error) {
+	close(h.started)
+	<-ctx.Done()
+	h.cause <- context.Cause(ctx)
+	return mcp.ToolResponse{}, ctx.Err()
+}
+
+func TestCancelledRequestSuppressesResponse(t *testing.T) {
+	tool := &blockingToolHandler{started: make(chan struct{}), cause: make(chan error, 1)}
+	srv, err := New(newTestConfig(), tool, &testResourceHandler{}, &testPromptHandler{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	session := mcp.NewSession("session-1")
+	session.BeginInitialize()
+	session.MarkInitialized()
+
+	sender := &captureSender{}
+	ctx := mcp.WithSession(context.WithValue(context.Background(), mcp.ResponseSenderKey, sender), session)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/call", ID: float64(3), Params: map[string]any{"name": "slow"}})
+	}()
+	<-tool.started
+
+	cancel := mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: mcp.NotificationCancelled, Params: map[string]any{"requestId": float64(3), "reason": "user aborted"}}
+	if err := srv.HandleNotification(mcp.WithSession(context.Background(), session), cancel); err != nil {
+		t.Fatalf("HandleNotification failed: %v", err)
+	}
+
+	if cause := <-tool.cause; !errors.Is(cause, mcp.ErrRequestCancelled) {
+		t.Fatalf("expected tool context cancelled by client, got %v", cause)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("HandleRequest failed: %v", err)
+	}
+	if sender.response != nil || sender.errorCode != 0 {
+		t.Fatalf("expected no response for cancelled request, got %+v / %d", sender.response, sender.errorCode)
+	}
+}
+
+type progressToolHandler struct {
+	testToolHandler
+	reporter *mcp.ProgressReporter
+}
+
+func (h *progressToolHandler) CallTool(ctx context.Context, params mcp.ToolCallParams) (mcp.ToolResponse, error) {
+	h.reporter = mcp.ProgressFromContext(ctx)
+	return h.testToolHandler.CallTool(ctx, params)
+}
+
+func TestToolsCallProgressToken(t *testing.T) {
+	tool := &progressToolHandler{}
+	srv, err := New(newTestConfig(), tool, &testResourceHandler{}, &testPromptHandler{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	call := func(params map[string]any) {
+		t.Helper()
+		ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, &captureSender{})
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/call", ID: 1, Params: params}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+	}
+
+	call(map[string]any{"name": "toolA"})
+	if tool.reporter.Enabled() || tool.last.Meta != nil {
+		t.Fatal("expected no progress reporter without _meta")
+	}
+
+	call(map[string]any{"name": "toolA", "_meta": map[string]any{"progressToken": "abc"}})
+	if !tool.reporter.Enabled() {
+		t.Fatal("expected progress reporter for progress token")
+	}
+	if tool.last.Meta == nil || tool.last.Meta.ProgressToken != "abc" {
+		t.Fatalf("expected progress token in params, got %+v", tool.last.Meta)
+	}
+}
+
+func TestLoggingSetLevel(t *testing.T) {
+	srv, _, _, _ := newServerWithHandlers(t)
+	result, err := srv.Initialize(context.Background(), mcp.InitializeParams{ProtocolVersion: mcp.ProtocolVersion})
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if _, ok := result.Capabilities["logging"]; !ok {
+		t.Fatalf("expected logging capability, got %#v", result.Capabilities)
+	}
+
+	session := mcp.NewSession("session-1")
+	session.BeginInitialize()
+	session.MarkInitialized()
+
+	setLevel := func(level any) *captureSender {
+		t.Helper()
+		sender := &captureSender{}
+		ctx := mcp.WithSession(context.WithValue(context.Background(), mcp.ResponseSenderKey, sender), session)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "logging/setLevel", ID: 1, Params: map[string]any{"level": level}}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		return sender
+	}
+
+	if sender := setLevel("error"); sender.response == nil {
+		t.Fatalf("expected setLevel response, got error %q", sender.errorMsg)
+	}
+	if session.LogLevel() != mcp.LoggingLevelError {
+		t.Fatalf("expected session level error, got %s", session.LogLevel())
+	}
+	if sender := setLevel("loud"); sender.errorCode != mcp.ErrorCodeInvalidParams {
+		t.Fatalf("expected invalid params for unknown level, got %d", sender.errorCode)
+	}
+	if session.LogLevel() != mcp.LoggingLevelError {
+		t.Fatal("expected invalid level to leave session level unchanged")
+	}
+}
+
+func TestCompletionComplete(t *testing.T) {
+	srv, _, _, _ := newServerWithHandlers(t)
+	result, _ := srv.Initialize(context.Background(), mcp.InitializeParams{ProtocolVersion: mcp.ProtocolVersion})
+	if _, ok := result.Capabilities["completions"]; ok {
+		t.Fatal("expected no completions capability without a completion handler")
+	}
+	sender := &captureSender{}
+	ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+	req := mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "completion/complete", ID: 1, Params: map[string]any{
+		"ref":      map[string]any{"type": "ref/prompt", "name": "itemBrief"},
+		"argument": map[string]any{"name": "item_name", "value": "perf"},
+	}}
+	if err := srv.HandleRequest(ctx, req); err != nil {
+		t.Fatalf("HandleRequest failed: %v", err)
+	}
+	if sender.errorCode != mcp.ErrorCodeMethodNotFound {
+		t.Fatalf("expected method not found without a completion handler, got %d", sender.errorCode)
+	}
+
+	catalog := handlers.NewCatalog()
+	srv, err := New(newTestConfig(), catalog, catalog, catalog)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	result, _ = srv.Initialize(context.Background(), mcp.InitializeParams{ProtocolVersion: mcp.ProtocolVersion})
+	if _, ok := result.Capabilities["completions"]; !ok {
+		t.Fatal("expected completions capability with a completion handler")
+	}
+
+	sender = &captureSender{}
+	ctx = context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+	if err := srv.HandleRequest(ctx, req); err != nil {
+		t.Fatalf("HandleRequest failed: %v", err)
+	}
+	if sender.response == nil {
+		t.Fatalf("expected completion response, got error %q", sender.errorMsg)
+	}
+	completion := sender.response.Result.(mcp.CompleteResponse).Completion
+	if len(completion.Values) != 1 || completion.Values[0] != "Performance Review Bundle" {
+		t.Fatalf("unexpected completion: %+v", completion)
+	}
+
+	sender = &captureSender{}
+	ctx = context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+	req.Params = map[string]any{"ref": map[string]any{"type": "ref/tool", "name": "x"}, "argument": map[string]any{"name": "a", "value": ""}}
+	if err := srv.HandleRequest(ctx, req); err != nil {
+		t.Fatalf("HandleRequest failed: %v", err)
+	}
+	if sender.errorCode != mcp.ErrorCodeInvalidParams {
+		t.Fatalf("expected invalid params for unsupported ref, got %d", sender.errorCode)
+	}
+}
+
+// pagingToolHandler pages natively and records the page requests it receives
+type pagingToolHandler struct {
+	testToolHandler
+	pages []mcp.PageRequest
+}
+
+func (h *pagingToolHandler) ListToolsPage(_ context.Context, page mcp.PageRequest) ([]mcp.Tool, string, error) {
+	h.pages = append(h.pages, page)
+	if page.Cursor == "" {
+		return []mcp.Tool{{Name: "first"}}, "token-2", nil
+	}
+	return []mcp.Tool{{Name: "second"}}, "", nil
+}
+
+func TestListPagination(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PageSize = 1
+	catalog := handlers.NewCatalog()
+	srv, err := New(cfg, catalog, catalog, catalog)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	list := func(t *testing.T, method string, params any) *captureSender {
+		t.Helper()
+		sender := &captureSender{}
+		ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: method, ID: 1, Params: params}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		return sender
+	}
+
+	t.Run("walks every page", func(t *testing.T) {
+		var names []string
+		var params any
+		for range 5 {
+			sender := list(t, "prompts/list", params)
+			if sender.response == nil {
+				t.Fatalf("expected prompts/list response, got error %q", sender.errorMsg)
+			}
+			result := sender.response.Result.(mcp.ListPromptsResult)
+			if len(result.Prompts) != 1 {
+				t.Fatalf("expected one prompt per page, got %d", len(result.Prompts))
+			}
+			names = append(names, result.Prompts[0].Name)
+			if result.NextCursor == "" {
+				break
+			}
+			params = map[string]any{"cursor": result.NextCursor}
+		}
+		if len(names) != 2 || names[0] != "planRecommendation" || names[1] != "itemBrief" {
+			t.Fatalf("unexpected prompts across pages: %v", names)
+		}
+	})
+
+	t.Run("rejects tampered and foreign cursors", func(t *testing.T) {
+		first := list(t, "tools/list", nil).response.Result.(mcp.ListToolsResult)
+		if first.NextCursor == "" {
+			t.Fatal("expected a next cursor for tools")
+		}
+
+		for name, cursor := range map[string]any{
+			"tampered": first.NextCursor + "x",
+			"garbage":  "1",
+			"not text": 5,
+		} {
+			if sender := list(t, "tools/list", map[string]any{"cursor": cursor}); sender.errorCode != mcp.ErrorCodeInvalidParams {
+				t.Fatalf("%s cursor: expected invalid params, got %d", name, sender.errorCode)
+			}
+		}
+		if sender := list(t, "prompts/list", map[string]any{"cursor": first.NextCursor}); sender.errorCode != mcp.ErrorCodeInvalidParams {
+			t.Fatalf("expected tools cursor to be rejected for prompts, got %d", sender.errorCode)
+		}
+	})
+
+	t.Run("native pager", func(t *testing.T) {
+		pager := &pagingToolHandler{}
+		srv, err := New(cfg, pager, &testResourceHandler{}, &testPromptHandler{})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		sender := &captureSender{}
+		ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/list", ID: 1}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		result := sender.response.Result.(mcp.ListToolsResult)
+		if result.NextCursor == "" || result.NextCursor == "token-2" {
+			t.Fatalf("expected an opaque next cursor, got %q", result.NextCursor)
+		}
+
+		sender = &captureSender{}
+		ctx = context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/list", ID: 2, Params: map[string]any{"cursor": result.NextCursor}}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		if len(pager.pages) != 2 || pager.pages[1].Cursor != "token-2" || pager.pages[1].Limit != 1 {
+			t.Fatalf("unexpected page requests: %+v", pager.pages)
+		}
+		if result := sender.response.Result.(mcp.ListToolsResult); result.NextCursor != "" || result.Tools[0].Name != "second" {
+			t.Fatalf("unexpected last page: %+v", result)
+		}
+	})
+
+	t.Run("shared cursor key", func(t *testing.T) {
+		sharedCfg := *cfg
+		sharedCfg.CursorKey = "shared-cursor-signing-key"
+		replicas := make([]*Server, 3)
+		for i := range replicas {
+			keyCfg := &sharedCfg
+			if i == 2 {
+				// The last replica signs with its own random key.
+				keyCfg = cfg
+			}
+			replica, err := New(keyCfg, catalog, catalog, catalog)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			replicas[i] = replica
+		}
+
+		call := func(srv *Server, params any) *captureSender {
+			sender := &captureSender{}
+			ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+			if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/list", ID: 1, Params: params}); err != nil {
+				t.Fatalf("HandleRequest failed: %v", err)
+			}
+			return sender
+		}
+		cursor := call(replicas[0], nil).response.Result.(mcp.ListToolsResult).NextCursor
+		if sender := call(replicas[1], map[string]any{"cursor": cursor}); sender.response == nil {
+			t.Fatalf("expected a replica sharing the key to accept the cursor, got error %v", sender.errorData)
+		}
+		if sender := call(replicas[2], map[string]any{"cursor": cursor}); sender.errorCode != mcp.ErrorCodeInvalidParams {
+			t.Fatalf("expected a replica with another key to reject the cursor, got code %d", sender.errorCode)
+		}
+	})
+}
+
+func TestResourceTemplatesList(t *testing.T) {
+	list := func(t *testing.T, srv *Server) mcp.ListResourceTemplatesResult {
+		t.Helper()
+		sender := &captureSender{}
+		ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "resources/templates/list", ID: 1}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		if sender.response == nil {
+			t.Fatalf("expected resources/templates/list response, got error %q", sender.errorMsg)
+		}
+		return sender.response.Result.(mcp.ListResourceTemplatesResult)
+	}
+
+	srv, _, _, _ := newServerWithHandlers(t)
+	if result := list(t, srv); result.ResourceTemplates == nil || len(result.ResourceTemplates) != 0 {
+		t.Fatalf("expected an empty template list without a template handler, got %+v", result)
+	}
+
+	catalog := handlers.NewCatalog()
+	srv, err := New(newTestConfig(), catalog, catalog, catalog)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if result := list(t, srv); len(result.ResourceTemplates) != 1 || result.ResourceTemplates[0].URITemplate != "catalog://items/{name}" {
+		t.Fatalf("unexpected templates: %+v", result)
+	}
+}
+
+func TestResourceSubscriptions(t *testing.T) {
+	srv, _, _, _ := newServerWithHandlers(t)
+	result, _ := srv.Initialize(context.Background(), mcp.InitializeParams{ProtocolVersion: mcp.ProtocolVersion})
+	if result.Capabilities["resources"].(map[string]bool)["subscribe"] {
+		t.Fatal("expected no subscribe capability without a resource subscriber")
+	}
+	sender := &captureSender{}
+	ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+	if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "resources/subscribe", ID: 1, Params: map[string]any{"uri": "catalog://items"}}); err != nil {
+		t.Fatalf("HandleRequest failed: %v", err)
+	}
+	if sender.errorCode != mcp.ErrorCodeMethodNotFound {
+		t.Fatalf("expected method not found without a resource subscriber, got %d", sender.errorCode)
+	}
+
+	catalog := handlers.NewCatalog()
+	srv, err := New(newTestConfig(), catalog, catalog, catalog)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	result, _ = srv.Initialize(context.Background(), mcp.InitializeParams{ProtocolVersion: mcp.ProtocolVersion})
+	if !result.Capabilities["resources"].(map[string]bool)["subscribe"] {
+		t.Fatal("expected subscribe capability with a resource subscriber")
+	}
+
+	connect := func(id string) (context.Context, *recordingWriter) {
+		t.Helper()
+		session := mcp.NewSession(id)
+		writer := &recordingWriter{}
+		session.SetWriter(writer)
+		ctx := mcp.WithSession(context.WithValue(context.Background(), mcp.ResponseSenderKey, &captureSender{}), session)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "initialize", ID: 1, Params: map[string]any{"protocolVersion": mcp.ProtocolVersion}}); err != nil {
+			t.Fatalf("initialize failed: %v", err)
+		}
+		if err := srv.HandleNotification(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "notifications/initialized"}); err != nil {
+			t.Fatalf("initialized notification failed: %v", err)
+		}
+		return ctx, writer
+	}
+	request := func(ctx context.Context, method, uri string) *captureSender {
+		t.Helper()
+		sender := &captureSender{}
+		ctx = context.WithValue(ctx, mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: method, ID: 2, Params: map[string]any{"uri": uri}}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		return sender
+	}
+
+	subscriberCtx, subscriberWriter := connect("subscriber")
+	_, otherWriter := connect("other")
+
+	if sender := request(subscriberCtx, "resources/subscribe", "catalog://items"); sender.response == nil {
+		t.Fatalf("expected subscribe to succeed, got error %q", sender.errorMsg)
+	}
+	if sender := request(subscriberCtx, "resources/subscribe", "catalog://missing"); sender.errorCode != mcp.ErrorCodeInvalidParams {
+		t.Fatalf("expected invalid params for unknown resource, got %d", sender.errorCode)
+	}
+
+	srv.Notifications().Notify(mcp.NotificationResourcesUpdated, mcp.ResourceUpdatedParams{URI: "catalog://items"})
+	srv.Notifications().Notify(mcp.NotificationResourcesUpdated, mcp.ResourceUpdatedParams{URI: "catalog://items/Incident%20Triage%20Guide"})
+	if len(subscriberWriter.messages) != 1 {
+		t.Fatalf("expected one update for the subscribed resource, got %d", len(subscriberWriter.messages))
+	}
+	if notification := subscriberWriter.messages[0].(mcp.Notification); notification.Method != mcp.NotificationResourcesUpdated {
+		t.Fatalf("unexpected notification: %#v", notification)
+	}
+	if len(otherWriter.messages) != 0 {
+		t.Fatalf("expected no updates for an unsubscribed session, got %d", len(otherWriter.messages))
+	}
+
+	if sender := request(subscriberCtx, "resources/unsubscribe", "catalog://items"); sender.response == nil {
+		t.Fatalf("expected unsubscribe to succeed, got error %q", sender.errorMsg)
+	}
+	srv.Notifications().Notify(mcp.NotificationResourcesUpdated, mcp.ResourceUpdatedParams{URI: "catalog://items"})
+	if len(subscriberWriter.messages) != 1 {
+		t.Fatalf("expected no updates after unsubscribe, got %d", len(subscriberWriter.messages))
+	}
+}
+
+// structuredToolHandler declares an output schema and returns a configurable structured result
+type structuredToolHandler struct {
+	testToolHandler
+	structured any
+}
+
+func (h *structuredToolHandler) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	return []mcp.Tool{{
+		Name:         "count",
+		InputSchema:  mcp.InputSchema{Type: "object"},
+		OutputSchema: &mcp.InputSchema{Type: "object", Properties: map[string]any{"count": map[string]any{"type": "integer"}}, Required: []string{"count"}},
+	}}, nil
+}
+
+func (h *structuredToolHandler) CallTool(ctx context.Context, params mcp.ToolCallParams) (mcp.ToolResponse, error) {
+	return mcp.ToolResponse{Content: []mcp.ContentItem{{Type: "text", Text: "{}"}}, StructuredContent: h.structured}, nil
+}
+
+func TestToolsCallStructuredContent(t *testing.T) {
+	tool := &structuredToolHandler{}
+	srv, err := New(newTestConfig(), tool, &testResourceHandler{}, &testPromptHandler{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	handle := func(t *testing.T, ctx context.Context, method string, params any) *captureSender {
+		t.Helper()
+		sender := &captureSender{}
+		ctx = context.WithValue(ctx, mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: method, ID: 1, Params: params}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		return sender
+	}
+	call := map[string]any{"name": "count"}
+
+	t.Run("valid output", func(t *testing.T) {
+		tool.structured = map[string]any{"count": 3}
+		sender := handle(t, context.Background(), "tools/call", call)
+		if sender.response == nil {
+			t.Fatalf("expected response, got error %q", sender.errorMsg)
+		}
+		if sender.response.Result.(mcp.ToolResponse).StructuredContent == nil {
+			t.Fatal("expected structured content in the result")
+		}
+	})
+
+	t.Run("invalid output", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			structured any
+			wantData   map[string]any
+		}{
+			{name: "missing", structured: nil, wantData: map[string]any{"tool": "count", "error": "tool count declares an output schema but returned no structured content"}},
+			{name: "wrong type", structured: map[string]any{"count": "three"}, wantData: map[string]any{"tool": "count", "path": "$.count", "error": "expected integer, got string"}},
+		}
+		for _, tt := range tests {
+			tool.structured = tt.structured
+			sender := handle(t, context.Background(), "tools/call", call)
+			if sender.errorCode != mcp.ErrorCodeInternalError {
+				t.Fatalf("%s: expected internal error, got %d", tt.name, sender.errorCode)
+			}
+			if !reflect.DeepEqual(sender.errorData, tt.wantData) {
+				t.Fatalf("%s: expected error data %v, got %v", tt.name, tt.wantData, sender.errorData)
+			}
+		}
+	})
+
+	t.Run("legacy protocol", func(t *testing.T) {
+		session := mcp.NewSession("legacy")
+		session.SetClient(mcp.LegacyProtocolVersion, mcp.Implementation{}, nil)
+		session.BeginInitialize()
+		session.MarkInitialized()
+		ctx := mcp.WithSession(context.Background(), session)
+
+		tools := handle(t, ctx, "tools/list", nil).response.Result.(mcp.ListToolsResult).Tools
+		if tools[0].OutputSchema != nil {
+			t.Fatal("expected output schema to be hidden from legacy sessions")
+		}
+		tool.structured = map[string]any{"count": 3}
+		if result := handle(t, ctx, "tools/call", call).response.Result.(mcp.ToolResponse); result.StructuredContent != nil || len(result.Content) != 1 {
+			t.Fatalf("expected text-only result for legacy sessions, got %+v", result)
+		}
+	})
+}
+
+func TestToolsCallErrorTaxonomy(t *testing.T) {
+	srv, tool, _, _ := newServerWithHandlers(t)
+
+	call := func(t *testing.T) *captureSender {
+		t.Helper()
+		sender := &captureSender{}
+		ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/call", ID: 1, Params: map[string]any{"name": "toolA"}}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		return sender
+	}
+
+	t.Run("execution error is a tool result", func(t *testing.T) {
+		tool.callErr = errors.New("item not found: Missing")
+		sender := call(t)
+		if sender.response == nil {
+			t.Fatalf("expected a result, got JSON-RPC error %d %q", sender.errorCode, sender.errorMsg)
+		}
+		result := sender.response.Result.(mcp.ToolResponse)
+		if !result.IsError || len(result.Content) != 1 || result.Content[0].Text != "item not found: Missing" {
+			t.Fatalf("unexpected error result: %+v", result)
+		}
+	})
+
+	t.Run("protocol error is a JSON-RPC error", func(t *testing.T) {
+		tool.callErr = fmt.Errorf("lookup: %w", mcp.NewInvalidParamsError("invalid name parameter: expected string"))
+		sender := call(t)
+		if sender.response != nil || sender.errorCode != mcp.ErrorCodeInvalidParams || sender.errorMsg != "invalid name parameter: expected string" {
+			t.Fatalf("expected invalid params error, got %d %q", sender.errorCode, sender.errorMsg)
+		}
+	})
+}
+
+// schemaToolHandler declares an input schema so the server validates call arguments
+type schemaToolHandler struct {
+	testToolHandler
+	called bool
+}
+
+func (h *schemaToolHandler) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	return []mcp.Tool{{
+		Name: "search",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"query": map[string]any{"type": "string", "minLength": 1, "pattern": "^[a-z ]+$"},
+				"limit": map[string]any{"type": "integer", "minimum": 1, "maximum": 50},
+			},
+			Required:             []string{"query"},
+			AdditionalProperties: false,
+		},
+	}}, nil
+}
+
+func (h *schemaToolHandler) CallTool(ctx context.Context, params mcp.ToolCallParams) (mcp.ToolResponse, error) {
+	h.called = true
+	return h.testToolHandler.CallTool(ctx, params)
+}
+
+func TestToolsCallValidatesArguments(t *testing.T) {
+	tool := &schemaToolHandler{}
+	srv, err := New(newTestConfig(), tool, &testResourceHandler{}, &testPromptHandler{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	call := func(t *testing.T, params map[string]any) *captureSender {
+		t.Helper()
+		tool.called = false
+		sender := &captureSender{}
+		ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/call", ID: 1, Params: params}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		return sender
+	}
+
+	if sender := call(t, map[string]any{"name": "search", "arguments": map[string]any{"query": "runbooks", "limit": float64(10)}}); sender.response == nil || !tool.called {
+		t.Fatalf("expected valid arguments to reach the handler, got error %q", sender.errorData)
+	}
+
+	tests := []struct {
+		name      string
+		arguments any
+		wantData  string
+	}{
+		{name: "missing arguments", arguments: nil, wantData: `$: missing required property "query"`},
+		{name: "wrong type", arguments: map[string]any{"query": float64(3)}, wantData: "$.query: expected string, got integer"},
+		{name: "pattern", arguments: map[string]any{"query": "DROP TABLE"}, wantData: `$.query: value "DROP TABLE" does not match pattern`},
+		{name: "maximum", arguments: map[string]any{"query": "a", "limit": float64(100)}, wantData: "$.limit: value 100 is greater than maximum 50"},
+		{name: "additional property", arguments: map[string]any{"query": "a", "force": true}, wantData: `$: additional property "force" is not allowed`},
+		{name: "not an object", arguments: []any{"a"}, wantData: "arguments must be an object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := call(t, map[string]any{"name": "search", "arguments": tt.arguments})
+			if sender.errorCode != mcp.ErrorCodeInvalidParams {
+				t.Fatalf("expected invalid params error, got code %d", sender.errorCode)
+			}
+			if data, _ := sender.errorData.(string); !strings.Contains(data, tt.wantData) {
+				t.Fatalf("expected error data containing %q, got %q", tt.wantData, data)
+			}
+			if tool.called {
+				t.Fatal("handler should not run for invalid arguments")
+			}
+		})
+	}
+}
+
+// elicitingToolHandler asks the user for a missing query instead of requiring it
+type elicitingToolHandler struct {
+	schemaToolHandler
+}
+
+func (h *elicitingToolHandler) ElicitedArguments(tool string) []string {
+	return []string{"query"}
+}
+
+func TestToolsCallAllowsElicitedArguments(t *testing.T) {
+	tool := &elicitingToolHandler{}
+	srv, err := New(newTestConfig(), tool, &testResourceHandler{}, &testPromptHandler{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	call := func(t *testing.T, capabilities map[string]any, arguments map[string]any) *captureSender {
+		t.Helper()
+		tool.called = false
+		session := mcp.RestoreSession("session-1", mcp.SessionSnapshot{State: mcp.SessionReady, ProtocolVersion: mcp.ProtocolVersion, ClientCapabilities: capabilities})
+		sender := &captureSender{}
+		ctx := mcp.WithSession(context.WithValue(context.Background(), mcp.ResponseSenderKey, sender), session)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/call", ID: 1, Params: map[string]any{"name": "search", "arguments": arguments}}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		return sender
+	}
+	canElicit := map[string]any{"elicitation": map[string]any{}}
+
+	if sender := call(t, canElicit, map[string]any{}); sender.response == nil || !tool.called {
+		t.Fatalf("expected a missing elicited argument to reach the handler, got error %v", sender.errorData)
+	}
+	if sender := call(t, nil, map[string]any{}); sender.errorCode != mcp.ErrorCodeInvalidParams || tool.called {
+		t.Fatalf("expected missing argument to be rejected without elicitation, got code %d", sender.errorCode)
+	}
+	if sender := call(t, canElicit, map[string]any{"query": float64(3)}); sender.errorCode != mcp.ErrorCodeInvalidParams || tool.called {
+		t.Fatalf("expected a present elicited argument to be validated, got code %d", sender.errorCode)
+	}
+}
+
+// reloadingToolHandler lists a search tool whose schema changes on reload
+type reloadingToolHandler struct {
+	testToolHandler
+	notifier mcp.Notifier
+	listed   int
+	required []string
+}
+
+func (h *reloadingToolHandler) BindNotifier(n mcp.Notifier) { h.notifier = n }
+
+func (h *reloadingToolHandler) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	h.listed++
+	return []mcp.Tool{{
+		Name: "search",
+		InputSchema: mcp.InputSchema{
+			Type:       "object",
+			Properties: map[string]any{"query": map[string]any{"type": "string"}},
+			Required:   h.required,
+		},
+	}}, nil
+}
+
+func (h *reloadingToolHandler) reload(required ...string) {
+	h.required = required
+	h.notifier.Notify(mcp.NotificationToolsListChanged, nil)
+}
+
+func TestToolsCallCachesSchemasUntilListChanges(t *testing.T) {
+	tool := &reloadingToolHandler{}
+	srv, err := New(newTestConfig(), tool, &testResourceHandler{}, &testPromptHandler{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	call := func(t *testing.T) *captureSender {
+		t.Helper()
+		sender := &captureSender{}
+		ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/call", ID: 1, Params: map[string]any{"name": "search", "arguments": map[string]any{}}}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		return sender
+	}
+
+	for range 3 {
+		if sender := call(t); sender.response == nil {
+			t.Fatalf("expected call to succeed, got error %v", sender.errorData)
+		}
+	}
+	if tool.listed != 1 {
+		t.Fatalf("expected tools to be listed once, got %d", tool.listed)
+	}
+
+	tool.reload("query")
+	if sender := call(t); sender.errorCode != mcp.ErrorCodeInvalidParams {
+		t.Fatalf("expected the reloaded schema to reject the call, got code %d", sender.errorCode)
+	}
+	if tool.listed != 2 {
+		t.Fatalf("expected tools to be listed again after the list changed, got %d", tool.listed)
+	}
+}
+
+func TestServerMiddleware(t *testing.T) {
+	srv, tool, _, _ := newServerWithHandlers(t)
+
+	type observation struct {
+		method  string
+		session string
+		sender  bool
+		result  any
+		err     error
+	}
+	var observed []observation
+	srv.Use(func(next mcp.Handler) mcp.Handler {
+		return func(ctx context.Context, req mcp.Request) (any, error) {
+			result, err := next(ctx, req)
+			obs := observation{method: req.Method, sender: mcp.ResponseSenderFromContext(ctx) != nil, result: result, err: err}
+			if session := mcp.SessionFromContext(ctx); session != nil {
+				obs.session = session.ID()
+			}
+			observed = append(observed, obs)
+			return result, err
+		}
+	}, func(next mcp.Handler) mcp.Handler {
+		return func(ctx context.Context, req mcp.Request) (any, error) {
+			if req.Method == "tools/call" {
+				if args, _ := req.Params.(map[string]any)["arguments"].(map[string]any); args["token"] != "secret" {
+					return nil, mcp.NewError(mcp.ErrorCodeInvalidRequest, "Unauthorized", nil)
+				}
+			}
+			return next(ctx, req)
+		}
+	})
+
+	session := mcp.NewSession("session-1")
+	send := func(method string, params any) *captureSender {
+		t.Helper()
+		sender := &captureSender{}
+		ctx := context.WithValue(mcp.WithSession(context.Background(), session), mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: method, ID: 1, Params: params}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		return sender
+	}
+
+	if sender := send("tools/list", nil); sender.errorCode != mcp.ErrorCodeInvalidRequest {
+		t.Fatalf("expected lifecycle error before initialize, got %d", sender.errorCode)
+	}
+	send("initialize", map[string]any{"protocolVersion": mcp.ProtocolVersion})
+	if err := srv.HandleNotification(mcp.WithSession(context.Background(), session), mcp.Request{Method: "notifications/initialized"}); err != nil {
+		t.Fatalf("HandleNotification failed: %v", err)
+	}
+
+	if sender := send("tools/call", map[string]any{"name": "toolA", "arguments": map[string]any{}}); sender.errorCode != mcp.ErrorCodeInvalidRequest || sender.errorMsg != "Unauthorized" {
+		t.Fatalf("expected middleware to short-circuit, got %d %q", sender.errorCode, sender.errorMsg)
+	}
+	if tool.last.Name != "" {
+		t.Fatal("tool handler should not run when middleware rejects the call")
+	}
+	if sender := send("tools/call", map[string]any{"name": "toolA", "arguments": map[string]any{"token": "secret"}}); sender.response == nil {
+		t.Fatalf("expected authorized call to succeed, got %d %q", sender.errorCode, sender.errorMsg)
+	}
+
+	if len(observed) != 4 {
+		t.Fatalf("expected 4 observed requests, got %+v", observed)
+	}
+	for _, obs := range observed {
+		if obs.session != "session-1" || !obs.sender {
+			t.Fatalf("middleware should see the session and sender: %+v", obs)
+		}
+	}
+	if _, ok := mcp.AsProtocolError(observed[0].err); !ok || observed[0].method != "tools/list" {
+		t.Fatalf("expected lifecycle error to be observed, got %+v", observed[0])
+	}
+	if _, ok := observed[3].result.(mcp.ToolResponse); !ok || observed[3].err != nil {
+		t.Fatalf("expected tool result to be observed, got %+v", observed[3])
+	}
+}
+
+func TestServerMiddlewareInternalError(t *testing.T) {
+	srv, _, _, _ := newServerWithHandlers(t)
+	srv.Use(mcp.Recover(), func(next mcp.Handler) mcp.Handler {
+		return func(ctx context.Context, req mcp.Request) (any, error) {
+			if req.Method == "ping" {
+				panic("boom")
+			}
+			return nil, errors.New("metrics backend unavailable")
+		}
+	})
+
+	for method, wantData := range map[string]string{"ping": "panic: boom", "tools/list": "metrics backend unavailable"} {
+		sender := &captureSender{}
+		ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: method, ID: 1}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		if sender.errorCode != mcp.ErrorCodeInternalError || sender.errorData != wantData {
+			t.Fatalf("%s: expected internal error %q, got %d %v", method, wantData, sender.errorCode, sender.errorData)
+		}
+	}
+}
+
+func TestRegisterMethod(t *testing.T) {
+	srv, _, _, _ := newServerWithHandlers(t)
+
+	type echoParams struct {
+		Text string `json:"text"`
+	}
+	srv.RegisterMethod("vendor/echo", mcp.TypedHandler(func(ctx context.Context, params echoParams) (map[string]string, error) {
+		return map[string]string{"text": params.Text}, nil
+	}), Capability{Name: "vendor", Value: map[string]any{"echo": true}})
+	srv.RegisterMethod("vendor/silent", func(ctx context.Context, req mcp.Request) (any, error) {
+		return nil, nil
+	})
+	srv.RegisterMethod("tools/list", func(ctx context.Context, req mcp.Request) (any, error) {
+		return mcp.ListToolsResult{Tools: []mcp.Tool{{Name: "override"}}}, nil
+	})
+	var notified []string
+	srv.RegisterNotification("notifications/vendor/ping", func(ctx context.Context, req mcp.Request) error {
+		notified = append(notified, req.Method)
+		return nil
+	})
+
+	call := func(method string, params any) *captureSender {
+		t.Helper()
+		sender := &captureSender{}
+		ctx := context.WithValue(context.Background(), mcp.ResponseSenderKey, sender)
+		if err := srv.HandleRequest(ctx, mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: method, ID: 1, Params: params}); err != nil {
+			t.Fatalf("HandleRequest failed: %v", err)
+		}
+		return sender
+	}
+
+	if sender := call("vendor/echo", map[string]any{"text": "hi"}); sender.response == nil || sender.response.Result.(map[string]string)["text"] != "hi" {
+		t.Fatalf("unexpected echo response: %+v %q", sender.response, sender.errorMsg)
+	}
+	if sender := call("vendor/echo", map[string]any{"text": 5}); sender.errorCode != mcp.ErrorCodeInvalidParams {
+		t.Fatalf("expected invalid params for mistyped params, got %d", sender.errorCode)
+	}
+	if sender := call("tools/list", nil); sender.response.Result.(mcp.ListToolsResult).Tools[0].Name != "override" {
+		t.Fatalf("expected built-in method to be overridden, got %+v", sender.response.Result)
+	}
+
+	if err := srv.HandleNotification(context.Background(), mcp.Request{Method: "notifications/vendor/ping"}); err != nil || len(notified) != 1 {
+		t.Fatalf("expected registered notification handler to run, got %v (%v)", notified, err)
+	}
+
+	result, err := srv.Initialize(context.Background(), mcp.InitializeParams{})
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	experimental, _ := result.Capabilities["experimental"].(map[string]any)
+	if !reflect.DeepEqual(experimental, map[string]any{"vendor": map[string]any{"echo": true}}) {
+		t.Fatalf("expected only the registered vendor capability under experimental, got %+v", result.Capabilities)
+	}
+	for _, capability := range []string{"tools", "resources", "prompts", "logging"} {
+		if _, ok := result.Capabilities[capability]; !ok {
+			t.Fatalf("expected %s capability, got %+v", capability, result.Capabilities)
+		}
+	}
+	if _, ok := result.Capabilities["completions"]; ok {
+		t.Fatal("completions should not be advertised without a completion handler")
+	}
+	if _, ok := result.Capabilities["resources"].(map[string]bool)["subscribe"]; ok {
+		t.Fatal("resources.subscribe should not be advertised without a subscriber")
 	}
 }
@@ -4,10 +4,71 @@ package config
 import (
 	"flag"
 	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// Source identifies where an effective configuration value came from.
+type Source string
+
+// Configuration sources, from lowest to highest precedence.
+const (
+	SourceDefault Source = "default"
+	SourceSpec    Source = "spec"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Setting names tracked for precedence and startup logging.
+const (
+	SettingTransport      = "transport"
+	SettingPort           = "port"
+	SettingSpec           = "spec"
+	SettingRequestTimeout = "request-timeout"
+	SettingAllowedOrigins = "allowed-origins"
+	SettingServerName     = "server-name"
+	SettingServerVersion  = "server-version"
+	SettingPageSize       = "page-size"
+	SettingEventRetention = "event-retention"
+	SettingEventMaxAge    = "event-max-age"
+	SettingEventStoreDir  = "event-store-dir"
+	SettingSessionIdle    = "session-idle-timeout"
+	SettingSessionMaxLife = "session-max-lifetime"
+	SettingMaxSessions    = "max-sessions"
+	SettingSessionDir     = "session-store-dir"
+	SettingStateless      = "stateless"
+	SettingCursorKey      = "cursor-key"
+)
+
+// Environment variables read by ParseFlags.
+const (
+	EnvTransport      = "MCP_TRANSPORT"
+	EnvPort           = "MCP_PORT"
+	EnvSpec           = "MCP_SPEC"
+	EnvRequestTimeout = "MCP_REQUEST_TIMEOUT"
+	EnvAllowedOrigins = "MCP_ALLOWED_ORIGINS"
+	EnvServerName     = "MCP_SERVER_NAME"
+	EnvServerVersion  = "MCP_SERVER_VERSION"
+	EnvPageSize       = "MCP_PAGE_SIZE"
+	EnvEventRetention = "MCP_EVENT_RETENTION"
+	EnvEventMaxAge    = "MCP_EVENT_MAX_AGE"
+	EnvEventStoreDir  = "MCP_EVENT_STORE_DIR"
+	EnvSessionIdle    = "MCP_SESSION_IDLE_TIMEOUT"
+	EnvSessionMaxLife = "MCP_SESSION_MAX_LIFETIME"
+	EnvMaxSessions    = "MCP_MAX_SESSIONS"
+	EnvSessionDir     = "MCP_SESSION_STORE_DIR"
+	EnvStateless      = "MCP_STATELESS"
+	EnvCursorKey      = "MCP_CURSOR_KEY"
+)
+
+// MinCursorKeyLength is the shortest accepted cursor signing key.
+const MinCursorKeyLength = 16
+
+var lookupEnv = os.LookupEnv
+
 // Config holds all configuration for the MCP server
 type Config struct {
 	// Transport settings
@@ -19,6 +80,10 @@ type Config struct {
 	ServerName    string
 	ServerVersion string
 
+	// PageSize is the number of entries returned per tools, resources and prompts
+	// list page; zero uses the protocol package default
+	PageSize int
+
 	// Timeouts
 	RequestTimeout  time.Duration
 	ShutdownTimeout time.Duration
@@ -28,6 +93,64 @@ type Config struct {
 	WriteTimeout   time.Duration
 	IdleTimeout    time.Duration
 	AllowedOrigins []string
+
+	// EventRetention and EventMaxAge bound how many SSE events are kept per
+	// session for Last-Event-ID replay, and for how long; zero uses the
+	// transport package defaults
+	EventRetention int
+	EventMaxAge    time.Duration
+	// EventStoreDir, when set, keeps replayable events on disk instead of in memory
+	EventStoreDir string
+
+	// HTTP sessions expire after SessionIdleTimeout without client activity or
+	// SessionMaxLifetime after creation; at most MaxSessions may be open at once.
+	// Zero disables the limit
+	SessionIdleTimeout time.Duration
+	SessionMaxLifetime time.Duration
+	MaxSessions        int
+	// SessionStoreDir, when set, keeps HTTP session state in a directory that
+	// several server processes on one host can share
+	SessionStoreDir string
+	// Stateless serves every HTTP POST on its own, without sessions. Features
+	// that need a session are disabled
+	Stateless bool
+	// CursorKey signs list pagination cursors. Processes serving the same
+	// clients must share it to accept each other's cursors; empty uses a
+	// random key per process
+	CursorKey string
+
+	sources map[string]Source
+}
+
+// Layer holds the values contributed by a single configuration source.
+// Nil fields, empty strings and empty slices are treated as unset, so a
+// source can explicitly set a numeric setting to zero or a flag to false.
+type Layer struct {
+	TransportType  string
+	HTTPPort       *int
+	SpecPath       string
+	RequestTimeout *time.Duration
+	AllowedOrigins []string
+	ServerName     string
+	ServerVersion  string
+	PageSize       *int
+	EventRetention *int
+	EventMaxAge    *time.Duration
+	EventStoreDir  string
+
+	SessionIdleTimeout *time.Duration
+	SessionMaxLifetime *time.Duration
+	MaxSessions        *int
+	SessionStoreDir    string
+	Stateless          *bool
+	CursorKey          string
+}
+
+// Setting describes the effective value of a configuration setting.
+type Setting struct {
+	Name   string
+	Value  any
+	Source Source
 }
 
 // New creates a new configuration with defaults
@@ -37,16 +160,21 @@ func New() *Config {
 		HTTPPort:        8080,
 		ServerName:      "MCP Template Server",
 		ServerVersion:   "1.1.0",
+		PageSize:        100,
 		RequestTimeout:  30 * time.Second,
 		ShutdownTimeout: 5 * time.Second,
 		ReadTimeout:     30 * time.Second,
 		WriteTimeout:    30 * time.Second,
 		IdleTimeout:     120 * time.Second,
 		AllowedOrigins:  []string{"http://localhost:*", "http://127.0.0.1:*"},
+		EventRetention:  1000,
+		EventMaxAge:     10 * time.Minute,
 	}
 }
 
-// ParseFlags parses command line flags and returns a config
+// ParseFlags parses command line flags and returns a config.
+// Explicitly set flags take precedence over environment variables, which
+// take precedence over defaults.
 func ParseFlags() (*Config, error) {
 	cfg := New()
 
@@ -55,20 +183,204 @@ func ParseFlags() (*Config, error) {
 	specPath := flag.String("spec", cfg.SpecPath, "Path to JSON MCP spec used to configure handlers")
 	requestTimeout := flag.Duration("request-timeout", cfg.RequestTimeout, "Request timeout duration")
 	allowedOrigins := flag.String("allowed-origins", "", "Comma-separated list of allowed CORS origins (e.g., https://example.com,https://api.example.com)")
+	serverName := flag.String("server-name", cfg.ServerName, "Server name reported during initialization")
+	serverVersion := flag.String("server-version", cfg.ServerVersion, "Server version reported during initialization")
+	pageSize := flag.Int("page-size", cfg.PageSize, "Number of entries per tools, resources and prompts list page")
+	eventRetention := flag.Int("event-retention", cfg.EventRetention, "Number of SSE events kept per HTTP session for Last-Event-ID replay")
+	eventMaxAge := flag.Duration("event-max-age", cfg.EventMaxAge, "How long SSE events are kept for Last-Event-ID replay")
+	eventStoreDir := flag.String("event-store-dir", cfg.EventStoreDir, "Directory for file-backed SSE event storage (default in memory)")
+	sessionIdle := flag.Duration("session-idle-timeout", cfg.SessionIdleTimeout, "Close HTTP sessions after this long without client activity (0 disables)")
+	sessionMaxLife := flag.Duration("session-max-lifetime", cfg.SessionMaxLifetime, "Close HTTP sessions this long after they were created (0 disables)")
+	maxSessions := flag.Int("max-sessions", cfg.MaxSessions, "Maximum number of concurrent HTTP sessions (0 disables)")
+	sessionStoreDir := flag.String("session-store-dir", cfg.SessionStoreDir, "Directory for file-backed HTTP session state shared between processes (default in memory)")
+	stateless := flag.Bool("stateless", cfg.Stateless, "Serve HTTP requests without sessions, GET streams, subscriptions or resumability")
+	cursorKey := flag.String("cursor-key", cfg.CursorKey, "Key signing pagination cursors, shared by processes serving the same clients (prefer "+EnvCursorKey+"; default random per process)")
 
 	flag.Parse()
 
-	cfg.TransportType = *transportType
-	cfg.HTTPPort = *port
-	cfg.SpecPath = strings.TrimSpace(*specPath)
-	cfg.RequestTimeout = *requestTimeout
-	if *allowedOrigins != "" {
-		cfg.AllowedOrigins = parseAllowedOrigins(*allowedOrigins)
+	envLayer, err := envLayer()
+	if err != nil {
+		return cfg, err
 	}
+	cfg.Apply(SourceEnv, envLayer)
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "transport":
+			cfg.TransportType = *transportType
+			cfg.setSource(SettingTransport, SourceFlag)
+		case "port":
+			cfg.HTTPPort = *port
+			cfg.setSource(SettingPort, SourceFlag)
+		case "spec":
+			cfg.SpecPath = strings.TrimSpace(*specPath)
+			cfg.setSource(SettingSpec, SourceFlag)
+		case "request-timeout":
+			cfg.RequestTimeout = *requestTimeout
+			cfg.setSource(SettingRequestTimeout, SourceFlag)
+		case "allowed-origins":
+			if origins := parseAllowedOrigins(*allowedOrigins); len(origins) > 0 {
+				cfg.AllowedOrigins = origins
+				cfg.setSource(SettingAllowedOrigins, SourceFlag)
+			}
+		case "server-name":
+			cfg.ServerName = strings.TrimSpace(*serverName)
+			cfg.setSource(SettingServerName, SourceFlag)
+		case "server-version":
+			cfg.ServerVersion = strings.TrimSpace(*serverVersion)
+			cfg.setSource(SettingServerVersion, SourceFlag)
+		case "page-size":
+			cfg.PageSize = *pageSize
+			cfg.setSource(SettingPageSize, SourceFlag)
+		case "event-retention":
+			cfg.EventRetention = *eventRetention
+			cfg.setSource(SettingEventRetention, SourceFlag)
+		case "event-max-age":
+			cfg.EventMaxAge = *eventMaxAge
+			cfg.setSource(SettingEventMaxAge, SourceFlag)
+		case "event-store-dir":
+			cfg.EventStoreDir = strings.TrimSpace(*eventStoreDir)
+			cfg.setSource(SettingEventStoreDir, SourceFlag)
+		case "session-idle-timeout":
+			cfg.SessionIdleTimeout = *sessionIdle
+			cfg.setSource(SettingSessionIdle, SourceFlag)
+		case "session-max-lifetime":
+			cfg.SessionMaxLifetime = *sessionMaxLife
+			cfg.setSource(SettingSessionMaxLife, SourceFlag)
+		case "max-sessions":
+			cfg.MaxSessions = *maxSessions
+			cfg.setSource(SettingMaxSessions, SourceFlag)
+		case "session-store-dir":
+			cfg.SessionStoreDir = strings.TrimSpace(*sessionStoreDir)
+			cfg.setSource(SettingSessionDir, SourceFlag)
+		case "stateless":
+			cfg.Stateless = *stateless
+			cfg.setSource(SettingStateless, SourceFlag)
+		case "cursor-key":
+			cfg.CursorKey = strings.TrimSpace(*cursorKey)
+			cfg.setSource(SettingCursorKey, SourceFlag)
+		}
+	})
 
 	return cfg, cfg.Validate()
 }
 
+// Apply merges the values set in layer into the configuration.
+// A value is only replaced when source has at least the precedence of
+// the source that set the current value.
+func (c *Config) Apply(source Source, layer Layer) {
+	if layer.TransportType != "" && c.canOverride(SettingTransport, source) {
+		c.TransportType = layer.TransportType
+		c.setSource(SettingTransport, source)
+	}
+	if layer.HTTPPort != nil && c.canOverride(SettingPort, source) {
+		c.HTTPPort = *layer.HTTPPort
+		c.setSource(SettingPort, source)
+	}
+	if layer.SpecPath != "" && c.canOverride(SettingSpec, source) {
+		c.SpecPath = layer.SpecPath
+		c.setSource(SettingSpec, source)
+	}
+	if layer.RequestTimeout != nil && c.canOverride(SettingRequestTimeout, source) {
+		c.RequestTimeout = *layer.RequestTimeout
+		c.setSource(SettingRequestTimeout, source)
+	}
+	if len(layer.AllowedOrigins) > 0 && c.canOverride(SettingAllowedOrigins, source) {
+		c.AllowedOrigins = append([]string(nil), layer.AllowedOrigins...)
+		c.setSource(SettingAllowedOrigins, source)
+	}
+	if layer.ServerName != "" && c.canOverride(SettingServerName, source) {
+		c.ServerName = layer.ServerName
+		c.setSource(SettingServerName, source)
+	}
+	if layer.ServerVersion != "" && c.canOverride(SettingServerVersion, source) {
+		c.ServerVersion = layer.ServerVersion
+		c.setSource(SettingServerVersion, source)
+	}
+	if layer.PageSize != nil && c.canOverride(SettingPageSize, source) {
+		c.PageSize = *layer.PageSize
+		c.setSource(SettingPageSize, source)
+	}
+	if layer.EventRetention != nil && c.canOverride(SettingEventRetention, source) {
+		c.EventRetention = *layer.EventRetention
+		c.setSource(SettingEventRetention, source)
+	}
+	if layer.EventMaxAge != nil && c.canOverride(SettingEventMaxAge, source) {
+		c.EventMaxAge = *layer.EventMaxAge
+		c.setSource(SettingEventMaxAge, source)
+	}
+	if layer.EventStoreDir != "" && c.canOverride(SettingEventStoreDir, source) {
+		c.EventStoreDir = layer.EventStoreDir
+		c.setSource(SettingEventStoreDir, source)
+	}
+	if layer.SessionIdleTimeout != nil && c.canOverride(SettingSessionIdle, source) {
+		c.SessionIdleTimeout = *layer.SessionIdleTimeout
+		c.setSource(SettingSessionIdle, source)
+	}
+	if layer.SessionMaxLifetime != nil && c.canOverride(SettingSessionMaxLife, source) {
+		c.SessionMaxLifetime = *layer.SessionMaxLifetime
+		c.setSource(SettingSessionMaxLife, source)
+	}
+	if layer.MaxSessions != nil && c.canOverride(SettingMaxSessions, source) {
+		c.MaxSessions = *layer.MaxSessions
+		c.setSource(SettingMaxSessions, source)
+	}
+	if layer.SessionStoreDir != "" && c.canOverride(SettingSessionDir, source) {
+		c.SessionStoreDir = layer.SessionStoreDir
+		c.setSource(SettingSessionDir, source)
+	}
+	if layer.Stateless != nil && c.canOverride(SettingStateless, source) {
+		c.Stateless = *layer.Stateless
+		c.setSource(SettingStateless, source)
+	}
+	if layer.CursorKey != "" && c.canOverride(SettingCursorKey, source) {
+		c.CursorKey = layer.CursorKey
+		c.setSource(SettingCursorKey, source)
+	}
+}
+
+// Source reports where the effective value of a setting came from.
+func (c *Config) Source(setting string) Source {
+	if source, ok := c.sources[setting]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Settings returns the effective value and source of each tracked setting.
+func (c *Config) Settings() []Setting {
+	return []Setting{
+		{Name: SettingTransport, Value: c.TransportType, Source: c.Source(SettingTransport)},
+		{Name: SettingPort, Value: c.HTTPPort, Source: c.Source(SettingPort)},
+		{Name: SettingSpec, Value: c.SpecPath, Source: c.Source(SettingSpec)},
+		{Name: SettingRequestTimeout, Value: c.RequestTimeout, Source: c.Source(SettingRequestTimeout)},
+		{Name: SettingAllowedOrigins, Value: strings.Join(c.AllowedOrigins, ","), Source: c.Source(SettingAllowedOrigins)},
+		{Name: SettingServerName, Value: c.ServerName, Source: c.Source(SettingServerName)},
+		{Name: SettingServerVersion, Value: c.ServerVersion, Source: c.Source(SettingServerVersion)},
+		{Name: SettingPageSize, Value: c.PageSize, Source: c.Source(SettingPageSize)},
+		{Name: SettingEventRetention, Value: c.EventRetention, Source: c.Source(SettingEventRetention)},
+		{Name: SettingEventMaxAge, Value: c.EventMaxAge, Source: c.Source(SettingEventMaxAge)},
+		{Name: SettingEventStoreDir, Value: c.EventStoreDir, Source: c.Source(SettingEventStoreDir)},
+		{Name: SettingSessionIdle, Value: c.SessionIdleTimeout, Source: c.Source(SettingSessionIdle)},
+		{Name: SettingSessionMaxLife, Value: c.SessionMaxLifetime, Source: c.Source(SettingSessionMaxLife)},
+		{Name: SettingMaxSessions, Value: c.MaxSessions, Source: c.Source(SettingMaxSessions)},
+		{Name: SettingSessionDir, Value: c.SessionStoreDir, Source: c.Source(SettingSessionDir)},
+		{Name: SettingStateless, Value: c.Stateless, Source: c.Source(SettingStateless)},
+		// The key is a secret, so only whether one is set is reported.
+		{Name: SettingCursorKey, Value: c.CursorKey != "", Source: c.Source(SettingCursorKey)},
+	}
+}
+
+// LogAttrs returns one slog group per setting with its value and source.
+func (c *Config) LogAttrs() []any {
+	settings := c.Settings()
+	attrs := make([]any, 0, len(settings))
+	for _, setting := range settings {
+		attrs = append(attrs, slog.Group(setting.Name, "value", setting.Value, "source", string(setting.Source)))
+	}
+	return attrs
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	if c.HTTPPort < 1 || c.HTTPPort > 65535 {
@@ -79,9 +391,160 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid request timeout: %v (must be positive)", c.RequestTimeout)
 	}
 
+	if c.PageSize < 0 {
+		return fmt.Errorf("invalid page size: %d (must not be negative)", c.PageSize)
+	}
+
+	if c.EventRetention < 0 {
+		return fmt.Errorf("invalid event retention: %d (must not be negative)", c.EventRetention)
+	}
+
+	if c.EventMaxAge < 0 {
+		return fmt.Errorf("invalid event max age: %v (must not be negative)", c.EventMaxAge)
+	}
+
+	if c.SessionIdleTimeout < 0 || c.SessionMaxLifetime < 0 {
+		return fmt.Errorf("invalid session timeouts: idle %v, max lifetime %v (must not be negative)", c.SessionIdleTimeout, c.SessionMaxLifetime)
+	}
+
+	if c.MaxSessions < 0 {
+		return fmt.Errorf("invalid max sessions: %d (must not be negative)", c.MaxSessions)
+	}
+
+	if c.CursorKey != "" && len(c.CursorKey) < MinCursorKeyLength {
+		return fmt.Errorf("invalid cursor key: %d characters (must be at least %d)", len(c.CursorKey), MinCursorKeyLength)
+	}
+
 	return nil
 }
 
+func (c *Config) canOverride(setting string, source Source) bool {
+	return sourceRank(source) >= sourceRank(c.Source(setting))
+}
+
+func (c *Config) setSource(setting string, source Source) {
+	if c.sources == nil {
+		c.sources = make(map[string]Source)
+	}
+	c.sources[setting] = source
+}
+
+func sourceRank(source Source) int {
+	switch source {
+	case SourceSpec:
+		return 1
+	case SourceEnv:
+		return 2
+	case SourceFlag:
+		return 3
+	default:
+		return 0
+	}
+}
+
+func envLayer() (Layer, error) {
+	var layer Layer
+
+	if value, ok := lookupEnvTrimmed(EnvTransport); ok {
+		layer.TransportType = value
+	}
+	if value, ok := lookupEnvTrimmed(EnvPort); ok {
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return layer, fmt.Errorf("invalid %s: %q (must be 1-65535)", EnvPort, value)
+		}
+		layer.HTTPPort = &port
+	}
+	if value, ok := lookupEnvTrimmed(EnvSpec); ok {
+		layer.SpecPath = value
+	}
+	if value, ok := lookupEnvTrimmed(EnvRequestTimeout); ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return layer, fmt.Errorf("invalid %s: %q (must be a positive duration)", EnvRequestTimeout, value)
+		}
+		layer.RequestTimeout = &timeout
+	}
+	if value, ok := lookupEnvTrimmed(EnvAllowedOrigins); ok {
+		layer.AllowedOrigins = parseAllowedOrigins(value)
+	}
+	if value, ok := lookupEnvTrimmed(EnvServerName); ok {
+		layer.ServerName = value
+	}
+	if value, ok := lookupEnvTrimmed(EnvServerVersion); ok {
+		layer.ServerVersion = value
+	}
+	if value, ok := lookupEnvTrimmed(EnvPageSize); ok {
+		pageSize, err := strconv.Atoi(value)
+		if err != nil || pageSize < 0 {
+			return layer, fmt.Errorf("invalid %s: %q (must be a non-negative integer)", EnvPageSize, value)
+		}
+		layer.PageSize = &pageSize
+	}
+	if value, ok := lookupEnvTrimmed(EnvEventRetention); ok {
+		retention, err := strconv.Atoi(value)
+		if err != nil || retention < 0 {
+			return layer, fmt.Errorf("invalid %s: %q (must be a non-negative integer)", EnvEventRetention, value)
+		}
+		layer.EventRetention = &retention
+	}
+	if value, ok := lookupEnvTrimmed(EnvEventMaxAge); ok {
+		maxAge, err := time.ParseDuration(value)
+		if err != nil || maxAge < 0 {
+			return layer, fmt.Errorf("invalid %s: %q (must be a non-negative duration)", EnvEventMaxAge, value)
+		}
+		layer.EventMaxAge = &maxAge
+	}
+	if value, ok := lookupEnvTrimmed(EnvEventStoreDir); ok {
+		layer.EventStoreDir = value
+	}
+	if value, ok := lookupEnvTrimmed(EnvSessionIdle); ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return layer, fmt.Errorf("invalid %s: %q (must be a non-negative duration)", EnvSessionIdle, value)
+		}
+		layer.SessionIdleTimeout = &timeout
+	}
+	if value, ok := lookupEnvTrimmed(EnvSessionMaxLife); ok {
+		lifetime, err := time.ParseDuration(value)
+		if err != nil || lifetime < 0 {
+			return layer, fmt.Errorf("invalid %s: %q (must be a non-negative duration)", EnvSessionMaxLife, value)
+		}
+		layer.SessionMaxLifetime = &lifetime
+	}
+	if value, ok := lookupEnvTrimmed(EnvMaxSessions); ok {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return layer, fmt.Errorf("invalid %s: %q (must be a non-negative integer)", EnvMaxSessions, value)
+		}
+		layer.MaxSessions = &limit
+	}
+	if value, ok := lookupEnvTrimmed(EnvSessionDir); ok {
+		layer.SessionStoreDir = value
+	}
+	if value, ok := lookupEnvTrimmed(EnvStateless); ok {
+		stateless, err := strconv.ParseBool(value)
+		if err != nil {
+			return layer, fmt.Errorf("invalid %s: %q (must be a boolean)", EnvStateless, value)
+		}
+		layer.Stateless = &stateless
+	}
+	if value, ok := lookupEnvTrimmed(EnvCursorKey); ok {
+		layer.CursorKey = value
+	}
+
+	return layer, nil
+}
+
+func lookupEnvTrimmed(key string) (string, bool) {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return "", false
+	}
+	value = strings.TrimSpace(value)
+	return value, value != ""
+}
+
 func parseAllowedOrigins(value string) []string {
 	origins := strings.Split(value, ",")
 	normalized := make([]string, 0, len(origins))
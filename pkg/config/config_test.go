@@ -67,6 +67,11 @@ func TestNew(t *testing.T) {
 			t.Errorf("Expected 120s, got %v", cfg.IdleTimeout)
 		}
 	})
+	t.Run("SessionLimits", func(t *testing.T) {
+		if cfg.SessionIdleTimeout != 0 || cfg.SessionMaxLifetime != 0 || cfg.MaxSessions != 0 {
+			t.Errorf("Expected session limits to be disabled, got idle %v, lifetime %v, max %d", cfg.SessionIdleTimeout, cfg.SessionMaxLifetime, cfg.MaxSessions)
+		}
+	})
 }
 
 func TestParseFlags(t *testing.T) {
@@ -202,6 +207,33 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "negative page size",
+			cfg: &Config{
+				HTTPPort:       8080,
+				RequestTimeout: 30 * time.Second,
+				PageSize:       -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative event retention",
+			cfg: &Config{
+				HTTPPort:       8080,
+				RequestTimeout: 30 * time.Second,
+				EventRetention: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "short cursor key",
+			cfg: &Config{
+				HTTPPort:       8080,
+				RequestTimeout: 30 * time.Second,
+				CursorKey:      "short",
+			},
+			wantErr: true,
+		},
 		{
 			name: "negative request timeout",
 			cfg: &Config{
@@ -228,3 +260,288 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFlagsPrecedence(t *testing.T) {
+	parse := func(t *testing.T, args ...string) (*Config, error) {
+		t.Helper()
+		oldArgs := os.Args
+		t.Cleanup(func() { os.Args = oldArgs })
+		os.Args = append([]string{"test"}, args...)
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+		return ParseFlags()
+	}
+
+	t.Run("environment overrides defaults", func(t *testing.T) {
+		t.Setenv(EnvTransport, "http")
+		t.Setenv(EnvPort, "9100")
+		t.Setenv(EnvAllowedOrigins, "https://ops.example.com")
+		t.Setenv(EnvServerName, "Env Server")
+		t.Setenv(EnvPageSize, "25")
+
+		cfg, err := parse(t)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.TransportType != "http" || cfg.Source(SettingTransport) != SourceEnv {
+			t.Errorf("Expected env transport, got %s from %s", cfg.TransportType, cfg.Source(SettingTransport))
+		}
+		if cfg.HTTPPort != 9100 || cfg.Source(SettingPort) != SourceEnv {
+			t.Errorf("Expected env port, got %d from %s", cfg.HTTPPort, cfg.Source(SettingPort))
+		}
+		if len(cfg.AllowedOrigins) != 1 || cfg.AllowedOrigins[0] != "https://ops.example.com" {
+			t.Errorf("Expected env origins, got %v", cfg.AllowedOrigins)
+		}
+		if cfg.ServerName != "Env Server" {
+			t.Errorf("Expected env server name, got %s", cfg.ServerName)
+		}
+		if cfg.PageSize != 25 || cfg.Source(SettingPageSize) != SourceEnv {
+			t.Errorf("Expected env page size, got %d from %s", cfg.PageSize, cfg.Source(SettingPageSize))
+		}
+		if cfg.Source(SettingRequestTimeout) != SourceDefault {
+			t.Errorf("Expected default request timeout source, got %s", cfg.Source(SettingRequestTimeout))
+		}
+	})
+
+	t.Run("flags override environment", func(t *testing.T) {
+		t.Setenv(EnvTransport, "http")
+		t.Setenv(EnvRequestTimeout, "10s")
+
+		cfg, err := parse(t, "-transport", "stdio")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.TransportType != "stdio" || cfg.Source(SettingTransport) != SourceFlag {
+			t.Errorf("Expected flag transport, got %s from %s", cfg.TransportType, cfg.Source(SettingTransport))
+		}
+		if cfg.RequestTimeout != 10*time.Second || cfg.Source(SettingRequestTimeout) != SourceEnv {
+			t.Errorf("Expected env request timeout, got %v from %s", cfg.RequestTimeout, cfg.Source(SettingRequestTimeout))
+		}
+	})
+
+	t.Run("invalid environment values", func(t *testing.T) {
+		t.Setenv(EnvPort, "not-a-port")
+		if _, err := parse(t); err == nil {
+			t.Error("Expected error for invalid port environment variable")
+		}
+	})
+
+	t.Run("event replay settings from environment", func(t *testing.T) {
+		t.Setenv(EnvEventRetention, "50")
+		t.Setenv(EnvEventMaxAge, "90s")
+		t.Setenv(EnvEventStoreDir, "/var/lib/mcp/events")
+
+		cfg, err := parse(t, "-event-retention", "75")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.EventRetention != 75 || cfg.Source(SettingEventRetention) != SourceFlag {
+			t.Errorf("Expected flag event retention, got %d from %s", cfg.EventRetention, cfg.Source(SettingEventRetention))
+		}
+		if cfg.EventMaxAge != 90*time.Second || cfg.Source(SettingEventMaxAge) != SourceEnv {
+			t.Errorf("Expected env event max age, got %v from %s", cfg.EventMaxAge, cfg.Source(SettingEventMaxAge))
+		}
+		if cfg.EventStoreDir != "/var/lib/mcp/events" {
+			t.Errorf("Expected env event store dir, got %q", cfg.EventStoreDir)
+		}
+	})
+
+	t.Run("session limits from environment", func(t *testing.T) {
+		t.Setenv(EnvSessionIdle, "5m")
+		t.Setenv(EnvSessionMaxLife, "2h")
+		t.Setenv(EnvMaxSessions, "50")
+
+		cfg, err := parse(t, "-max-sessions", "0")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.SessionIdleTimeout != 5*time.Minute || cfg.SessionMaxLifetime != 2*time.Hour {
+			t.Errorf("Expected env session timeouts, got idle %v, lifetime %v", cfg.SessionIdleTimeout, cfg.SessionMaxLifetime)
+		}
+		if cfg.MaxSessions != 0 || cfg.Source(SettingMaxSessions) != SourceFlag {
+			t.Errorf("Expected flag to disable the session limit, got %d from %s", cfg.MaxSessions, cfg.Source(SettingMaxSessions))
+		}
+	})
+
+	t.Run("stateless from environment and flag", func(t *testing.T) {
+		t.Setenv(EnvStateless, "true")
+
+		cfg, err := parse(t)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !cfg.Stateless || cfg.Source(SettingStateless) != SourceEnv {
+			t.Errorf("Expected env stateless mode, got %v from %s", cfg.Stateless, cfg.Source(SettingStateless))
+		}
+
+		cfg, err = parse(t, "-stateless=false")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.Stateless || cfg.Source(SettingStateless) != SourceFlag {
+			t.Errorf("Expected flag to disable stateless mode, got %v from %s", cfg.Stateless, cfg.Source(SettingStateless))
+		}
+	})
+
+	t.Run("stateless false overrides spec", func(t *testing.T) {
+		t.Setenv(EnvStateless, "false")
+
+		cfg, err := parse(t)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		cfg.Apply(SourceSpec, Layer{Stateless: ptr(true)})
+		if cfg.Stateless || cfg.Source(SettingStateless) != SourceEnv {
+			t.Errorf("Expected env to disable stateless mode over spec, got %v from %s", cfg.Stateless, cfg.Source(SettingStateless))
+		}
+
+		t.Setenv(EnvStateless, "true")
+		cfg, err = parse(t, "-stateless=false")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		cfg.Apply(SourceSpec, Layer{Stateless: ptr(true)})
+		if cfg.Stateless || cfg.Source(SettingStateless) != SourceFlag {
+			t.Errorf("Expected flag to disable stateless mode over env and spec, got %v from %s", cfg.Stateless, cfg.Source(SettingStateless))
+		}
+	})
+
+	t.Run("cursor key from environment", func(t *testing.T) {
+		t.Setenv(EnvCursorKey, "shared-cursor-signing-key")
+
+		cfg, err := parse(t)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.CursorKey != "shared-cursor-signing-key" || cfg.Source(SettingCursorKey) != SourceEnv {
+			t.Errorf("Expected env cursor key, got %q from %s", cfg.CursorKey, cfg.Source(SettingCursorKey))
+		}
+		for _, setting := range cfg.Settings() {
+			if setting.Name == SettingCursorKey && setting.Value != true {
+				t.Errorf("Expected the cursor key to be reported only as set, got %v", setting.Value)
+			}
+		}
+	})
+
+	t.Run("invalid stateless environment value", func(t *testing.T) {
+		t.Setenv(EnvStateless, "sometimes")
+		if _, err := parse(t); err == nil {
+			t.Error("Expected error for invalid stateless environment variable")
+		}
+	})
+
+	t.Run("invalid max sessions environment value", func(t *testing.T) {
+		t.Setenv(EnvMaxSessions, "-3")
+		if _, err := parse(t); err == nil {
+			t.Error("Expected error for negative max sessions environment variable")
+		}
+	})
+
+	t.Run("invalid event max age environment value", func(t *testing.T) {
+		t.Setenv(EnvEventMaxAge, "soon")
+		if _, err := parse(t); err == nil {
+			t.Error("Expected error for invalid event max age environment variable")
+		}
+	})
+
+	t.Run("invalid page size environment value", func(t *testing.T) {
+		t.Setenv(EnvPageSize, "-1")
+		if _, err := parse(t); err == nil {
+			t.Error("Expected error for negative page size environment variable")
+		}
+	})
+
+	t.Run("zero environment values are explicit", func(t *testing.T) {
+		t.Setenv(EnvMaxSessions, "0")
+		t.Setenv(EnvSessionIdle, "0s")
+
+		cfg, err := parse(t)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		cfg.Apply(SourceSpec, Layer{MaxSessions: ptr(50), SessionIdleTimeout: ptr(time.Minute)})
+		if cfg.MaxSessions != 0 || cfg.Source(SettingMaxSessions) != SourceEnv {
+			t.Errorf("Expected env to disable the session limit over spec, got %d from %s", cfg.MaxSessions, cfg.Source(SettingMaxSessions))
+		}
+		if cfg.SessionIdleTimeout != 0 || cfg.Source(SettingSessionIdle) != SourceEnv {
+			t.Errorf("Expected env to disable the idle timeout over spec, got %v from %s", cfg.SessionIdleTimeout, cfg.Source(SettingSessionIdle))
+		}
+	})
+
+	t.Run("zero flag values are explicit", func(t *testing.T) {
+		cfg, err := parse(t, "-page-size", "0", "-event-retention", "0")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		cfg.Apply(SourceSpec, Layer{PageSize: ptr(25), EventRetention: ptr(10)})
+		if cfg.PageSize != 0 || cfg.Source(SettingPageSize) != SourceFlag {
+			t.Errorf("Expected flag page size over spec, got %d from %s", cfg.PageSize, cfg.Source(SettingPageSize))
+		}
+		if cfg.EventRetention != 0 || cfg.Source(SettingEventRetention) != SourceFlag {
+			t.Errorf("Expected flag event retention over spec, got %d from %s", cfg.EventRetention, cfg.Source(SettingEventRetention))
+		}
+	})
+}
+
+func TestApplyPrecedence(t *testing.T) {
+	cfg := New()
+	cfg.Apply(SourceFlag, Layer{TransportType: "http"})
+	cfg.Apply(SourceEnv, Layer{HTTPPort: ptr(9000)})
+	cfg.Apply(SourceSpec, Layer{
+		TransportType:  "stdio",
+		HTTPPort:       ptr(9500),
+		RequestTimeout: ptr(20 * time.Second),
+		AllowedOrigins: []string{"https://assistant.example.com"},
+		ServerName:     "Spec Server",
+		ServerVersion:  "2.0.0",
+	})
+
+	if cfg.TransportType != "http" {
+		t.Errorf("Expected flag transport to win over spec, got %s", cfg.TransportType)
+	}
+	if cfg.HTTPPort != 9000 {
+		t.Errorf("Expected env port to win over spec, got %d", cfg.HTTPPort)
+	}
+	if cfg.RequestTimeout != 20*time.Second || cfg.Source(SettingRequestTimeout) != SourceSpec {
+		t.Errorf("Expected spec request timeout over default, got %v from %s", cfg.RequestTimeout, cfg.Source(SettingRequestTimeout))
+	}
+	if len(cfg.AllowedOrigins) != 1 || cfg.AllowedOrigins[0] != "https://assistant.example.com" {
+		t.Errorf("Expected spec origins, got %v", cfg.AllowedOrigins)
+	}
+	if cfg.ServerName != "Spec Server" || cfg.ServerVersion != "2.0.0" {
+		t.Errorf("Expected spec server info, got %s %s", cfg.ServerName, cfg.ServerVersion)
+	}
+
+	settings := cfg.Settings()
+	if len(settings) != 17 {
+		t.Fatalf("Expected 17 settings, got %d", len(settings))
+	}
+	if settings[0].Name != SettingTransport || settings[0].Source != SourceFlag {
+		t.Errorf("Expected transport setting from flag, got %+v", settings[0])
+	}
+	if len(cfg.LogAttrs()) != len(settings) {
+		t.Errorf("Expected one log attribute per setting, got %d", len(cfg.LogAttrs()))
+	}
+}
+
+func TestApplyExplicitZero(t *testing.T) {
+	cfg := New()
+	cfg.Apply(SourceSpec, Layer{MaxSessions: ptr(25), EventMaxAge: ptr(time.Minute)})
+	cfg.Apply(SourceEnv, Layer{MaxSessions: ptr(0)})
+	cfg.Apply(SourceFlag, Layer{EventMaxAge: ptr(time.Duration(0))})
+
+	if cfg.MaxSessions != 0 || cfg.Source(SettingMaxSessions) != SourceEnv {
+		t.Errorf("Expected env zero max sessions over spec, got %d from %s", cfg.MaxSessions, cfg.Source(SettingMaxSessions))
+	}
+	if cfg.EventMaxAge != 0 || cfg.Source(SettingEventMaxAge) != SourceFlag {
+		t.Errorf("Expected flag zero event max age over spec, got %v from %s", cfg.EventMaxAge, cfg.Source(SettingEventMaxAge))
+	}
+
+	cfg.Apply(SourceSpec, Layer{})
+	if cfg.Source(SettingPort) != SourceDefault {
+		t.Errorf("Expected an empty layer to leave settings unset, got port from %s", cfg.Source(SettingPort))
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -2,18 +2,51 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
 	"github.com/BearHuddleston/mcp-server-template/pkg/spec"
 )
 
+// Catalogs with at least largeCatalogSize items report progress from tool
+// calls, every progressBatchSize items where they scan the catalog.
+const (
+	largeCatalogSize  = 1000
+	progressBatchSize = 250
+)
+
+// maxFileResourceBytes caps the size of item files served as resources.
+const maxFileResourceBytes = 10 << 20
+
+// catalogMimeType is the default media type of the catalog and item resources.
+const catalogMimeType = "application/json"
+
 type Item struct {
 	Values map[string]any
 }
 
+// Catalog serves tools, resources and prompts from an item catalog.
+// Its contents can be swapped at runtime with Reload.
 type Catalog struct {
+	mu       sync.RWMutex
+	data     *catalogData
+	notifier mcp.Notifier
+}
+
+// catalogData is an immutable snapshot of the catalog contents.
+type catalogData struct {
 	items                []Item
 	lookupField          string
 	detailArgName        string
@@ -30,10 +63,24 @@ type Catalog struct {
 	briefPrompt          mcp.Prompt
 	recommendationText   string
 	briefText            string
+	summarizeTool        *mcp.Tool
+	summarizeArgName     string
+	itemTemplate         *mcp.ResourceTemplate
+	itemURI              *mcp.URITemplate
+	listItemResources    bool
+	files                []catalogFile
+	fileIndex            map[string]catalogFile
+	itemFiles            map[string][]mcp.Resource
+}
+
+// catalogFile is a local file referenced by an item and served as a resource.
+type catalogFile struct {
+	resource mcp.Resource
+	path     string
 }
 
 func NewCatalog() *Catalog {
-	return newCatalog(
+	data := newCatalogData(
 		[]Item{
 			{Values: map[string]any{"name": "Workspace Automation Pack", "cost": 5, "domain": "automation", "summary": "A starter package for automating repetitive engineering tasks."}},
 			{Values: map[string]any{"name": "Incident Triage Guide", "cost": 6, "domain": "operations", "summary": "A practical guide for diagnosing and resolving production incidents."}},
@@ -41,11 +88,11 @@ func NewCatalog() *Catalog {
 		},
 		"name",
 		"name",
-		mcp.Tool{Name: "listItems", Description: "List all item names in the catalog", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{}, Required: []string{}}},
-		mcp.Tool{Name: "getItemDetails", Description: "Get detailed information for a catalog item", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{"name": map[string]string{"type": "string"}}, Required: []string{"name"}}},
-		mcp.Resource{URI: "catalog://items", Name: "catalog"},
-		mcp.Prompt{Name: "planRecommendation", Description: "Get a recommendation for which catalog item to use", Arguments: []mcp.PromptArgument{{Name: "budget", Description: "Budget available in dollars", Required: false}, {Name: "goal", Description: "Primary goal, for example reliability or speed", Required: false}}},
-		mcp.Prompt{Name: "itemBrief", Description: "Get a concise brief for a specific catalog item", Arguments: []mcp.PromptArgument{{Name: "item_name", Description: "Name of the item to summarize", Required: true}}},
+		mcp.Tool{Name: "listItems", Title: "List Catalog Items", Description: "List all item names in the catalog", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{}, Required: []string{}}},
+		mcp.Tool{Name: "getItemDetails", Title: "Get Item Details", Description: "Get detailed information for a catalog item", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{"name": map[string]string{"type": "string"}}, Required: []string{"name"}}},
+		mcp.Resource{URI: "catalog://items", Name: "catalog", Title: "Catalog", Description: "All catalog items"},
+		mcp.Prompt{Name: "planRecommendation", Title: "Plan Recommendation", Description: "Get a recommendation for which catalog item to use", Arguments: []mcp.PromptArgument{{Name: "budget", Description: "Budget available in dollars", Required: false}, {Name: "goal", Description: "Primary goal, for example reliability or speed", Required: false}}},
+		mcp.Prompt{Name: "itemBrief", Title: "Item Brief", Description: "Get a concise brief for a specific catalog item", Arguments: []mcp.PromptArgument{{Name: "item_name", Description: "Name of the item to summarize", Required: true}}},
 		`You are a systems advisor. Recommend the best option for a team%s%s.
 
 Available catalog items:
@@ -60,9 +107,71 @@ Explain your recommendation and include a short tradeoff analysis.`,
 3. Risks or limitations
 4. Quick start steps`,
 	)
+	// The default template is static and known to parse.
+	_ = data.setItemTemplate(mcp.ResourceTemplate{URITemplate: "catalog://items/{name}", Name: "catalog item", Title: "Catalog Item", Description: "A single catalog item by name"}, false)
+	return &Catalog{data: data}
 }
 
 func NewCatalogFromSpec(sp *spec.Spec) (*Catalog, error) {
+	data, err := catalogDataFromSpec(sp)
+	if err != nil {
+		return nil, err
+	}
+	return &Catalog{data: data}, nil
+}
+
+// BindNotifier sets where list_changed notifications are published on Reload.
+func (c *Catalog) BindNotifier(n mcp.Notifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notifier = n
+}
+
+// Reload replaces the catalog contents with those described by sp and
+// publishes a list_changed notification for each list that changed and a
+// resources/updated notification for each resource whose contents changed.
+// The current contents are kept if sp is invalid.
+func (c *Catalog) Reload(sp *spec.Spec) error {
+	data, err := catalogDataFromSpec(sp)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	previous := c.data
+	c.data = data
+	notifier := c.notifier
+	c.mu.Unlock()
+
+	if notifier == nil {
+		return nil
+	}
+	if !reflect.DeepEqual(previous.tools(), data.tools()) {
+		notifier.Notify(mcp.NotificationToolsListChanged, nil)
+	}
+	if !reflect.DeepEqual(previous.resources(), data.resources()) || !reflect.DeepEqual(previous.resourceTemplates(), data.resourceTemplates()) {
+		notifier.Notify(mcp.NotificationResourcesListChanged, nil)
+	}
+	if !reflect.DeepEqual(previous.prompts(), data.prompts()) {
+		notifier.Notify(mcp.NotificationPromptsListChanged, nil)
+	}
+
+	previousContents, contents := previous.resourceContents(), data.resourceContents()
+	for _, uri := range slices.Sorted(maps.Keys(previousContents)) {
+		if text, ok := contents[uri]; !ok || text != previousContents[uri] {
+			notifier.Notify(mcp.NotificationResourcesUpdated, mcp.ResourceUpdatedParams{URI: uri})
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) snapshot() *catalogData {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data
+}
+
+func catalogDataFromSpec(sp *spec.Spec) (*catalogData, error) {
 	if sp == nil {
 		return nil, fmt.Errorf("spec cannot be nil")
 	}
@@ -104,21 +213,76 @@ func NewCatalogFromSpec(sp *spec.Spec) (*Catalog, error) {
 		items = append(items, Item{Values: cloneMap(map[string]any(item))})
 	}
 
-	return newCatalog(
+	data := newCatalogData(
 		items,
 		lookupField,
 		lookupField,
-		mcp.Tool{Name: listTool.Name, Description: listTool.Description, InputSchema: listTool.InputSchema},
-		mcp.Tool{Name: detailTool.Name, Description: detailTool.Description, InputSchema: detailTool.InputSchema},
-		mcp.Resource{URI: resource.URI, Name: resource.Name},
-		mcp.Prompt{Name: recommendationPrompt.Name, Description: recommendationPrompt.Description, Arguments: recommendationPrompt.Arguments},
-		mcp.Prompt{Name: briefPrompt.Name, Description: briefPrompt.Description, Arguments: briefPrompt.Arguments},
+		toolFromSpec(listTool),
+		toolFromSpec(detailTool),
+		mcp.Resource{URI: resource.URI, Name: resource.Name, Title: resource.Title, Description: resource.Description, MimeType: resource.MimeType, Icons: resource.Icons},
+		promptFromSpec(recommendationPrompt),
+		promptFromSpec(briefPrompt),
 		recommendationPrompt.Template,
 		briefPrompt.Template,
-	), nil
+	)
+
+	// The summarize tool is optional and relies on client sampling.
+	if summarizeTool, err := toolByMode(sp.Tools, "summarize_item"); err == nil {
+		tool := toolFromSpec(summarizeTool)
+		// Summaries come from the client's model and differ between calls.
+		tool.Annotations = mcp.ToolAnnotations{ReadOnlyHint: boolPtr(true), DestructiveHint: boolPtr(false), IdempotentHint: boolPtr(false), OpenWorldHint: boolPtr(false)}.Merge(summarizeTool.Annotations)
+		data.summarizeTool = &tool
+		data.summarizeArgName = summarizeTool.InputSchema.Required[0]
+	}
+
+	if err := data.setFiles(sp); err != nil {
+		return nil, err
+	}
+
+	// Per-item resources are optional and addressed through a URI template.
+	if itemResource, err := resourceByMode(sp.Resources, "catalog_item"); err == nil {
+		template := mcp.ResourceTemplate{URITemplate: itemResource.URITemplate, Name: itemResource.Name, Title: itemResource.Title, Description: itemResource.Description, MimeType: itemResource.MimeType, Icons: itemResource.Icons}
+		if err := data.setItemTemplate(template, itemResource.ListItems); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
 }
 
-func newCatalog(items []Item, lookupField string, detailArgName string, listTool mcp.Tool, detailTool mcp.Tool, resource mcp.Resource, recommendationPrompt mcp.Prompt, briefPrompt mcp.Prompt, recommendationText string, briefText string) *Catalog {
+// toolFromSpec copies the client-facing fields of a spec tool.
+func toolFromSpec(tool *spec.ToolSpec) mcp.Tool {
+	return mcp.Tool{Name: tool.Name, Title: tool.Title, Description: tool.Description, InputSchema: tool.InputSchema, OutputSchema: tool.OutputSchema, Annotations: tool.Annotations, Icons: tool.Icons}
+}
+
+// promptFromSpec copies the client-facing fields of a spec prompt.
+func promptFromSpec(prompt *spec.PromptSpec) mcp.Prompt {
+	return mcp.Prompt{Name: prompt.Name, Title: prompt.Title, Description: prompt.Description, Arguments: prompt.Arguments, Icons: prompt.Icons}
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+// lookupToolAnnotations marks tools that only read the in-memory catalog, so
+// clients can run them without asking for approval.
+func lookupToolAnnotations() mcp.ToolAnnotations {
+	return mcp.ToolAnnotations{ReadOnlyHint: boolPtr(true), DestructiveHint: boolPtr(false), IdempotentHint: boolPtr(true), OpenWorldHint: boolPtr(false)}
+}
+
+func newCatalogData(items []Item, lookupField string, detailArgName string, listTool mcp.Tool, detailTool mcp.Tool, resource mcp.Resource, recommendationPrompt mcp.Prompt, briefPrompt mcp.Prompt, recommendationText string, briefText string) *catalogData {
+	if listTool.OutputSchema == nil {
+		listTool.OutputSchema = listItemsOutputSchema()
+	}
+	if detailTool.OutputSchema == nil {
+		detailTool.OutputSchema = itemOutputSchema(lookupField)
+	}
+	listTool.Annotations = lookupToolAnnotations().Merge(listTool.Annotations)
+	detailTool.Annotations = lookupToolAnnotations().Merge(detailTool.Annotations)
+	if resource.MimeType == "" {
+		resource.MimeType = catalogMimeType
+	}
+
 	itemIndex := make(map[string]map[string]any, len(items))
 	itemDetailText := make(map[string]string, len(items))
 	lookupValues := make([]string, 0, len(items))
@@ -144,8 +308,9 @@ func newCatalog(items []Item, lookupField string, detailArgName string, listTool
 	if resourceJSON, err := json.Marshal(serializedItems); err == nil {
 		resourceText = string(resourceJSON)
 	}
+	resource.Size = int64(len(resourceText))
 
-	return &Catalog{
+	return &catalogData{
 		items:                items,
 		lookupField:          lookupField,
 		detailArgName:        detailArgName,
@@ -165,6 +330,110 @@ func newCatalog(items []Item, lookupField string, detailArgName string, listTool
 	}
 }
 
+// setItemTemplate serves each item as a resource addressed by template, whose
+// only variable is the lookup field
+func (c *catalogData) setItemTemplate(template mcp.ResourceTemplate, listItems bool) error {
+	uri, err := mcp.ParseURITemplate(template.URITemplate)
+	if err != nil {
+		return err
+	}
+	if template.MimeType == "" {
+		template.MimeType = catalogMimeType
+	}
+	c.itemTemplate = &template
+	c.itemURI = uri
+	c.listItemResources = listItems
+	return nil
+}
+
+// setFiles serves every file referenced from an item field as a resource
+// below the catalog resource URI and links it from the item's details.
+func (c *catalogData) setFiles(sp *spec.Spec) error {
+	c.fileIndex = make(map[string]catalogFile)
+	c.itemFiles = make(map[string][]mcp.Resource)
+
+	for _, item := range c.items {
+		lookupValue, _ := item.Values[c.lookupField].(string)
+		for _, field := range slices.Sorted(maps.Keys(item.Values)) {
+			ref, ok := spec.ParseFileRef(item.Values[field])
+			if !ok {
+				continue
+			}
+			path, err := sp.ResolveFile(ref)
+			if err != nil {
+				return fmt.Errorf("item %q field %q: %w", lookupValue, field, err)
+			}
+
+			file := catalogFile{resource: mcp.Resource{URI: c.fileURI(ref.Path), Name: ref.Name, MimeType: ref.MimeType}, path: path}
+			// Name and media type follow the reference, not a symlink target.
+			if file.resource.Name == "" {
+				file.resource.Name = filepath.Base(filepath.FromSlash(ref.Path))
+			}
+			if file.resource.MimeType == "" {
+				file.resource.MimeType = detectMimeType(ref.Path)
+			}
+			if info, err := os.Stat(path); err == nil {
+				file.resource.Size = info.Size()
+			}
+			if _, ok := c.fileIndex[file.resource.URI]; !ok {
+				c.fileIndex[file.resource.URI] = file
+				c.files = append(c.files, file)
+			}
+			c.itemFiles[lookupValue] = append(c.itemFiles[lookupValue], file.resource)
+		}
+	}
+	return nil
+}
+
+// fileURI places a spec-relative file path below the catalog resource URI
+func (c *catalogData) fileURI(path string) string {
+	segments := strings.Split(filepath.ToSlash(filepath.Clean(filepath.FromSlash(path))), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimSuffix(c.resource.URI, "/") + "/files/" + strings.Join(segments, "/")
+}
+
+// detectMimeType guesses a file's media type from its extension
+func detectMimeType(path string) string {
+	if mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(path))); err == nil {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
+
+// isTextMimeType reports whether contents of the media type are served as text rather than a blob
+func isTextMimeType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	return slices.Contains([]string{"application/json", "application/xml", "application/yaml", "application/javascript"}, mediaType)
+}
+
+// listItemsOutputSchema describes the structured result of the list tool
+func listItemsOutputSchema() *mcp.InputSchema {
+	return &mcp.InputSchema{
+		Type: "object",
+		Properties: map[string]any{
+			"field":  map[string]any{"type": "string"},
+			"values": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+		Required: []string{"field", "values"},
+	}
+}
+
+// itemOutputSchema is the detail tool's default output schema: any item with its lookup field
+func itemOutputSchema(lookupField string) *mcp.InputSchema {
+	return &mcp.InputSchema{
+		Type:       "object",
+		Properties: map[string]any{lookupField: map[string]any{"type": "string"}},
+		Required:   []string{lookupField},
+	}
+}
+
 func cloneMap(src map[string]any) map[string]any {
 	clone := make(map[string]any, len(src))
 	for k, v := range src {
@@ -201,84 +470,312 @@ func promptByMode(prompts []spec.PromptSpec, mode string) (*spec.PromptSpec, err
 }
 
 func (c *Catalog) ListTools(ctx context.Context) ([]mcp.Tool, error) {
-	return []mcp.Tool{c.listTool, c.detailTool}, nil
+	return c.snapshot().tools(), nil
 }
 
 func (c *Catalog) CallTool(ctx context.Context, params mcp.ToolCallParams) (mcp.ToolResponse, error) {
+	return c.snapshot().callTool(ctx, params)
+}
+
+// ElicitedArguments reports that the item details tool asks for its lookup
+// argument when a call leaves it out.
+func (c *Catalog) ElicitedArguments(tool string) []string {
+	data := c.snapshot()
+	if tool != data.detailTool.Name {
+		return nil
+	}
+	return []string{data.detailArgName}
+}
+
+func (c *catalogData) tools() []mcp.Tool {
+	tools := []mcp.Tool{c.listTool, c.detailTool}
+	if c.summarizeTool != nil {
+		tools = append(tools, *c.summarizeTool)
+	}
+	return tools
+}
+
+func (c *catalogData) callTool(ctx context.Context, params mcp.ToolCallParams) (mcp.ToolResponse, error) {
 	switch params.Name {
 	case c.listTool.Name:
-		return c.listItems(ctx), nil
+		return c.listItems(ctx)
 	case c.detailTool.Name:
 		return c.getItemDetails(ctx, params.Arguments)
-	default:
-		return mcp.ToolResponse{}, fmt.Errorf("tool %s not found", params.Name)
 	}
+	if c.summarizeTool != nil && params.Name == c.summarizeTool.Name {
+		return c.summarizeItem(ctx, params.Arguments)
+	}
+	return mcp.ToolResponse{}, mcp.NewInvalidParamsError("Unknown tool: %s", params.Name)
 }
 
-func (c *Catalog) listItems(ctx context.Context) mcp.ToolResponse {
+func (c *catalogData) listItems(ctx context.Context) (mcp.ToolResponse, error) {
 	select {
 	case <-ctx.Done():
-		return mcp.ToolResponse{
-			Content: []mcp.ContentItem{{Type: "text", Text: `{"error":"request cancelled"}`}},
-		}
+		return mcp.ToolResponse{}, ctx.Err()
 	default:
 	}
 
+	total := len(c.lookupValues)
+	reportProgress(ctx, 0, total, fmt.Sprintf("Listing %d items", total))
+	defer reportProgress(ctx, total, total, fmt.Sprintf("Listed %d items", total))
+
+	structured := map[string]any{"field": c.lookupField, "values": c.lookupValues}
 	if c.listItemsText != "" {
-		return mcp.ToolResponse{Content: []mcp.ContentItem{{Type: "text", Text: c.listItemsText}}}
+		return mcp.ToolResponse{Content: []mcp.ContentItem{{Type: "text", Text: c.listItemsText}}, StructuredContent: structured}, nil
 	}
 
-	namesJSON, err := json.Marshal(map[string]any{"field": c.lookupField, "values": c.lookupValues})
+	namesJSON, err := json.Marshal(structured)
 	if err != nil {
-		return mcp.ToolResponse{
-			Content: []mcp.ContentItem{{Type: "text", Text: fmt.Sprintf(`{"error":"failed to marshal item names: %s"}`, err.Error())}},
-		}
+		return mcp.ToolResponse{}, fmt.Errorf("failed to marshal item names: %w", err)
 	}
 
-	return mcp.ToolResponse{Content: []mcp.ContentItem{{Type: "text", Text: string(namesJSON)}}}
+	return mcp.ToolResponse{Content: []mcp.ContentItem{{Type: "text", Text: string(namesJSON)}}, StructuredContent: structured}, nil
 }
 
-func (c *Catalog) getItemDetails(ctx context.Context, args map[string]any) (mcp.ToolResponse, error) {
+func (c *catalogData) getItemDetails(ctx context.Context, args map[string]any) (mcp.ToolResponse, error) {
 	select {
 	case <-ctx.Done():
 		return mcp.ToolResponse{}, ctx.Err()
 	default:
 	}
 
+	session := mcp.SessionFromContext(ctx)
 	name, ok := args[c.detailArgName].(string)
-	if !ok {
-		return mcp.ToolResponse{}, fmt.Errorf("invalid %s parameter: expected string", c.detailArgName)
+	if !ok || name == "" {
+		if !mcp.CanElicit(session) {
+			if !ok {
+				return mcp.ToolResponse{}, mcp.NewInvalidParamsError("invalid %s parameter: expected string", c.detailArgName)
+			}
+			return mcp.ToolResponse{}, fmt.Errorf("item not found: %s", name)
+		}
+		elicited, err := c.elicitLookup(ctx, session, fmt.Sprintf("Which item do you want details for? Provide its %s.", c.detailArgName), nil)
+		if err != nil {
+			return mcp.ToolResponse{}, err
+		}
+		name = elicited
 	}
 
-	if itemText, ok := c.itemDetailText[name]; ok {
-		return mcp.ToolResponse{Content: []mcp.ContentItem{{Type: "text", Text: itemText}}}, nil
+	if _, ok := c.itemIndex[name]; !ok && mcp.CanElicit(session) {
+		if candidates := c.matchingLookupValues(ctx, name); len(candidates) > 0 {
+			elicited, err := c.elicitLookup(ctx, session, fmt.Sprintf("%q does not name a single item. Which one did you mean?", name), candidates)
+			if err != nil {
+				return mcp.ToolResponse{}, err
+			}
+			name = elicited
+		}
 	}
 
-	if item, ok := c.itemIndex[name]; ok {
+	item, ok := c.itemIndex[name]
+	if !ok {
+		return mcp.ToolResponse{}, fmt.Errorf("item not found: %s", name)
+	}
+	itemText, ok := c.itemDetailText[name]
+	if !ok {
 		itemJSON, err := json.Marshal(item)
 		if err != nil {
 			return mcp.ToolResponse{}, fmt.Errorf("failed to marshal item details: %w", err)
 		}
-		return mcp.ToolResponse{Content: []mcp.ContentItem{{Type: "text", Text: string(itemJSON)}}}, nil
+		itemText = string(itemJSON)
+	}
+
+	content := []mcp.ContentItem{mcp.NewTextContent(itemText)}
+	for _, file := range c.itemFiles[name] {
+		content = append(content, mcp.NewResourceLink(file))
+	}
+	return mcp.ToolResponse{Content: content, StructuredContent: item}, nil
+}
+
+// elicitLookup asks the user for the detail lookup value, restricted to
+// candidates when there are any
+func (c *catalogData) elicitLookup(ctx context.Context, session *mcp.Session, message string, candidates []string) (string, error) {
+	schema := mcp.ElicitationSchemaFor(c.detailTool.InputSchema, c.detailArgName)
+	if len(candidates) > 0 {
+		schema.Properties[c.detailArgName].(map[string]any)["enum"] = candidates
+	}
+
+	result, err := mcp.Elicit(ctx, session, mcp.ElicitParams{Message: message, RequestedSchema: schema})
+	if err != nil {
+		return "", fmt.Errorf("failed to request %s from user: %w", c.detailArgName, err)
+	}
+	if result.Action != mcp.ElicitActionAccept {
+		return "", fmt.Errorf("user did not provide %s (%s)", c.detailArgName, result.Action)
 	}
 
-	return mcp.ToolResponse{}, fmt.Errorf("item not found: %s", name)
+	value, ok := result.Content[c.detailArgName].(string)
+	if !ok {
+		return "", fmt.Errorf("invalid %s parameter: expected string", c.detailArgName)
+	}
+	return value, nil
+}
+
+// matchingLookupValues returns the lookup values containing name, ignoring case
+func (c *catalogData) matchingLookupValues(ctx context.Context, name string) []string {
+	needle := strings.ToLower(strings.TrimSpace(name))
+	if needle == "" {
+		return nil
+	}
+
+	total := len(c.lookupValues)
+	var matches []string
+	for i, value := range c.lookupValues {
+		if i > 0 && i%progressBatchSize == 0 {
+			reportProgress(ctx, i, total, fmt.Sprintf("Searched %d of %d items", i, total))
+		}
+		if strings.Contains(strings.ToLower(value), needle) {
+			matches = append(matches, value)
+		}
+	}
+	reportProgress(ctx, total, total, fmt.Sprintf("Searched %d items", total))
+	return matches
+}
+
+// reportProgress sends a progress notification for catalogs of at least
+// largeCatalogSize items when the client asked for progress
+func reportProgress(ctx context.Context, done, total int, message string) {
+	if total < largeCatalogSize {
+		return
+	}
+	_ = mcp.ProgressFromContext(ctx).Report(float64(done), float64(total), message)
+}
+
+// summarizeItem asks the client's LLM, via sampling, to summarize an item
+func (c *catalogData) summarizeItem(ctx context.Context, args map[string]any) (mcp.ToolResponse, error) {
+	name, ok := args[c.summarizeArgName].(string)
+	if !ok {
+		return mcp.ToolResponse{}, mcp.NewInvalidParamsError("invalid %s parameter: expected string", c.summarizeArgName)
+	}
+	itemText, ok := c.itemDetailText[name]
+	if !ok {
+		return mcp.ToolResponse{}, fmt.Errorf("item not found: %s", name)
+	}
+
+	result, err := mcp.CreateMessage(ctx, mcp.SessionFromContext(ctx), mcp.CreateMessageParams{
+		Messages: []mcp.SamplingMessage{{
+			Role:    "user",
+			Content: mcp.ContentItem{Type: "text", Text: fmt.Sprintf("Summarize this catalog item in two or three sentences:\n%s", itemText)},
+		}},
+		SystemPrompt: "You write short, factual summaries of catalog items for engineering teams.",
+		MaxTokens:    300,
+	})
+	if err != nil {
+		return mcp.ToolResponse{}, fmt.Errorf("failed to summarize item %s: %w", name, err)
+	}
+
+	return mcp.ToolResponse{Content: []mcp.ContentItem{{Type: "text", Text: result.Content.Text}}}, nil
 }
 
 func (c *Catalog) ListResources(ctx context.Context) ([]mcp.Resource, error) {
-	return []mcp.Resource{c.resource}, nil
+	return c.snapshot().resources(), nil
 }
 
 func (c *Catalog) ReadResource(ctx context.Context, params mcp.ResourceParams) (mcp.ResourceResponse, error) {
+	return c.snapshot().readResource(params)
+}
+
+// ListResourceTemplates returns the per-item resource template, if configured.
+func (c *Catalog) ListResourceTemplates(ctx context.Context) ([]mcp.ResourceTemplate, error) {
+	return c.snapshot().resourceTemplates(), nil
+}
+
+func (c *catalogData) resources() []mcp.Resource {
+	resources := []mcp.Resource{c.resource}
+	if c.itemURI != nil && c.listItemResources {
+		for _, value := range c.lookupValues {
+			resources = append(resources, mcp.Resource{URI: c.itemURI.Expand(map[string]string{c.lookupField: value}), Name: value, MimeType: c.itemTemplate.MimeType, Size: int64(len(c.itemDetailText[value]))})
+		}
+	}
+	for _, file := range c.files {
+		resources = append(resources, file.resource)
+	}
+	return resources
+}
+
+func (c *catalogData) resourceTemplates() []mcp.ResourceTemplate {
+	if c.itemTemplate == nil {
+		return []mcp.ResourceTemplate{}
+	}
+	return []mcp.ResourceTemplate{*c.itemTemplate}
+}
+
+// resourceContents maps every readable resource URI, including one per item
+// when an item template is configured, to its text
+func (c *catalogData) resourceContents() map[string]string {
+	contents := map[string]string{c.resource.URI: c.resourceText}
+	if c.itemURI != nil {
+		for value, text := range c.itemDetailText {
+			contents[c.itemURI.Expand(map[string]string{c.lookupField: value})] = text
+		}
+	}
+	return contents
+}
+
+// CheckSubscription accepts the catalog resource and, with an item template,
+// the canonical URI of each item.
+func (c *Catalog) CheckSubscription(ctx context.Context, uri string) error {
+	return c.snapshot().checkSubscription(uri)
+}
+
+func (c *catalogData) checkSubscription(uri string) error {
+	if uri == c.resource.URI {
+		return nil
+	}
+	if c.itemURI != nil {
+		if vars, ok := c.itemURI.Match(uri); ok {
+			if _, ok := c.itemIndex[vars[c.lookupField]]; ok {
+				// Updates are published for the canonical encoding only.
+				if canonical := c.itemURI.Expand(vars); canonical != uri {
+					return fmt.Errorf("resource %s must be addressed as %s", uri, canonical)
+				}
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("resource not found: %s", uri)
+}
+
+func (c *catalogData) readResource(params mcp.ResourceParams) (mcp.ResourceResponse, error) {
 	if params.URI == c.resource.URI {
 		return c.getCatalogResource()
 	}
+	if file, ok := c.fileIndex[params.URI]; ok {
+		return readFileResource(file)
+	}
+	if c.itemURI != nil {
+		if vars, ok := c.itemURI.Match(params.URI); ok {
+			if itemText, ok := c.itemDetailText[vars[c.lookupField]]; ok {
+				return mcp.ResourceResponse{Contents: []mcp.ResourceContent{{URI: params.URI, MimeType: c.itemTemplate.MimeType, Text: itemText}}}, nil
+			}
+		}
+	}
 	return mcp.ResourceResponse{}, fmt.Errorf("resource not found: %s", params.URI)
 }
 
-func (c *Catalog) getCatalogResource() (mcp.ResourceResponse, error) {
+// readFileResource reads an item file from disk, as text for textual media types and as a blob otherwise
+func readFileResource(file catalogFile) (mcp.ResourceResponse, error) {
+	info, err := os.Stat(file.path)
+	if err != nil {
+		return mcp.ResourceResponse{}, fmt.Errorf("failed to read %s: %w", file.resource.URI, err)
+	}
+	if info.Size() > maxFileResourceBytes {
+		return mcp.ResourceResponse{}, fmt.Errorf("resource %s is larger than %d bytes", file.resource.URI, maxFileResourceBytes)
+	}
+	data, err := os.ReadFile(file.path)
+	if err != nil {
+		return mcp.ResourceResponse{}, fmt.Errorf("failed to read %s: %w", file.resource.URI, err)
+	}
+
+	content := mcp.ResourceContent{URI: file.resource.URI, MimeType: file.resource.MimeType}
+	if isTextMimeType(content.MimeType) && utf8.Valid(data) {
+		content.Text = string(data)
+	} else {
+		content.Blob = base64.StdEncoding.EncodeToString(data)
+	}
+	return mcp.ResourceResponse{Contents: []mcp.ResourceContent{content}}, nil
+}
+
+func (c *catalogData) getCatalogResource() (mcp.ResourceResponse, error) {
 	if c.resourceText != "" {
-		return mcp.ResourceResponse{Contents: []mcp.ResourceContent{{URI: c.resource.URI, Text: c.resourceText}}}, nil
+		return mcp.ResourceResponse{Contents: []mcp.ResourceContent{{URI: c.resource.URI, MimeType: c.resource.MimeType, Text: c.resourceText}}}, nil
 	}
 
 	itemsJSON, err := json.Marshal(c.serializedItems)
@@ -286,14 +783,22 @@ func (c *Catalog) getCatalogResource() (mcp.ResourceResponse, error) {
 		return mcp.ResourceResponse{}, fmt.Errorf("failed to marshal catalog: %w", err)
 	}
 
-	return mcp.ResourceResponse{Contents: []mcp.ResourceContent{{URI: c.resource.URI, Text: string(itemsJSON)}}}, nil
+	return mcp.ResourceResponse{Contents: []mcp.ResourceContent{{URI: c.resource.URI, MimeType: c.resource.MimeType, Text: string(itemsJSON)}}}, nil
 }
 
 func (c *Catalog) ListPrompts(ctx context.Context) ([]mcp.Prompt, error) {
-	return []mcp.Prompt{c.recommendationPrompt, c.briefPrompt}, nil
+	return c.snapshot().prompts(), nil
 }
 
 func (c *Catalog) GetPrompt(ctx context.Context, params mcp.PromptParams) (mcp.PromptResponse, error) {
+	return c.snapshot().getPrompt(params)
+}
+
+func (c *catalogData) prompts() []mcp.Prompt {
+	return []mcp.Prompt{c.recommendationPrompt, c.briefPrompt}
+}
+
+func (c *catalogData) getPrompt(params mcp.PromptParams) (mcp.PromptResponse, error) {
 	switch params.Name {
 	case c.recommendationPrompt.Name:
 		return c.createPlanRecommendationPrompt(params.Arguments), nil
@@ -304,7 +809,45 @@ func (c *Catalog) GetPrompt(ctx context.Context, params mcp.PromptParams) (mcp.P
 	}
 }
 
-func (c *Catalog) createPlanRecommendationPrompt(args map[string]any) mcp.PromptResponse {
+// Complete suggests catalog lookup values for the item brief prompt's argument
+// and the item resource template's variable.
+func (c *Catalog) Complete(ctx context.Context, params mcp.CompleteParams) (mcp.CompletionResult, error) {
+	return c.snapshot().complete(params)
+}
+
+func (c *catalogData) complete(params mcp.CompleteParams) (mcp.CompletionResult, error) {
+	switch params.Ref.Type {
+	case mcp.CompletionRefPrompt:
+		if params.Ref.Name != c.briefPrompt.Name && params.Ref.Name != c.recommendationPrompt.Name {
+			return mcp.CompletionResult{}, fmt.Errorf("prompt %s not found", params.Ref.Name)
+		}
+		// Only the brief's item argument has a closed set of values.
+		if params.Ref.Name == c.briefPrompt.Name && params.Argument.Name == c.briefArgName() {
+			return mcp.NewCompletionResult(mcp.RankCompletions(c.lookupValues, params.Argument.Value)), nil
+		}
+	case mcp.CompletionRefResource:
+		if c.itemTemplate != nil && params.Ref.URI == c.itemTemplate.URITemplate {
+			if params.Argument.Name == c.lookupField {
+				return mcp.NewCompletionResult(mcp.RankCompletions(c.lookupValues, params.Argument.Value)), nil
+			}
+			break
+		}
+		if params.Ref.URI != c.resource.URI {
+			return mcp.CompletionResult{}, fmt.Errorf("resource not found: %s", params.Ref.URI)
+		}
+	}
+	return mcp.NewCompletionResult(nil), nil
+}
+
+// briefArgName is the item brief prompt's item argument
+func (c *catalogData) briefArgName() string {
+	if len(c.briefPrompt.Arguments) > 0 {
+		return c.briefPrompt.Arguments[0].Name
+	}
+	return "item_name"
+}
+
+func (c *catalogData) createPlanRecommendationPrompt(args map[string]any) mcp.PromptResponse {
 	budgetKey := "budget"
 	goalKey := "goal"
 	for _, arg := range c.recommendationPrompt.Arguments {
@@ -339,13 +882,8 @@ func (c *Catalog) createPlanRecommendationPrompt(args map[string]any) mcp.Prompt
 	}
 }
 
-func (c *Catalog) createItemBriefPrompt(args map[string]any) mcp.PromptResponse {
-	argName := "item_name"
-	if len(c.briefPrompt.Arguments) > 0 {
-		argName = c.briefPrompt.Arguments[0].Name
-	}
-
-	itemName, ok := args[argName].(string)
+func (c *catalogData) createItemBriefPrompt(args map[string]any) mcp.PromptResponse {
+	itemName, ok := args[c.briefArgName()].(string)
 	if !ok {
 		itemName = "catalog item"
 	}
@@ -359,3 +897,53 @@ func (c *Catalog) createItemBriefPrompt(args map[string]any) mcp.PromptResponse
 		}},
 	}
 }
+
+// StatsParams are the params of the catalog/stats method.
+type StatsParams struct {
+	// GroupBy optionally names an item field to count items by.
+	GroupBy string `json:"groupBy,omitempty"`
+}
+
+// Stats is the result of the catalog/stats method.
+type Stats struct {
+	Items       int            `json:"items"`
+	LookupField string         `json:"lookupField"`
+	Tools       int            `json:"tools"`
+	Resources   int            `json:"resources"`
+	Prompts     int            `json:"prompts"`
+	Files       int            `json:"files"`
+	Groups      map[string]int `json:"groups,omitempty"`
+}
+
+// Stats summarizes the current catalog. It backs the vendor catalog/stats
+// method registered by cmd/mcpserver.
+func (c *Catalog) Stats(ctx context.Context, params StatsParams) (Stats, error) {
+	return c.snapshot().stats(params)
+}
+
+func (c *catalogData) stats(params StatsParams) (Stats, error) {
+	stats := Stats{
+		Items:       len(c.items),
+		LookupField: c.lookupField,
+		Tools:       len(c.tools()),
+		Resources:   len(c.resources()),
+		Prompts:     len(c.prompts()),
+		Files:       len(c.files),
+	}
+	if params.GroupBy == "" {
+		return stats, nil
+	}
+
+	stats.Groups = make(map[string]int)
+	for _, item := range c.items {
+		value, ok := item.Values[params.GroupBy]
+		if !ok {
+			continue
+		}
+		stats.Groups[fmt.Sprint(value)]++
+	}
+	if len(stats.Groups) == 0 {
+		return Stats{}, mcp.NewInvalidParamsError("no item has field %q", params.GroupBy)
+	}
+	return stats, nil
+}
@@ -2,10 +2,19 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/BearHuddleston/mcp-server-template/internal/server"
+	"github.com/BearHuddleston/mcp-server-template/pkg/config"
 	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
+	"github.com/BearHuddleston/mcp-server-template/pkg/schema"
 	"github.com/BearHuddleston/mcp-server-template/pkg/spec"
 )
 
@@ -100,12 +109,9 @@ func TestCatalogHandler(t *testing.T) {
 	t.Run("CallTool - cancelled context", func(t *testing.T) {
 		cancelledCtx, cancel := context.WithCancel(context.Background())
 		cancel()
-		resp, err := handler.CallTool(cancelledCtx, mcp.ToolCallParams{Name: "listItems", Arguments: map[string]any{}})
-		if err != nil {
-			t.Fatalf("Expected no error for listItems cancellation branch, got %v", err)
-		}
-		if len(resp.Content) != 1 || !strings.Contains(resp.Content[0].Text, "request cancelled") {
-			t.Fatalf("Expected cancellation response content, got %+v", resp.Content)
+		_, err := handler.CallTool(cancelledCtx, mcp.ToolCallParams{Name: "listItems", Arguments: map[string]any{}})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context cancellation error for listItems, got %v", err)
 		}
 
 		_, err = handler.CallTool(cancelledCtx, mcp.ToolCallParams{Name: "getItemDetails", Arguments: map[string]any{"name": "Workspace Automation Pack"}})
@@ -286,3 +292,689 @@ func TestNewCatalogFromSpec(t *testing.T) {
 		}
 	})
 }
+
+type recordingNotifier struct {
+	methods []string
+	params  []any
+}
+
+func (n *recordingNotifier) Notify(method string, params any) {
+	n.methods = append(n.methods, method)
+	n.params = append(n.params, params)
+}
+
+func TestCatalogReload(t *testing.T) {
+	newSpec := func(listToolName string) *spec.Spec {
+		return &spec.Spec{
+			SchemaVersion: "v1",
+			Items:         []spec.ItemSpec{{"item_name": "Template Bundle"}},
+			Tools: []spec.ToolSpec{
+				{Mode: "list_items", Name: listToolName, Description: "List names", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{}, Required: []string{}}},
+				{Mode: "get_item_details", Name: "fetchDetails", Description: "Get details", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{"item_name": map[string]string{"type": "string"}}, Required: []string{"item_name"}}},
+			},
+			Resources: []spec.ResourceSpec{{Mode: "catalog_items", URI: "catalog://custom-items", Name: "custom-catalog"}},
+			Prompts: []spec.PromptSpec{
+				{Mode: "plan_recommendation", Name: "buildPlan", Description: "Build a recommendation", Template: "Plan for a team%s%s"},
+				{Mode: "item_brief", Name: "quickBrief", Description: "Write item brief", Template: "Brief for %s"},
+			},
+		}
+	}
+
+	h, err := NewCatalogFromSpec(newSpec("listCatalog"))
+	if err != nil {
+		t.Fatalf("NewCatalogFromSpec failed: %v", err)
+	}
+	notifier := &recordingNotifier{}
+	h.BindNotifier(notifier)
+
+	if err := h.Reload(newSpec("listCatalog")); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if len(notifier.methods) != 0 {
+		t.Fatalf("expected no notifications for unchanged spec, got %v", notifier.methods)
+	}
+
+	if err := h.Reload(newSpec("listEverything")); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if len(notifier.methods) != 1 || notifier.methods[0] != mcp.NotificationToolsListChanged {
+		t.Fatalf("expected only tools list_changed, got %v", notifier.methods)
+	}
+	tools, err := h.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if tools[0].Name != "listEverything" {
+		t.Fatalf("expected reloaded tool name, got %q", tools[0].Name)
+	}
+
+	if err := h.Reload(&spec.Spec{SchemaVersion: "v1"}); err == nil {
+		t.Fatal("expected invalid spec to fail reload")
+	}
+	tools, _ = h.ListTools(context.Background())
+	if tools[0].Name != "listEverything" {
+		t.Fatalf("expected failed reload to keep catalog, got %q", tools[0].Name)
+	}
+}
+
+type samplingWriter struct {
+	session *mcp.Session
+	prompt  string
+}
+
+func (w *samplingWriter) WriteMessage(msg any) error {
+	req, ok := msg.(mcp.Request)
+	if !ok {
+		return nil
+	}
+	params := req.Params.(mcp.CreateMessageParams)
+	w.prompt = params.Messages[0].Content.Text
+	go w.session.HandleResponse(mcp.ClientResponse{
+		JSONRPC: mcp.JSONRPCVersion,
+		ID:      req.ID,
+		Result:  json.RawMessage(`{"role":"assistant","content":{"type":"text","text":"A short summary."},"model":"test-model"}`),
+	})
+	return nil
+}
+
+func TestCatalogSummarizeItem(t *testing.T) {
+	sp := &spec.Spec{
+		SchemaVersion: "v1",
+		Items:         []spec.ItemSpec{{"item_name": "Template Bundle", "track": "starter"}},
+		Tools: []spec.ToolSpec{
+			{Mode: "list_items", Name: "listCatalog", Description: "List names", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{}, Required: []string{}}},
+			{Mode: "get_item_details", Name: "fetchDetails", Description: "Get details", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{"item_name": map[string]string{"type": "string"}}, Required: []string{"item_name"}}},
+			{Mode: "summarize_item", Name: "summarize", Description: "Summarize an item", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{"item_name": map[string]string{"type": "string"}}, Required: []string{"item_name"}}},
+		},
+		Resources: []spec.ResourceSpec{{Mode: "catalog_items", URI: "catalog://custom-items", Name: "custom-catalog"}},
+		Prompts: []spec.PromptSpec{
+			{Mode: "plan_recommendation", Name: "buildPlan", Description: "Build a recommendation", Template: "Plan for a team%s%s"},
+			{Mode: "item_brief", Name: "quickBrief", Description: "Write item brief", Template: "Brief for %s"},
+		},
+	}
+	h, err := NewCatalogFromSpec(sp)
+	if err != nil {
+		t.Fatalf("NewCatalogFromSpec failed: %v", err)
+	}
+
+	tools, _ := h.ListTools(context.Background())
+	if len(tools) != 3 || tools[2].Name != "summarize" {
+		t.Fatalf("expected summarize tool to be listed, got %+v", tools)
+	}
+
+	session := mcp.NewSession("session-1")
+	writer := &samplingWriter{session: session}
+	session.SetWriter(writer)
+	ctx := mcp.WithSession(context.Background(), session)
+	params := mcp.ToolCallParams{Name: "summarize", Arguments: map[string]any{"item_name": "Template Bundle"}}
+
+	if _, err := h.CallTool(ctx, params); !errors.Is(err, mcp.ErrSamplingUnsupported) {
+		t.Fatalf("expected sampling unsupported without client capability, got %v", err)
+	}
+
+	session.SetClient(mcp.ProtocolVersion, mcp.Implementation{}, map[string]any{"sampling": map[string]any{}})
+	resp, err := h.CallTool(ctx, params)
+	if err != nil {
+		t.Fatalf("CallTool summarize failed: %v", err)
+	}
+	if len(resp.Content) != 1 || resp.Content[0].Text != "A short summary." {
+		t.Fatalf("unexpected summarize response: %+v", resp.Content)
+	}
+	if !strings.Contains(writer.prompt, "Template Bundle") {
+		t.Fatalf("expected item details in sampling prompt, got %q", writer.prompt)
+	}
+}
+
+type elicitationWriter struct {
+	session *mcp.Session
+	result  string
+	params  []mcp.ElicitParams
+}
+
+func (w *elicitationWriter) WriteMessage(msg any) error {
+	req, ok := msg.(mcp.Request)
+	if !ok {
+		return nil
+	}
+	w.params = append(w.params, req.Params.(mcp.ElicitParams))
+	go w.session.HandleResponse(mcp.ClientResponse{JSONRPC: mcp.JSONRPCVersion, ID: req.ID, Result: json.RawMessage(w.result)})
+	return nil
+}
+
+func TestCatalogGetItemDetailsElicitsLookup(t *testing.T) {
+	h := NewCatalog()
+
+	newSession := func(result string) (context.Context, *elicitationWriter) {
+		session := mcp.NewSession("session-1")
+		session.SetClient(mcp.ProtocolVersion, mcp.Implementation{}, map[string]any{"elicitation": map[string]any{}})
+		writer := &elicitationWriter{session: session, result: result}
+		session.SetWriter(writer)
+		return mcp.WithSession(context.Background(), session), writer
+	}
+
+	t.Run("missing argument", func(t *testing.T) {
+		ctx, writer := newSession(`{"action":"accept","content":{"name":"Incident Triage Guide"}}`)
+		resp, err := h.CallTool(ctx, mcp.ToolCallParams{Name: "getItemDetails", Arguments: map[string]any{}})
+		if err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		if !strings.Contains(resp.Content[0].Text, "Incident Triage Guide") {
+			t.Fatalf("expected elicited item details, got %+v", resp.Content)
+		}
+		if len(writer.params) != 1 {
+			t.Fatalf("expected one elicitation, got %d", len(writer.params))
+		}
+		if required := writer.params[0].RequestedSchema.Required; len(required) != 1 || required[0] != "name" {
+			t.Fatalf("expected lookup field to be requested, got %v", required)
+		}
+	})
+
+	t.Run("ambiguous argument", func(t *testing.T) {
+		ctx, writer := newSession(`{"action":"accept","content":{"name":"Performance Review Bundle"}}`)
+		resp, err := h.CallTool(ctx, mcp.ToolCallParams{Name: "getItemDetails", Arguments: map[string]any{"name": "e"}})
+		if err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		if !strings.Contains(resp.Content[0].Text, "Performance Review Bundle") {
+			t.Fatalf("expected elicited item details, got %+v", resp.Content)
+		}
+		property := writer.params[0].RequestedSchema.Properties["name"].(map[string]any)
+		if candidates, ok := property["enum"].([]string); !ok || len(candidates) != 3 {
+			t.Fatalf("expected candidate enum, got %#v", property["enum"])
+		}
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		ctx, _ := newSession(`{"action":"decline"}`)
+		_, err := h.CallTool(ctx, mcp.ToolCallParams{Name: "getItemDetails", Arguments: map[string]any{}})
+		if err == nil || !strings.Contains(err.Error(), "decline") {
+			t.Fatalf("expected declined elicitation error, got %v", err)
+		}
+	})
+
+	t.Run("client without elicitation", func(t *testing.T) {
+		ctx := mcp.WithSession(context.Background(), mcp.NewSession("session-2"))
+		_, err := h.CallTool(ctx, mcp.ToolCallParams{Name: "getItemDetails", Arguments: map[string]any{}})
+		if err == nil || !strings.Contains(err.Error(), "invalid name parameter") {
+			t.Fatalf("expected invalid parameter error, got %v", err)
+		}
+	})
+}
+
+// responseRecorder captures the response the server sends for a request
+type responseRecorder struct {
+	response mcp.Response
+}
+
+func (r *responseRecorder) SendResponse(response mcp.Response) error {
+	r.response = response
+	return nil
+}
+
+func (r *responseRecorder) SendError(id any, code int, message string, data any) error {
+	r.response = mcp.Response{JSONRPC: mcp.JSONRPCVersion, ID: id, Error: &mcp.ErrorResponse{Code: code, Message: message, Data: data}}
+	return nil
+}
+
+// TestServerToolsCallElicitsMissingLookup runs getItemDetails through the
+// server's tools/call dispatch, including argument validation.
+func TestServerToolsCallElicitsMissingLookup(t *testing.T) {
+	catalog := NewCatalog()
+	srv, err := server.New(&config.Config{ServerName: "test", ServerVersion: "1.0.0"}, catalog, catalog, catalog)
+	if err != nil {
+		t.Fatalf("server.New failed: %v", err)
+	}
+
+	session := mcp.RestoreSession("session-1", mcp.SessionSnapshot{
+		State:              mcp.SessionReady,
+		ProtocolVersion:    mcp.ProtocolVersion,
+		ClientCapabilities: map[string]any{"elicitation": map[string]any{}},
+	})
+	writer := &elicitationWriter{session: session, result: `{"action":"accept","content":{"name":"Incident Triage Guide"}}`}
+	session.SetWriter(writer)
+	recorder := &responseRecorder{}
+	ctx := mcp.WithSession(context.WithValue(context.Background(), mcp.ResponseSenderKey, recorder), session)
+
+	req := mcp.Request{JSONRPC: mcp.JSONRPCVersion, ID: 1, Method: "tools/call", Params: map[string]any{"name": "getItemDetails", "arguments": map[string]any{}}}
+	if err := srv.HandleRequest(ctx, req); err != nil {
+		t.Fatalf("HandleRequest failed: %v", err)
+	}
+
+	if recorder.response.Error != nil {
+		t.Fatalf("expected the call to succeed, got %+v", recorder.response.Error)
+	}
+	if len(writer.params) != 1 {
+		t.Fatalf("expected one elicitation/create request, got %d", len(writer.params))
+	}
+	result, ok := recorder.response.Result.(mcp.ToolResponse)
+	if !ok || result.IsError || !strings.Contains(result.Content[0].Text, "Incident Triage Guide") {
+		t.Fatalf("expected details of the elicited item, got %#v", recorder.response.Result)
+	}
+}
+
+func TestCatalogReportsProgressForLargeCatalogs(t *testing.T) {
+	items := make([]Item, 0, largeCatalogSize)
+	for i := range largeCatalogSize {
+		items = append(items, Item{Values: map[string]any{"name": fmt.Sprintf("Item %04d", i)}})
+	}
+	base := NewCatalog().snapshot()
+	h := &Catalog{data: newCatalogData(items, "name", "name", base.listTool, base.detailTool, base.resource, base.recommendationPrompt, base.briefPrompt, base.recommendationText, base.briefText)}
+
+	writer := &recordingMessageWriter{}
+	ctx := mcp.WithProgressToken(mcp.WithMessageWriter(context.Background(), writer), "token-1")
+
+	if _, err := h.CallTool(ctx, mcp.ToolCallParams{Name: "listItems"}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(writer.progress) != 2 || writer.progress[1].Progress != float64(largeCatalogSize) || writer.progress[1].Total != float64(largeCatalogSize) {
+		t.Fatalf("expected start and completion progress, got %+v", writer.progress)
+	}
+
+	small := NewCatalog()
+	writer.progress = nil
+	if _, err := small.CallTool(ctx, mcp.ToolCallParams{Name: "listItems"}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(writer.progress) != 0 {
+		t.Fatalf("expected no progress for small catalogs, got %+v", writer.progress)
+	}
+}
+
+type recordingMessageWriter struct {
+	progress []mcp.ProgressParams
+}
+
+func (w *recordingMessageWriter) WriteMessage(msg any) error {
+	if notification, ok := msg.(mcp.Notification); ok && notification.Method == mcp.NotificationProgress {
+		w.progress = append(w.progress, notification.Params.(mcp.ProgressParams))
+	}
+	return nil
+}
+
+func TestCatalogComplete(t *testing.T) {
+	h := NewCatalog()
+	ctx := context.Background()
+
+	result, err := h.Complete(ctx, mcp.CompleteParams{
+		Ref:      mcp.CompletionReference{Type: mcp.CompletionRefPrompt, Name: "itemBrief"},
+		Argument: mcp.CompletionArgument{Name: "item_name", Value: "incid"},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if len(result.Values) != 1 || result.Values[0] != "Incident Triage Guide" || result.Total != 1 {
+		t.Fatalf("unexpected completion: %+v", result)
+	}
+
+	result, err = h.Complete(ctx, mcp.CompleteParams{
+		Ref:      mcp.CompletionReference{Type: mcp.CompletionRefPrompt, Name: "planRecommendation"},
+		Argument: mcp.CompletionArgument{Name: "goal", Value: "re"},
+	})
+	if err != nil || len(result.Values) != 0 {
+		t.Fatalf("expected no suggestions for free-form argument, got %+v (%v)", result, err)
+	}
+
+	if _, err := h.Complete(ctx, mcp.CompleteParams{
+		Ref:      mcp.CompletionReference{Type: mcp.CompletionRefPrompt, Name: "missing"},
+		Argument: mcp.CompletionArgument{Name: "item_name"},
+	}); err == nil {
+		t.Fatal("expected unknown prompt to fail")
+	}
+}
+
+func TestCatalogItemResourceTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("default catalog", func(t *testing.T) {
+		h := NewCatalog()
+		templates, err := h.ListResourceTemplates(ctx)
+		if err != nil || len(templates) != 1 || templates[0].URITemplate != "catalog://items/{name}" {
+			t.Fatalf("unexpected templates: %+v (%v)", templates, err)
+		}
+
+		resp, err := h.ReadResource(ctx, mcp.ResourceParams{URI: "catalog://items/Incident%20Triage%20Guide"})
+		if err != nil {
+			t.Fatalf("ReadResource failed: %v", err)
+		}
+		if len(resp.Contents) != 1 || resp.Contents[0].URI != "catalog://items/Incident%20Triage%20Guide" || !strings.Contains(resp.Contents[0].Text, `"domain":"operations"`) {
+			t.Fatalf("unexpected item resource: %+v", resp.Contents)
+		}
+		if _, err := h.ReadResource(ctx, mcp.ResourceParams{URI: "catalog://items/Missing"}); err == nil {
+			t.Fatal("expected unknown item to fail")
+		}
+
+		resources, _ := h.ListResources(ctx)
+		if len(resources) != 1 {
+			t.Fatalf("expected per-item resources to be unlisted by default, got %+v", resources)
+		}
+
+		result, err := h.Complete(ctx, mcp.CompleteParams{
+			Ref:      mcp.CompletionReference{Type: mcp.CompletionRefResource, URI: "catalog://items/{name}"},
+			Argument: mcp.CompletionArgument{Name: "name", Value: "perf"},
+		})
+		if err != nil || len(result.Values) != 1 || result.Values[0] != "Performance Review Bundle" {
+			t.Fatalf("unexpected template completion: %+v (%v)", result, err)
+		}
+	})
+
+	t.Run("spec listing items", func(t *testing.T) {
+		sp := &spec.Spec{
+			SchemaVersion: "v1",
+			Items:         []spec.ItemSpec{{"item_key": "Item A"}, {"item_key": "Item B"}},
+			Tools: []spec.ToolSpec{
+				{Mode: "list_items", Name: "listCatalog", Description: "List names", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{}, Required: []string{}}},
+				{Mode: "get_item_details", Name: "fetchDetails", Description: "Get details", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{"item_key": map[string]string{"type": "string"}}, Required: []string{"item_key"}}},
+			},
+			Resources: []spec.ResourceSpec{
+				{Mode: "catalog_items", URI: "catalog://items", Name: "catalog"},
+				{Mode: "catalog_item", URITemplate: "catalog://items/{item_key}", Name: "item", ListItems: true},
+			},
+			Prompts: []spec.PromptSpec{
+				{Mode: "plan_recommendation", Name: "buildPlan", Description: "Build a recommendation", Template: "Plan for a team%s%s"},
+				{Mode: "item_brief", Name: "quickBrief", Description: "Write item brief", Template: "Brief for %s"},
+			},
+		}
+		h, err := NewCatalogFromSpec(sp)
+		if err != nil {
+			t.Fatalf("NewCatalogFromSpec failed: %v", err)
+		}
+
+		resources, _ := h.ListResources(ctx)
+		if len(resources) != 3 || resources[1].URI != "catalog://items/Item%20A" || resources[2].Name != "Item B" {
+			t.Fatalf("unexpected resources: %+v", resources)
+		}
+		if _, err := h.ReadResource(ctx, mcp.ResourceParams{URI: resources[2].URI}); err != nil {
+			t.Fatalf("expected listed item resource to be readable: %v", err)
+		}
+	})
+}
+
+func TestCatalogResourceSubscriptions(t *testing.T) {
+	newSpec := func(owner string) *spec.Spec {
+		return &spec.Spec{
+			SchemaVersion: "v1",
+			Items:         []spec.ItemSpec{{"item_key": "Item A", "owner": owner}, {"item_key": "Item B", "owner": "platform"}},
+			Tools: []spec.ToolSpec{
+				{Mode: "list_items", Name: "listCatalog", Description: "List names", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{}, Required: []string{}}},
+				{Mode: "get_item_details", Name: "fetchDetails", Description: "Get details", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{"item_key": map[string]string{"type": "string"}}, Required: []string{"item_key"}}},
+			},
+			Resources: []spec.ResourceSpec{
+				{Mode: "catalog_items", URI: "catalog://items", Name: "catalog"},
+				{Mode: "catalog_item", URITemplate: "catalog://items/{item_key}", Name: "item"},
+			},
+			Prompts: []spec.PromptSpec{
+				{Mode: "plan_recommendation", Name: "buildPlan", Description: "Build a recommendation", Template: "Plan for a team%s%s"},
+				{Mode: "item_brief", Name: "quickBrief", Description: "Write item brief", Template: "Brief for %s"},
+			},
+		}
+	}
+
+	h, err := NewCatalogFromSpec(newSpec("platform"))
+	if err != nil {
+		t.Fatalf("NewCatalogFromSpec failed: %v", err)
+	}
+	ctx := context.Background()
+	for _, uri := range []string{"catalog://items", "catalog://items/Item%20A"} {
+		if err := h.CheckSubscription(ctx, uri); err != nil {
+			t.Fatalf("expected %s to be subscribable, got %v", uri, err)
+		}
+	}
+	for _, uri := range []string{"catalog://items/Missing", "catalog://items/Item A", "other://items"} {
+		if err := h.CheckSubscription(ctx, uri); err == nil {
+			t.Fatalf("expected %s to be rejected", uri)
+		}
+	}
+
+	notifier := &recordingNotifier{}
+	h.BindNotifier(notifier)
+	if err := h.Reload(newSpec("security")); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	var updated []string
+	for i, method := range notifier.methods {
+		if method == mcp.NotificationResourcesUpdated {
+			updated = append(updated, notifier.params[i].(mcp.ResourceUpdatedParams).URI)
+		}
+	}
+	if len(updated) != 2 || updated[0] != "catalog://items" || updated[1] != "catalog://items/Item%20A" {
+		t.Fatalf("expected updates for the catalog and the changed item, got %v", updated)
+	}
+}
+
+func TestCatalogStructuredContent(t *testing.T) {
+	h := NewCatalog()
+	ctx := context.Background()
+
+	tools, _ := h.ListTools(ctx)
+	for _, tool := range tools {
+		if tool.OutputSchema == nil {
+			t.Fatalf("expected %s to declare an output schema", tool.Name)
+		}
+	}
+
+	listResp, err := h.CallTool(ctx, mcp.ToolCallParams{Name: "listItems"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if err := schema.Validate(tools[0].OutputSchema, listResp.StructuredContent); err != nil {
+		t.Fatalf("list output does not match its schema: %v", err)
+	}
+	if structured := listResp.StructuredContent.(map[string]any); structured["field"] != "name" || len(structured["values"].([]string)) != 3 {
+		t.Fatalf("unexpected structured list: %+v", structured)
+	}
+	if len(listResp.Content) != 1 || !strings.Contains(listResp.Content[0].Text, `"values"`) {
+		t.Fatalf("expected text block to be kept, got %+v", listResp.Content)
+	}
+
+	detailResp, err := h.CallTool(ctx, mcp.ToolCallParams{Name: "getItemDetails", Arguments: map[string]any{"name": "Incident Triage Guide"}})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if err := schema.Validate(tools[1].OutputSchema, detailResp.StructuredContent); err != nil {
+		t.Fatalf("detail output does not match its schema: %v", err)
+	}
+	if structured := detailResp.StructuredContent.(map[string]any); structured["domain"] != "operations" {
+		t.Fatalf("unexpected structured item: %+v", structured)
+	}
+}
+
+func TestCatalogToolErrorKinds(t *testing.T) {
+	h := NewCatalog()
+	ctx := context.Background()
+
+	for name, params := range map[string]mcp.ToolCallParams{
+		"unknown tool":        {Name: "unknown"},
+		"non-string argument": {Name: "getItemDetails", Arguments: map[string]any{"name": 42}},
+	} {
+		_, err := h.CallTool(ctx, params)
+		if protocolErr, ok := mcp.AsProtocolError(err); !ok || protocolErr.Code != mcp.ErrorCodeInvalidParams {
+			t.Fatalf("%s: expected invalid params protocol error, got %v", name, err)
+		}
+	}
+
+	_, err := h.CallTool(ctx, mcp.ToolCallParams{Name: "getItemDetails", Arguments: map[string]any{"name": "Missing"}})
+	if err == nil {
+		t.Fatal("expected unknown item to fail")
+	}
+	if _, ok := mcp.AsProtocolError(err); ok {
+		t.Fatalf("expected unknown item to be an execution error, got %v", err)
+	}
+}
+
+func TestCatalogItemFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	specJSON := `{
+	  "schemaVersion": "v1",
+	  "server": {"name": "Template MCP", "version": "1.0.0"},
+	  "runtime": {},
+	  "items": [
+	    {"item_key": "Item A", "diagram": {"file": "diagrams/item a.png"}, "runbook": {"file": "runbook.md", "name": "Runbook"}},
+	    {"item_key": "Item B"}
+	  ],
+	  "tools": [
+	    {"mode": "list_items", "name": "listItems", "description": "List items", "inputSchema": {"type": "object", "properties": {}, "required": []}},
+	    {"mode": "get_item_details", "name": "getItemDetails", "description": "Get item details", "inputSchema": {"type": "object", "properties": {"item_key": {"type": "string"}}, "required": ["item_key"]}}
+	  ],
+	  "resources": [{"mode": "catalog_items", "uri": "catalog://items", "name": "catalog"}],
+	  "prompts": [
+	    {"mode": "plan_recommendation", "name": "planRecommendation", "description": "Plan prompt", "arguments": [], "template": "Plan for team%s%s"},
+	    {"mode": "item_brief", "name": "itemBrief", "description": "Brief prompt", "arguments": [{"name": "item_name", "description": "Item", "required": true}], "template": "Brief for %s"}
+	  ]
+	}`
+	png := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a}
+	files := map[string][]byte{
+		"mcp-spec.json":       []byte(specJSON),
+		"diagrams/item a.png": png,
+		"runbook.md":          []byte("# Runbook\n"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	sp, err := spec.LoadFile(filepath.Join(dir, "mcp-spec.json"))
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	h, err := NewCatalogFromSpec(sp)
+	if err != nil {
+		t.Fatalf("NewCatalogFromSpec failed: %v", err)
+	}
+
+	resources, err := h.ListResources(ctx)
+	if err != nil {
+		t.Fatalf("ListResources failed: %v", err)
+	}
+	want := []mcp.Resource{
+		{URI: "catalog://items", Name: "catalog", MimeType: "application/json", Size: resources[0].Size},
+		{URI: "catalog://items/files/diagrams/item%20a.png", Name: "item a.png", MimeType: "image/png", Size: 8},
+		{URI: "catalog://items/files/runbook.md", Name: "Runbook", MimeType: "text/markdown", Size: 10},
+	}
+	if fmt.Sprint(resources) != fmt.Sprint(want) {
+		t.Fatalf("unexpected resources:\n got %+v\nwant %+v", resources, want)
+	}
+
+	image, err := h.ReadResource(ctx, mcp.ResourceParams{URI: want[1].URI})
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	if got := image.Contents[0]; got.MimeType != "image/png" || got.Text != "" || got.Blob != base64.StdEncoding.EncodeToString(png) {
+		t.Fatalf("unexpected image contents: %+v", got)
+	}
+	runbook, err := h.ReadResource(ctx, mcp.ResourceParams{URI: want[2].URI})
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	if got := runbook.Contents[0]; got.MimeType != "text/markdown" || got.Text != "# Runbook\n" || got.Blob != "" {
+		t.Fatalf("unexpected runbook contents: %+v", got)
+	}
+
+	resp, err := h.CallTool(ctx, mcp.ToolCallParams{Name: "getItemDetails", Arguments: map[string]any{"item_key": "Item A"}})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(resp.Content) != 3 || resp.Content[0].Type != mcp.ContentTypeText {
+		t.Fatalf("expected text plus two resource links, got %+v", resp.Content)
+	}
+	for i, link := range resp.Content[1:] {
+		if link.Type != mcp.ContentTypeResourceLink || link.URI != want[i+1].URI || link.MimeType != want[i+1].MimeType {
+			t.Fatalf("unexpected resource link %d: %+v", i, link)
+		}
+	}
+
+	resp, err = h.CallTool(ctx, mcp.ToolCallParams{Name: "getItemDetails", Arguments: map[string]any{"item_key": "Item B"}})
+	if err != nil || len(resp.Content) != 1 {
+		t.Fatalf("expected item without files to return only text, got %+v (%v)", resp.Content, err)
+	}
+}
+
+func TestCatalogDisplayMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("default catalog tools are read-only", func(t *testing.T) {
+		tools, _ := NewCatalog().ListTools(ctx)
+		for _, tool := range tools {
+			a := tool.Annotations
+			if tool.Title == "" || a == nil || !*a.ReadOnlyHint || *a.DestructiveHint || !*a.IdempotentHint || *a.OpenWorldHint {
+				t.Fatalf("unexpected metadata for %s: %+v %+v", tool.Name, tool, a)
+			}
+		}
+	})
+
+	t.Run("spec overrides", func(t *testing.T) {
+		no := false
+		sp := &spec.Spec{
+			SchemaVersion: "v1",
+			Items:         []spec.ItemSpec{{"item_key": "Item A"}},
+			Tools: []spec.ToolSpec{
+				{Mode: "list_items", Name: "listItems", Title: "List Items", Description: "List items", InputSchema: mcp.InputSchema{Type: "object"}, Icons: []mcp.Icon{{Src: "https://example.com/list.png"}}},
+				{Mode: "get_item_details", Name: "getItemDetails", Description: "Get details", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{"item_key": map[string]any{"type": "string"}}, Required: []string{"item_key"}}, Annotations: &mcp.ToolAnnotations{IdempotentHint: &no}},
+				{Mode: "summarize_item", Name: "summarizeItem", Description: "Summarize", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{"item_key": map[string]any{"type": "string"}}, Required: []string{"item_key"}}},
+			},
+			Resources: []spec.ResourceSpec{{Mode: "catalog_items", URI: "catalog://items", Name: "catalog", Title: "Catalog", MimeType: "application/vnd.catalog+json"}},
+			Prompts: []spec.PromptSpec{
+				{Mode: "plan_recommendation", Name: "planRecommendation", Title: "Plan", Description: "Plan prompt", Template: "Plan for%s%s"},
+				{Mode: "item_brief", Name: "itemBrief", Description: "Brief prompt", Template: "Brief for %s"},
+			},
+		}
+		h, err := NewCatalogFromSpec(sp)
+		if err != nil {
+			t.Fatalf("NewCatalogFromSpec failed: %v", err)
+		}
+
+		tools, _ := h.ListTools(ctx)
+		if tools[0].Title != "List Items" || len(tools[0].Icons) != 1 || !*tools[0].Annotations.ReadOnlyHint {
+			t.Fatalf("unexpected list tool: %+v", tools[0])
+		}
+		if a := tools[1].Annotations; *a.IdempotentHint || !*a.ReadOnlyHint {
+			t.Fatalf("expected idempotent override on read-only defaults, got %+v", a)
+		}
+		if a := tools[2].Annotations; *a.IdempotentHint || !*a.ReadOnlyHint {
+			t.Fatalf("expected summarize tool to be read-only but not idempotent, got %+v", a)
+		}
+
+		resources, _ := h.ListResources(ctx)
+		if resources[0].Title != "Catalog" || resources[0].MimeType != "application/vnd.catalog+json" || resources[0].Size == 0 {
+			t.Fatalf("unexpected resource: %+v", resources[0])
+		}
+		resp, _ := h.ReadResource(ctx, mcp.ResourceParams{URI: "catalog://items"})
+		if resp.Contents[0].MimeType != "application/vnd.catalog+json" || int64(len(resp.Contents[0].Text)) != resources[0].Size {
+			t.Fatalf("resource contents do not match listing: %+v", resp.Contents[0])
+		}
+
+		prompts, _ := h.ListPrompts(ctx)
+		if prompts[0].Title != "Plan" {
+			t.Fatalf("unexpected prompt: %+v", prompts[0])
+		}
+	})
+}
+
+func TestCatalogStats(t *testing.T) {
+	h := NewCatalog()
+
+	stats, err := h.Stats(context.Background(), StatsParams{})
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Items != 3 || stats.LookupField != "name" || stats.Tools != 2 || stats.Resources != 1 || stats.Prompts != 2 || stats.Groups != nil {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	stats, err = h.Stats(context.Background(), StatsParams{GroupBy: "domain"})
+	if err != nil || stats.Groups["operations"] != 1 || len(stats.Groups) != 3 {
+		t.Fatalf("unexpected grouped stats: %+v (%v)", stats, err)
+	}
+
+	if _, err := h.Stats(context.Background(), StatsParams{GroupBy: "missing"}); err == nil {
+		t.Fatal("expected unknown group field to fail")
+	} else if _, ok := mcp.AsProtocolError(err); !ok {
+		t.Fatalf("expected invalid params error, got %v", err)
+	}
+}
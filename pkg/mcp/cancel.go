@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrRequestCancelled is the context cause for requests the client cancelled.
+var ErrRequestCancelled = errors.New("request cancelled by client")
+
+// BeginRequest registers an in-flight client request so that a later
+// notifications/cancelled can cancel it. The returned function must be called
+// once the request has finished.
+func (s *Session) BeginRequest(ctx context.Context, id any) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	key := requestKey(id)
+
+	s.mu.Lock()
+	if s.inflight == nil {
+		s.inflight = make(map[string]context.CancelCauseFunc)
+	}
+	s.inflight[key] = cancel
+	s.mu.Unlock()
+
+	return ctx, func() {
+		s.mu.Lock()
+		delete(s.inflight, key)
+		s.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// CancelRequest cancels the in-flight request with the given JSON-RPC id and
+// reports whether one was found.
+func (s *Session) CancelRequest(id any) bool {
+	key := requestKey(id)
+
+	s.mu.Lock()
+	cancel, ok := s.inflight[key]
+	delete(s.inflight, key)
+	s.mu.Unlock()
+
+	if ok {
+		cancel(ErrRequestCancelled)
+	}
+	return ok
+}
+
+// RequestCancelled reports whether ctx belongs to a request the client cancelled.
+// Responses to such requests must not be sent.
+func RequestCancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrRequestCancelled)
+}
+
+// requestKey normalizes a JSON-RPC id so that numbers decoded from JSON and
+// numbers constructed in Go map to the same key, while strings stay distinct.
+func requestKey(id any) string {
+	switch v := id.(type) {
+	case string:
+		return "s:" + v
+	case float64:
+		return "n:" + strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return "n:" + strconv.Itoa(v)
+	case int64:
+		return "n:" + strconv.FormatInt(v, 10)
+	default:
+		return fmt.Sprintf("%T:%v", id, id)
+	}
+}
@@ -0,0 +1,44 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSessionCancelRequest(t *testing.T) {
+	session := NewSession("session-1")
+
+	ctx, done := session.BeginRequest(context.Background(), 7)
+	defer done()
+
+	if session.CancelRequest("7") {
+		t.Fatal("expected string id not to match numeric request id")
+	}
+	// Ids decoded from JSON are float64.
+	if !session.CancelRequest(float64(7)) {
+		t.Fatal("expected numeric id to match in-flight request")
+	}
+	if ctx.Err() == nil || !RequestCancelled(ctx) {
+		t.Fatalf("expected request context cancelled by client, got cause %v", context.Cause(ctx))
+	}
+	if session.CancelRequest(7) {
+		t.Fatal("expected cancelled request to be unregistered")
+	}
+
+	finished, finish := session.BeginRequest(context.Background(), "abc")
+	finish()
+	if session.CancelRequest("abc") {
+		t.Fatal("expected finished request to be unregistered")
+	}
+	if RequestCancelled(finished) {
+		t.Fatal("expected finished request not to count as cancelled")
+	}
+
+	closing, closeDone := session.BeginRequest(context.Background(), 8)
+	defer closeDone()
+	session.Close()
+	if !errors.Is(context.Cause(closing), ErrSessionClosed) {
+		t.Fatalf("expected close to cancel in-flight requests, got %v", context.Cause(closing))
+	}
+}
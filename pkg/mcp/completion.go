@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"sort"
+	"strings"
+)
+
+// Completion-related types
+
+// MaxCompletionValues is the most values a completion result may carry.
+const MaxCompletionValues = 100
+
+// Completion reference types.
+const (
+	CompletionRefPrompt   = "ref/prompt"
+	CompletionRefResource = "ref/resource"
+)
+
+type CompletionReference struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+	URI  string `json:"uri,omitempty"`
+}
+
+type CompletionArgument struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CompletionContext carries arguments the client already resolved.
+type CompletionContext struct {
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+type CompleteParams struct {
+	Ref      CompletionReference `json:"ref"`
+	Argument CompletionArgument  `json:"argument"`
+	Context  *CompletionContext  `json:"context,omitempty"`
+}
+
+type CompletionResult struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total,omitempty"`
+	HasMore bool     `json:"hasMore,omitempty"`
+}
+
+type CompleteResponse struct {
+	Completion CompletionResult `json:"completion"`
+}
+
+// NewCompletionResult caps matches at MaxCompletionValues and reports the
+// full match count in Total.
+func NewCompletionResult(matches []string) CompletionResult {
+	result := CompletionResult{Values: matches, Total: len(matches)}
+	if len(matches) > MaxCompletionValues {
+		result.Values = matches[:MaxCompletionValues]
+		result.HasMore = true
+	}
+	if result.Values == nil {
+		result.Values = []string{}
+	}
+	return result
+}
+
+// RankCompletions returns the candidates matching value, best first: exact,
+// prefix, word prefix, substring and finally fuzzy (in-order characters)
+// matches, all case-insensitive. Candidates keep their order within a rank.
+func RankCompletions(candidates []string, value string) []string {
+	needle := strings.ToLower(strings.TrimSpace(value))
+	if needle == "" {
+		return append([]string(nil), candidates...)
+	}
+
+	type ranked struct {
+		value string
+		rank  int
+	}
+	var matches []ranked
+	for _, candidate := range candidates {
+		if rank, ok := completionRank(strings.ToLower(candidate), needle); ok {
+			matches = append(matches, ranked{value: candidate, rank: rank})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].rank < matches[j].rank })
+
+	values := make([]string, len(matches))
+	for i, match := range matches {
+		values[i] = match.value
+	}
+	return values
+}
+
+func completionRank(candidate, needle string) (int, bool) {
+	switch {
+	case candidate == needle:
+		return 0, true
+	case strings.HasPrefix(candidate, needle):
+		return 1, true
+	case hasWordPrefix(candidate, needle):
+		return 2, true
+	case strings.Contains(candidate, needle):
+		return 3, true
+	case isSubsequence(candidate, needle):
+		return 4, true
+	default:
+		return 0, false
+	}
+}
+
+func hasWordPrefix(candidate, needle string) bool {
+	words := strings.FieldsFunc(candidate, func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-' || r == '.' || r == '/'
+	})
+	for _, word := range words {
+		if strings.HasPrefix(word, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSubsequence(candidate, needle string) bool {
+	remaining := []rune(needle)
+	for _, r := range candidate {
+		if len(remaining) == 0 {
+			break
+		}
+		if r == remaining[0] {
+			remaining = remaining[1:]
+		}
+	}
+	return len(remaining) == 0
+}
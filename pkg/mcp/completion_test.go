@@ -0,0 +1,48 @@
+package mcp
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestRankCompletions(t *testing.T) {
+	candidates := []string{"Incident Triage Guide", "Performance Review Bundle", "Workspace Automation Pack", "Review"}
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "empty returns all", value: "", want: candidates},
+		{name: "exact before prefix before word prefix", value: "review", want: []string{"Review", "Performance Review Bundle"}},
+		{name: "prefix", value: "incid", want: []string{"Incident Triage Guide"}},
+		{name: "substring", value: "mat", want: []string{"Workspace Automation Pack"}},
+		{name: "fuzzy", value: "wap", want: []string{"Workspace Automation Pack"}},
+		{name: "no match", value: "zzz", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RankCompletions(candidates, tt.value)
+			if !slices.Equal(got, tt.want) && !(len(got) == 0 && len(tt.want) == 0) {
+				t.Fatalf("RankCompletions(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCompletionResult(t *testing.T) {
+	if result := NewCompletionResult(nil); result.Values == nil || result.Total != 0 || result.HasMore {
+		t.Fatalf("unexpected empty result: %+v", result)
+	}
+
+	matches := make([]string, MaxCompletionValues+5)
+	for i := range matches {
+		matches[i] = fmt.Sprintf("value-%d", i)
+	}
+	result := NewCompletionResult(matches)
+	if len(result.Values) != MaxCompletionValues || result.Total != len(matches) || !result.HasMore {
+		t.Fatalf("expected capped result with total and hasMore, got %d values, total %d, hasMore %v", len(result.Values), result.Total, result.HasMore)
+	}
+}
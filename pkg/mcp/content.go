@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// Content block types.
+const (
+	ContentTypeText         = "text"
+	ContentTypeImage        = "image"
+	ContentTypeAudio        = "audio"
+	ContentTypeResource     = "resource"
+	ContentTypeResourceLink = "resource_link"
+)
+
+// Audience roles for Annotations.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Annotations tell clients how to use a content block or resource.
+type Annotations struct {
+	Audience []string `json:"audience,omitempty"`
+	// Priority ranges from 0 (optional) to 1 (required).
+	Priority     *float64 `json:"priority,omitempty"`
+	LastModified string   `json:"lastModified,omitempty"`
+}
+
+// ContentItem is one block of tool, prompt or sampling content. Type selects
+// which fields apply:
+//   - text: Text
+//   - image, audio: Data (base64) and MimeType
+//   - resource: Resource, an embedded resource
+//   - resource_link: URI, Name, Description and MimeType
+type ContentItem struct {
+	Type        string           `json:"type"`
+	Text        string           `json:"text,omitempty"`
+	Data        string           `json:"data,omitempty"`
+	MimeType    string           `json:"mimeType,omitempty"`
+	Resource    *ResourceContent `json:"resource,omitempty"`
+	URI         string           `json:"uri,omitempty"`
+	Name        string           `json:"name,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Annotations *Annotations     `json:"annotations,omitempty"`
+}
+
+// MarshalJSON always includes text for text blocks, even when it is empty.
+func (c ContentItem) MarshalJSON() ([]byte, error) {
+	type content ContentItem
+	if c.Type != ContentTypeText {
+		return json.Marshal(content(c))
+	}
+	return json.Marshal(struct {
+		content
+		Text string `json:"text"`
+	}{content(c), c.Text})
+}
+
+// NewTextContent returns a text content block.
+func NewTextContent(text string) ContentItem {
+	return ContentItem{Type: ContentTypeText, Text: text}
+}
+
+// NewImageContent returns an image content block carrying data base64-encoded.
+func NewImageContent(data []byte, mimeType string) ContentItem {
+	return ContentItem{Type: ContentTypeImage, Data: base64.StdEncoding.EncodeToString(data), MimeType: mimeType}
+}
+
+// NewAudioContent returns an audio content block carrying data base64-encoded.
+func NewAudioContent(data []byte, mimeType string) ContentItem {
+	return ContentItem{Type: ContentTypeAudio, Data: base64.StdEncoding.EncodeToString(data), MimeType: mimeType}
+}
+
+// NewEmbeddedResource returns a content block that embeds resource contents.
+func NewEmbeddedResource(resource ResourceContent) ContentItem {
+	return ContentItem{Type: ContentTypeResource, Resource: &resource}
+}
+
+// NewResourceLink returns a content block pointing at a resource the client can read.
+func NewResourceLink(resource Resource) ContentItem {
+	return ContentItem{Type: ContentTypeResourceLink, URI: resource.URI, Name: resource.Name, MimeType: resource.MimeType}
+}
@@ -0,0 +1,42 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestContentItemJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		item any
+		want string
+	}{
+		{name: "empty text keeps text field", item: NewTextContent(""), want: `{"type":"text","text":""}`},
+		{name: "image", item: NewImageContent([]byte("png"), "image/png"), want: `{"type":"image","data":"cG5n","mimeType":"image/png"}`},
+		{name: "audio", item: NewAudioContent([]byte("wav"), "audio/wav"), want: `{"type":"audio","data":"d2F2","mimeType":"audio/wav"}`},
+		{
+			name: "embedded resource",
+			item: NewEmbeddedResource(ResourceContent{URI: "file:///a.txt", MimeType: "text/plain", Text: "hi"}),
+			want: `{"type":"resource","resource":{"uri":"file:///a.txt","mimeType":"text/plain","text":"hi"}}`,
+		},
+		{
+			name: "resource link",
+			item: NewResourceLink(Resource{URI: "catalog://items/files/a.png", Name: "a.png", MimeType: "image/png"}),
+			want: `{"type":"resource_link","mimeType":"image/png","uri":"catalog://items/files/a.png","name":"a.png"}`,
+		},
+		{name: "blob resource omits text", item: ResourceContent{URI: "file:///a.png", MimeType: "image/png", Blob: "cG5n"}, want: `{"uri":"file:///a.png","mimeType":"image/png","blob":"cG5n"}`},
+		{name: "empty text resource keeps text", item: ResourceContent{URI: "file:///a.txt"}, want: `{"uri":"file:///a.txt","text":""}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.item)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Fatalf("got %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrElicitationUnsupported is returned when the client cannot handle elicitation/create.
+var ErrElicitationUnsupported = errors.New("client does not support elicitation")
+
+// Elicitation actions reported by the client.
+const (
+	ElicitActionAccept  = "accept"
+	ElicitActionDecline = "decline"
+	ElicitActionCancel  = "cancel"
+)
+
+// ElicitationSchema is the restricted, flat object schema used to request user input.
+type ElicitationSchema struct {
+	Type       string         `json:"type"`
+	Properties map[string]any `json:"properties"`
+	Required   []string       `json:"required,omitempty"`
+}
+
+// ElicitParams are the params of an elicitation/create request.
+type ElicitParams struct {
+	Message         string            `json:"message"`
+	RequestedSchema ElicitationSchema `json:"requestedSchema"`
+}
+
+// ElicitResult is the client's reply to elicitation/create.
+type ElicitResult struct {
+	Action  string         `json:"action"`
+	Content map[string]any `json:"content,omitempty"`
+}
+
+// CanElicit reports whether the session's client declared the elicitation
+// capability on a protocol version that defines it.
+func CanElicit(session *Session) bool {
+	return session != nil &&
+		session.HasClientCapability("elicitation") &&
+		session.SupportsProtocol(StructuredProtocolVersion)
+}
+
+// ArgumentEliciter is implemented by tool handlers that ask the user, through
+// elicitation, for required arguments a call leaves out. The server does not
+// reject calls missing those arguments from sessions that can elicit.
+type ArgumentEliciter interface {
+	// ElicitedArguments returns the arguments of the named tool the handler
+	// elicits when they are missing.
+	ElicitedArguments(tool string) []string
+}
+
+// ElicitationSchemaFor builds a requested schema asking for the named fields of
+// a tool input schema. Every requested field is required.
+func ElicitationSchemaFor(schema InputSchema, fields ...string) ElicitationSchema {
+	requested := ElicitationSchema{Type: "object", Properties: make(map[string]any, len(fields)), Required: fields}
+	for _, field := range fields {
+		requested.Properties[field] = elicitationProperty(schema.Properties[field])
+	}
+	return requested
+}
+
+// elicitationProperty copies a property schema so callers can refine it,
+// defaulting to a string when the tool schema does not describe the field.
+func elicitationProperty(prop any) map[string]any {
+	property := map[string]any{}
+	switch typed := prop.(type) {
+	case map[string]any:
+		for k, v := range typed {
+			property[k] = v
+		}
+	case map[string]string:
+		for k, v := range typed {
+			property[k] = v
+		}
+	}
+	if _, ok := property["type"]; !ok {
+		property["type"] = "string"
+	}
+	return property
+}
+
+// Elicit asks the client to collect structured input from the user.
+func Elicit(ctx context.Context, session *Session, params ElicitParams) (*ElicitResult, error) {
+	if !CanElicit(session) {
+		return nil, ErrElicitationUnsupported
+	}
+
+	raw, err := session.Request(ctx, "elicitation/create", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ElicitResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("decode elicitation result: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestElicitationSchemaFor(t *testing.T) {
+	schema := InputSchema{Type: "object", Properties: map[string]any{
+		"name":  map[string]string{"type": "string", "description": "Item name"},
+		"other": map[string]any{"type": "number"},
+	}}
+
+	requested := ElicitationSchemaFor(schema, "name", "missing")
+	if requested.Type != "object" || len(requested.Required) != 2 {
+		t.Fatalf("unexpected requested schema: %+v", requested)
+	}
+	name := requested.Properties["name"].(map[string]any)
+	if name["type"] != "string" || name["description"] != "Item name" {
+		t.Fatalf("expected name property copied from tool schema, got %#v", name)
+	}
+	if missing := requested.Properties["missing"].(map[string]any); missing["type"] != "string" {
+		t.Fatalf("expected undescribed field to default to string, got %#v", missing)
+	}
+	if _, ok := requested.Properties["other"]; ok {
+		t.Fatal("expected only requested fields in schema")
+	}
+
+	name["enum"] = []string{"a"}
+	if _, ok := schema.Properties["name"].(map[string]string)["enum"]; ok {
+		t.Fatal("expected property copy not to alias the tool schema")
+	}
+}
+
+func TestElicit(t *testing.T) {
+	session := NewSession("session-1")
+	if CanElicit(session) {
+		t.Fatal("expected no elicitation without client capability")
+	}
+	if _, err := Elicit(context.Background(), session, ElicitParams{}); !errors.Is(err, ErrElicitationUnsupported) {
+		t.Fatalf("expected elicitation unsupported, got %v", err)
+	}
+
+	session.SetClient(LegacyProtocolVersion, Implementation{}, map[string]any{"elicitation": map[string]any{}})
+	if CanElicit(session) {
+		t.Fatal("expected no elicitation on protocol versions that predate it")
+	}
+
+	session.SetClient(ProtocolVersion, Implementation{}, map[string]any{"elicitation": map[string]any{}})
+	session.SetWriter(&respondingWriter{session: session, respond: func(req Request) ClientResponse {
+		return ClientResponse{JSONRPC: JSONRPCVersion, ID: req.ID, Result: json.RawMessage(`{"action":"accept","content":{"name":"Item A"}}`)}
+	}})
+
+	result, err := Elicit(context.Background(), session, ElicitParams{Message: "Which item?", RequestedSchema: ElicitationSchemaFor(InputSchema{}, "name")})
+	if err != nil {
+		t.Fatalf("Elicit failed: %v", err)
+	}
+	if result.Action != ElicitActionAccept || result.Content["name"] != "Item A" {
+		t.Fatalf("unexpected elicitation result: %+v", result)
+	}
+}
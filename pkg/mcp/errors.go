@@ -0,0 +1,36 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Handler errors fall into two groups. Protocol errors, such as an unknown
+// tool or malformed arguments, are returned as *ErrorResponse values and sent
+// to the client as JSON-RPC errors. Every other error returned by CallTool is
+// an execution error: the server reports it as a tool result with IsError set,
+// so the model can read the message and react to it.
+
+// NewError returns a protocol error with the given JSON-RPC code.
+func NewError(code int, message string, data any) *ErrorResponse {
+	return &ErrorResponse{Code: code, Message: message, Data: data}
+}
+
+// NewInvalidParamsError returns a protocol error for a request with bad parameters.
+func NewInvalidParamsError(format string, args ...any) *ErrorResponse {
+	return &ErrorResponse{Code: ErrorCodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+// AsProtocolError reports whether err carries a JSON-RPC error and returns it.
+func AsProtocolError(err error) (*ErrorResponse, bool) {
+	var protocolErr *ErrorResponse
+	if errors.As(err, &protocolErr) {
+		return protocolErr, true
+	}
+	return nil, false
+}
+
+// NewToolErrorResponse returns a tool result that reports err to the model.
+func NewToolErrorResponse(err error) ToolResponse {
+	return ToolResponse{Content: []ContentItem{{Type: "text", Text: err.Error()}}, IsError: true}
+}
@@ -0,0 +1,10 @@
+package mcp
+
+// Icon is an image a client may display next to a tool, prompt or resource.
+type Icon struct {
+	// Src is an http(s) URL or a data: URI.
+	Src      string `json:"src"`
+	MimeType string `json:"mimeType,omitempty"`
+	// Sizes lists the sizes the icon is available in, such as "48x48" or "any".
+	Sizes []string `json:"sizes,omitempty"`
+}
@@ -0,0 +1,224 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// NotificationMessage carries a log entry from the server to the client.
+const NotificationMessage = "notifications/message"
+
+// LoggingLevel is a syslog severity as used by the MCP logging capability.
+type LoggingLevel string
+
+// Logging levels, from least to most severe.
+const (
+	LoggingLevelDebug     LoggingLevel = "debug"
+	LoggingLevelInfo      LoggingLevel = "info"
+	LoggingLevelNotice    LoggingLevel = "notice"
+	LoggingLevelWarning   LoggingLevel = "warning"
+	LoggingLevelError     LoggingLevel = "error"
+	LoggingLevelCritical  LoggingLevel = "critical"
+	LoggingLevelAlert     LoggingLevel = "alert"
+	LoggingLevelEmergency LoggingLevel = "emergency"
+)
+
+// DefaultLoggingLevel is the minimum level sent to clients that never called logging/setLevel.
+const DefaultLoggingLevel = LoggingLevelInfo
+
+var loggingLevels = []LoggingLevel{
+	LoggingLevelDebug,
+	LoggingLevelInfo,
+	LoggingLevelNotice,
+	LoggingLevelWarning,
+	LoggingLevelError,
+	LoggingLevelCritical,
+	LoggingLevelAlert,
+	LoggingLevelEmergency,
+}
+
+// ParseLoggingLevel validates a level sent by the client.
+func ParseLoggingLevel(level string) (LoggingLevel, error) {
+	parsed := LoggingLevel(level)
+	if !slices.Contains(loggingLevels, parsed) {
+		return "", fmt.Errorf("unknown logging level %q", level)
+	}
+	return parsed, nil
+}
+
+// AtLeast reports whether l is as severe as minimum.
+func (l LoggingLevel) AtLeast(minimum LoggingLevel) bool {
+	return slices.Index(loggingLevels, l) >= slices.Index(loggingLevels, minimum)
+}
+
+// LoggingMessageParams are the params of notifications/message.
+type LoggingMessageParams struct {
+	Level  LoggingLevel `json:"level"`
+	Logger string       `json:"logger,omitempty"`
+	Data   any          `json:"data"`
+}
+
+// SetLogLevel sets the minimum level of log messages sent to the client.
+func (s *Session) SetLogLevel(level LoggingLevel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logLevel = level
+}
+
+// LogLevel returns the minimum level of log messages sent to the client.
+func (s *Session) LogLevel() LoggingLevel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.logLevel == "" {
+		return DefaultLoggingLevel
+	}
+	return s.logLevel
+}
+
+// Log sends a log entry to the client of the session carried by ctx when
+// level passes the session's filter. Without a session it does nothing.
+func Log(ctx context.Context, level LoggingLevel, logger string, data any) error {
+	session := SessionFromContext(ctx)
+	if session == nil || !level.AtLeast(session.LogLevel()) {
+		return nil
+	}
+	return NotifyContext(ctx, NotificationMessage, LoggingMessageParams{Level: level, Logger: logger, Data: data})
+}
+
+// WithClientLog marks ctx so that records a SlogHandler receives with it are
+// also sent to the client of the session ctx carries. Records logged with
+// unmarked contexts, such as the server's own errors, stay on the server.
+func WithClientLog(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ClientLogKey, true)
+}
+
+// clientLogSession returns the session whose client should receive records
+// logged with ctx, or nil when ctx is not marked by WithClientLog.
+func clientLogSession(ctx context.Context) *Session {
+	if marked, _ := ctx.Value(ClientLogKey).(bool); !marked {
+		return nil
+	}
+	return SessionFromContext(ctx)
+}
+
+// SlogHandler forwards records logged with a WithClientLog context to that
+// session's client as notifications/message, in addition to the wrapped handler.
+type SlogHandler struct {
+	next   slog.Handler
+	logger string
+	attrs  []slog.Attr
+	groups []string
+}
+
+// NewSlogHandler wraps next so that client-marked records also reach the client.
+// logger names the source in forwarded messages.
+func NewSlogHandler(next slog.Handler, logger string) *SlogHandler {
+	return &SlogHandler{next: next, logger: logger}
+}
+
+// Enabled reports whether either the wrapped handler or the session's client wants the level.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.next.Enabled(ctx, level) {
+		return true
+	}
+	session := clientLogSession(ctx)
+	return session != nil && slogLevel(level).AtLeast(session.LogLevel())
+}
+
+// Handle passes the record on and forwards client-marked records to the
+// session's client.
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	var err error
+	if h.next.Enabled(ctx, record.Level) {
+		err = h.next.Handle(ctx, record)
+	}
+
+	if clientLogSession(ctx) == nil {
+		return err
+	}
+
+	data := map[string]any{"message": record.Message}
+	prefix := groupPrefix(h.groups)
+	for _, attr := range h.attrs {
+		addAttr(data, "", attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addAttr(data, prefix, attr)
+		return true
+	})
+	// Delivery failures must not recurse into the logger.
+	_ = Log(ctx, slogLevel(record.Level), h.logger, data)
+	return err
+}
+
+// WithAttrs returns a handler that adds attrs to every record.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	prefix := groupPrefix(h.groups)
+	clone.attrs = slices.Clone(h.attrs)
+	for _, attr := range attrs {
+		clone.attrs = append(clone.attrs, slog.Attr{Key: prefix + attr.Key, Value: attr.Value})
+	}
+	return &clone
+}
+
+// WithGroup returns a handler that nests later attributes under name.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.groups = append(slices.Clone(h.groups), name)
+	return &clone
+}
+
+func groupPrefix(groups []string) string {
+	prefix := ""
+	for _, group := range groups {
+		prefix += group + "."
+	}
+	return prefix
+}
+
+// addAttr flattens attr into data using dotted keys for groups
+func addAttr(data map[string]any, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		groupKey := prefix
+		if attr.Key != "" {
+			groupKey += attr.Key + "."
+		}
+		for _, member := range value.Group() {
+			addAttr(data, groupKey, member)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+	if err, ok := value.Any().(error); ok {
+		data[prefix+attr.Key] = err.Error()
+		return
+	}
+	data[prefix+attr.Key] = value.Any()
+}
+
+// slogLevel maps a slog level onto the closest MCP logging level
+func slogLevel(level slog.Level) LoggingLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return LoggingLevelDebug
+	case level < slog.LevelWarn:
+		return LoggingLevelInfo
+	case level < slog.LevelError:
+		return LoggingLevelWarning
+	case level == slog.LevelError:
+		return LoggingLevelError
+	default:
+		return LoggingLevelCritical
+	}
+}
@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLoggingLevel(t *testing.T) {
+	level, err := ParseLoggingLevel("warning")
+	if err != nil || level != LoggingLevelWarning {
+		t.Fatalf("expected warning level, got %q (%v)", level, err)
+	}
+	if _, err := ParseLoggingLevel("verbose"); err == nil {
+		t.Fatal("expected unknown level to fail")
+	}
+	if !LoggingLevelError.AtLeast(LoggingLevelWarning) || LoggingLevelInfo.AtLeast(LoggingLevelWarning) {
+		t.Fatal("unexpected level ordering")
+	}
+}
+
+func TestLogFiltersBySessionLevel(t *testing.T) {
+	if err := Log(context.Background(), LoggingLevelError, "test", "no session"); err != nil {
+		t.Fatalf("expected log without session to no-op, got %v", err)
+	}
+
+	session := NewSession("session-1")
+	writer := &recordingWriter{}
+	session.SetWriter(writer)
+	ctx := WithSession(context.Background(), session)
+
+	if session.LogLevel() != DefaultLoggingLevel {
+		t.Fatalf("expected default level, got %s", session.LogLevel())
+	}
+	Log(ctx, LoggingLevelDebug, "test", "dropped")
+	Log(ctx, LoggingLevelInfo, "test", "sent")
+
+	session.SetLogLevel(LoggingLevelError)
+	Log(ctx, LoggingLevelWarning, "test", "dropped")
+	Log(ctx, LoggingLevelCritical, "test", "sent")
+
+	if len(writer.messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(writer.messages))
+	}
+	params := writer.messages[1].(Notification).Params.(LoggingMessageParams)
+	if params.Level != LoggingLevelCritical || params.Logger != "test" || params.Data != "sent" {
+		t.Fatalf("unexpected log message: %+v", params)
+	}
+}
+
+func TestSlogHandlerForwardsClientRecords(t *testing.T) {
+	var stderr bytes.Buffer
+	logger := slog.New(NewSlogHandler(slog.NewTextHandler(&stderr, &slog.HandlerOptions{Level: slog.LevelInfo}), "server"))
+
+	session := NewSession("session-1")
+	writer := &recordingWriter{}
+	session.SetWriter(writer)
+	session.SetLogLevel(LoggingLevelDebug)
+	ctx := WithSession(context.Background(), session)
+	clientCtx := WithClientLog(ctx)
+
+	logger.Info("no session")
+	logger.ErrorContext(ctx, "server only", "error", errors.New("internal detail"))
+	logger.With("component", "catalog").WithGroup("call").WarnContext(clientCtx, "tool failed", "tool", "listItems", "error", errors.New("boom"))
+	logger.DebugContext(clientCtx, "debug detail")
+	logger.ErrorContext(WithClientLog(context.Background()), "marked without session")
+
+	if !strings.Contains(stderr.String(), "no session") || !strings.Contains(stderr.String(), "server only") || !strings.Contains(stderr.String(), "tool failed") {
+		t.Fatalf("expected records on the wrapped handler, got %q", stderr.String())
+	}
+	if strings.Contains(stderr.String(), "debug detail") {
+		t.Fatal("expected wrapped handler level to still apply")
+	}
+
+	if len(writer.messages) != 2 {
+		t.Fatalf("expected only the 2 client-marked records to be forwarded, got %d", len(writer.messages))
+	}
+	params := writer.messages[0].(Notification).Params.(LoggingMessageParams)
+	data := params.Data.(map[string]any)
+	if params.Level != LoggingLevelWarning || params.Logger != "server" {
+		t.Fatalf("unexpected forwarded record: %+v", params)
+	}
+	if data["message"] != "tool failed" || data["component"] != "catalog" || data["call.tool"] != "listItems" || data["call.error"] != "boom" {
+		t.Fatalf("unexpected forwarded data: %#v", data)
+	}
+	if level := writer.messages[1].(Notification).Params.(LoggingMessageParams).Level; level != LoggingLevelDebug {
+		t.Fatalf("expected debug record forwarded to client, got %s", level)
+	}
+}
@@ -0,0 +1,53 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+// Handler processes one JSON-RPC request and returns the result to send.
+// Returning an *ErrorResponse, possibly wrapped, sends that JSON-RPC error;
+// any other error is sent as an internal error.
+type Handler func(ctx context.Context, req Request) (any, error)
+
+// Middleware wraps a Handler to add behavior around every request, such as
+// authorization, metrics or auditing. It can short-circuit by returning
+// without calling next, and it observes the result or error next returns.
+// The request's session and ResponseSender are available from ctx through
+// SessionFromContext and ResponseSenderFromContext.
+type Middleware func(next Handler) Handler
+
+// Chain wraps h in middleware so that the first middleware is the outermost.
+func Chain(h Handler, middleware ...Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// ResponseSenderFromContext returns the sender for the current request, if any.
+func ResponseSenderFromContext(ctx context.Context) ResponseSender {
+	sender, _ := ctx.Value(ResponseSenderKey).(ResponseSender)
+	return sender
+}
+
+// Recover returns middleware that turns a panic in a later handler into an
+// internal error response and logs the stack, so one bad request cannot take
+// down the connection.
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req Request) (result any, err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					// Logged without ctx so the stack never reaches the client
+					// through a SlogHandler.
+					slog.Error("panic handling request", "method", req.Method, "panic", recovered, "stack", string(debug.Stack()))
+					result, err = nil, NewError(ErrorCodeInternalError, "Internal error", fmt.Sprintf("panic: %v", recovered))
+				}
+			}()
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req Request) (any, error) {
+				calls = append(calls, name+" before")
+				result, err := next(ctx, req)
+				calls = append(calls, name+" after")
+				return result, err
+			}
+		}
+	}
+	h := Chain(func(ctx context.Context, req Request) (any, error) {
+		calls = append(calls, "handler")
+		return "ok", nil
+	}, trace("outer"), trace("inner"))
+
+	result, err := h(context.Background(), Request{Method: "ping"})
+	if err != nil || result != "ok" {
+		t.Fatalf("unexpected result %v (%v)", result, err)
+	}
+	if got := strings.Join(calls, ", "); got != "outer before, inner before, handler, inner after, outer after" {
+		t.Fatalf("unexpected call order: %s", got)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	h := Chain(func(ctx context.Context, req Request) (any, error) {
+		panic("boom")
+	}, Recover())
+
+	result, err := h(context.Background(), Request{Method: "tools/call"})
+	protocolErr, ok := AsProtocolError(err)
+	if result != nil || !ok || protocolErr.Code != ErrorCodeInternalError || protocolErr.Data != "panic: boom" {
+		t.Fatalf("expected internal error from panic, got %v (%v)", result, err)
+	}
+}
+
+func TestRecoverKeepsPanicOffClientLog(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	slog.SetDefault(slog.New(NewSlogHandler(slog.NewTextHandler(io.Discard, nil), "server")))
+
+	session := NewSession("session-1")
+	writer := &recordingWriter{}
+	session.SetWriter(writer)
+	session.SetLogLevel(LoggingLevelDebug)
+	ctx := WithClientLog(WithSession(context.Background(), session))
+
+	h := Chain(func(ctx context.Context, req Request) (any, error) {
+		panic("boom")
+	}, Recover())
+	if _, err := h(ctx, Request{Method: "tools/call"}); err == nil {
+		t.Fatal("expected internal error from panic")
+	}
+
+	if len(writer.messages) != 0 {
+		t.Fatalf("expected no notifications/message after a recovered panic, got %+v", writer.messages)
+	}
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"sync"
+)
+
+// Notification method names for capability list changes.
+const (
+	NotificationToolsListChanged     = "notifications/tools/list_changed"
+	NotificationResourcesListChanged = "notifications/resources/list_changed"
+	NotificationPromptsListChanged   = "notifications/prompts/list_changed"
+)
+
+// NotificationCancelled tells the peer that a request it received was abandoned.
+const NotificationCancelled = "notifications/cancelled"
+
+// Notification is a server-initiated JSON-RPC notification.
+type Notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// Notifier publishes server-initiated notifications.
+type Notifier interface {
+	// Notify publishes a notification with the given method and params.
+	Notify(method string, params any)
+}
+
+// NotifierBinder is implemented by handlers that publish notifications.
+// The server binds its notification bus to such handlers and only advertises
+// listChanged for capabilities whose handler implements it.
+type NotifierBinder interface {
+	// BindNotifier gives the handler the notifier to publish to.
+	BindNotifier(n Notifier)
+}
+
+// SessionTracker is implemented by servers that broadcast notifications to the
+// sessions they know about. Transports call TrackSession for sessions they
+// restore from state shared with another process, since those never went
+// through initialize on this server.
+type SessionTracker interface {
+	// TrackSession adds session to the sessions that receive broadcasts.
+	TrackSession(session *Session)
+}
+
+// MessageWriter delivers server-initiated JSON-RPC messages to a client.
+type MessageWriter interface {
+	// WriteMessage encodes and sends a single JSON-RPC message.
+	WriteMessage(msg any) error
+}
+
+// NotificationBus fans published notifications out to every subscriber.
+type NotificationBus struct {
+	mu          sync.RWMutex
+	nextID      int
+	subscribers map[int]func(Notification)
+}
+
+// NewNotificationBus creates an empty notification bus.
+func NewNotificationBus() *NotificationBus {
+	return &NotificationBus{subscribers: make(map[int]func(Notification))}
+}
+
+// Notify publishes a notification to all current subscribers.
+func (b *NotificationBus) Notify(method string, params any) {
+	notification := Notification{JSONRPC: JSONRPCVersion, Method: method, Params: params}
+
+	b.mu.RLock()
+	subscribers := make([]func(Notification), 0, len(b.subscribers))
+	for _, fn := range b.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	b.mu.RUnlock()
+
+	for _, fn := range subscribers {
+		fn(notification)
+	}
+}
+
+// Subscribe registers fn for every future notification and returns a
+// function that removes the subscription.
+func (b *NotificationBus) Subscribe(fn func(Notification)) func() {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = fn
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		delete(b.subscribers, id)
+		b.mu.Unlock()
+	}
+}
@@ -0,0 +1,65 @@
+package mcp
+
+import (
+	"errors"
+	"testing"
+)
+
+type recordingWriter struct {
+	messages []any
+}
+
+func (w *recordingWriter) WriteMessage(msg any) error {
+	w.messages = append(w.messages, msg)
+	return nil
+}
+
+func TestNotificationBus(t *testing.T) {
+	bus := NewNotificationBus()
+
+	var first, second []Notification
+	unsubscribe := bus.Subscribe(func(n Notification) { first = append(first, n) })
+	bus.Subscribe(func(n Notification) { second = append(second, n) })
+
+	bus.Notify(NotificationToolsListChanged, nil)
+	if len(first) != 1 || len(second) != 1 {
+		t.Fatalf("expected both subscribers notified, got %d and %d", len(first), len(second))
+	}
+	if first[0].JSONRPC != JSONRPCVersion || first[0].Method != NotificationToolsListChanged {
+		t.Fatalf("unexpected notification: %+v", first[0])
+	}
+
+	unsubscribe()
+	bus.Notify(NotificationPromptsListChanged, nil)
+	if len(first) != 1 {
+		t.Fatalf("expected unsubscribed handler to be skipped, got %d notifications", len(first))
+	}
+	if len(second) != 2 || second[1].Method != NotificationPromptsListChanged {
+		t.Fatalf("unexpected notifications for remaining subscriber: %+v", second)
+	}
+}
+
+func TestSessionNotify(t *testing.T) {
+	session := NewSession("session-1")
+	if err := session.Notify(NotificationToolsListChanged, nil); !errors.Is(err, ErrNoMessageWriter) {
+		t.Fatalf("expected missing writer error, got %v", err)
+	}
+
+	writer := &recordingWriter{}
+	session.SetWriter(writer)
+	if err := session.Notify(NotificationResourcesListChanged, nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(writer.messages) != 1 {
+		t.Fatalf("expected one message, got %d", len(writer.messages))
+	}
+	notification, ok := writer.messages[0].(Notification)
+	if !ok || notification.Method != NotificationResourcesListChanged {
+		t.Fatalf("unexpected message: %#v", writer.messages[0])
+	}
+
+	session.Close()
+	if err := session.Notify(NotificationResourcesListChanged, nil); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("expected closed session error, got %v", err)
+	}
+}
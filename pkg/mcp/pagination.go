@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultPageSize is the number of entries returned per list page when none is configured.
+const DefaultPageSize = 100
+
+// ErrInvalidCursor is returned for cursors that were not issued by this server
+// for the requested list, or that were modified by the client.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest asks a handler for one page of a list.
+type PageRequest struct {
+	// Cursor is the handler's own position from a previous page, or "" for the first page.
+	Cursor string
+	// Limit is the maximum number of entries to return.
+	Limit int
+}
+
+// ToolPager is implemented by tool handlers that page natively.
+// The returned cursor is the handler position of the next page, or "" on the last page.
+type ToolPager interface {
+	ListToolsPage(ctx context.Context, page PageRequest) ([]Tool, string, error)
+}
+
+// ResourcePager is implemented by resource handlers that page natively.
+type ResourcePager interface {
+	ListResourcesPage(ctx context.Context, page PageRequest) ([]Resource, string, error)
+}
+
+// PromptPager is implemented by prompt handlers that page natively.
+type PromptPager interface {
+	ListPromptsPage(ctx context.Context, page PageRequest) ([]Prompt, string, error)
+}
+
+// ListToolsResult is the result of tools/list.
+type ListToolsResult struct {
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// ListResourcesResult is the result of resources/list.
+type ListResourcesResult struct {
+	Resources  []Resource `json:"resources"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+// ListPromptsResult is the result of prompts/list.
+type ListPromptsResult struct {
+	Prompts    []Prompt `json:"prompts"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+// Paginate returns the page of items starting at the offset encoded in cursor,
+// along with the cursor of the following page. It serves handlers that hold
+// their whole list in memory.
+func Paginate[T any](items []T, page PageRequest) ([]T, string, error) {
+	offset := 0
+	if page.Cursor != "" {
+		var err error
+		offset, err = strconv.Atoi(page.Cursor)
+		if err != nil || offset < 0 || offset > len(items) {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+
+	limit := page.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	end := min(offset+limit, len(items))
+
+	next := ""
+	if end < len(items) {
+		next = strconv.Itoa(end)
+	}
+	return items[offset:end], next, nil
+}
+
+// CursorCodec turns handler positions into opaque, tamper-resistant cursors.
+// Each cursor is bound to the list it was issued for and signed with HMAC-SHA256.
+type CursorCodec struct {
+	key []byte
+}
+
+type cursorPayload struct {
+	List     string `json:"l"`
+	Position string `json:"p"`
+}
+
+// NewCursorCodec creates a codec signing with key. A nil or empty key is
+// replaced by a random one, so cursors are only valid for this process.
+func NewCursorCodec(key []byte) (*CursorCodec, error) {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("generate cursor key: %w", err)
+		}
+	}
+	return &CursorCodec{key: append([]byte(nil), key...)}, nil
+}
+
+// Encode returns the cursor for position within list, or "" when position is empty.
+func (c *CursorCodec) Encode(list, position string) string {
+	if position == "" {
+		return ""
+	}
+	payload, _ := json.Marshal(cursorPayload{List: list, Position: position})
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload))
+}
+
+// Decode verifies cursor and returns the handler position it carries.
+// An empty cursor decodes to the first page.
+func (c *CursorCodec) Decode(list, cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+
+	encodedPayload, encodedMAC, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return "", ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, c.sign(payload)) {
+		return "", ErrInvalidCursor
+	}
+
+	var decoded cursorPayload
+	if err := json.Unmarshal(payload, &decoded); err != nil || decoded.List != list || decoded.Position == "" {
+		return "", ErrInvalidCursor
+	}
+	return decoded.Position, nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	page, next, err := Paginate(items, PageRequest{Limit: 2})
+	if err != nil || !slices.Equal(page, []int{1, 2}) || next != "2" {
+		t.Fatalf("unexpected first page: %v %q %v", page, next, err)
+	}
+	page, next, err = Paginate(items, PageRequest{Cursor: "4", Limit: 2})
+	if err != nil || !slices.Equal(page, []int{5}) || next != "" {
+		t.Fatalf("unexpected last page: %v %q %v", page, next, err)
+	}
+	for _, cursor := range []string{"x", "-1", "6"} {
+		if _, _, err := Paginate(items, PageRequest{Cursor: cursor, Limit: 2}); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("cursor %q: expected ErrInvalidCursor, got %v", cursor, err)
+		}
+	}
+}
+
+func TestCursorCodec(t *testing.T) {
+	codec, err := NewCursorCodec([]byte("secret"))
+	if err != nil {
+		t.Fatalf("NewCursorCodec failed: %v", err)
+	}
+
+	if cursor := codec.Encode("tools", ""); cursor != "" {
+		t.Fatalf("expected no cursor for the last page, got %q", cursor)
+	}
+	cursor := codec.Encode("tools", "42")
+	if position, err := codec.Decode("tools", cursor); err != nil || position != "42" {
+		t.Fatalf("round trip failed: %q %v", position, err)
+	}
+	if position, err := codec.Decode("tools", ""); err != nil || position != "" {
+		t.Fatalf("expected empty cursor to decode to the first page, got %q %v", position, err)
+	}
+
+	other, _ := NewCursorCodec([]byte("other"))
+	for name, decode := range map[string]func() (string, error){
+		"wrong list": func() (string, error) { return codec.Decode("prompts", cursor) },
+		"wrong key":  func() (string, error) { return other.Decode("tools", cursor) },
+		"tampered":   func() (string, error) { return codec.Decode("tools", "x"+cursor) },
+		"no mac":     func() (string, error) { return codec.Decode("tools", "42") },
+		"bad base64": func() (string, error) { return codec.Decode("tools", "!!.!!") },
+	} {
+		if _, err := decode(); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("%s: expected ErrInvalidCursor, got %v", name, err)
+		}
+	}
+}
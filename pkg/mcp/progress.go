@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// NotificationProgress reports progress on a long-running request.
+const NotificationProgress = "notifications/progress"
+
+// ErrProgressNotIncreasing is returned when a progress value does not exceed the previous one.
+var ErrProgressNotIncreasing = errors.New("progress must increase with each notification")
+
+// RequestMeta carries the _meta field of a request's params.
+type RequestMeta struct {
+	ProgressToken any `json:"progressToken,omitempty"`
+}
+
+// ProgressParams are the params of notifications/progress.
+type ProgressParams struct {
+	ProgressToken any     `json:"progressToken"`
+	Progress      float64 `json:"progress"`
+	Total         float64 `json:"total,omitempty"`
+	Message       string  `json:"message,omitempty"`
+}
+
+// ProgressReporter sends progress notifications for one request.
+// A nil reporter, used when the client supplied no progress token, does nothing.
+type ProgressReporter struct {
+	token any
+	send  func(Notification) error
+
+	mu      sync.Mutex
+	last    float64
+	started bool
+}
+
+// WithProgressToken returns a copy of ctx carrying a progress reporter for
+// token. Notifications go to the same destination as NotifyContext would use.
+// A nil token leaves ctx unchanged.
+func WithProgressToken(ctx context.Context, token any) context.Context {
+	if token == nil {
+		return ctx
+	}
+	reporter := &ProgressReporter{token: token, send: func(n Notification) error {
+		return NotifyContext(ctx, n.Method, n.Params)
+	}}
+	return context.WithValue(ctx, ProgressReporterKey, reporter)
+}
+
+// ProgressFromContext returns the progress reporter carried by ctx, or nil.
+func ProgressFromContext(ctx context.Context) *ProgressReporter {
+	reporter, _ := ctx.Value(ProgressReporterKey).(*ProgressReporter)
+	return reporter
+}
+
+// Enabled reports whether the client asked for progress notifications.
+func (p *ProgressReporter) Enabled() bool {
+	return p != nil
+}
+
+// Report sends a progress notification. Total and message are omitted when zero.
+func (p *ProgressReporter) Report(progress, total float64, message string) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started && progress <= p.last {
+		return ErrProgressNotIncreasing
+	}
+	p.started = true
+	p.last = progress
+
+	return p.send(Notification{JSONRPC: JSONRPCVersion, Method: NotificationProgress, Params: ProgressParams{
+		ProgressToken: p.token,
+		Progress:      progress,
+		Total:         total,
+		Message:       message,
+	}})
+}
+
+// NotifyContext sends a notification related to the request ctx belongs to.
+// It prefers the request-scoped writer and falls back to the session's stream.
+func NotifyContext(ctx context.Context, method string, params any) error {
+	if writer := MessageWriterFromContext(ctx); writer != nil {
+		return writer.WriteMessage(Notification{JSONRPC: JSONRPCVersion, Method: method, Params: params})
+	}
+	if session := SessionFromContext(ctx); session != nil {
+		return session.Notify(method, params)
+	}
+	return ErrNoMessageWriter
+}
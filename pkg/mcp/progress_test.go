@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestProgressReporter(t *testing.T) {
+	if reporter := ProgressFromContext(WithProgressToken(context.Background(), nil)); reporter.Enabled() {
+		t.Fatal("expected no reporter without a progress token")
+	}
+	var none *ProgressReporter
+	if err := none.Report(1, 2, "ignored"); err != nil {
+		t.Fatalf("expected nil reporter to no-op, got %v", err)
+	}
+
+	session := NewSession("session-1")
+	sessionWriter := &recordingWriter{}
+	session.SetWriter(sessionWriter)
+	requestWriter := &recordingWriter{}
+	ctx := WithMessageWriter(WithSession(context.Background(), session), requestWriter)
+
+	reporter := ProgressFromContext(WithProgressToken(ctx, "token-1"))
+	if !reporter.Enabled() {
+		t.Fatal("expected reporter for progress token")
+	}
+	if err := reporter.Report(1, 4, "first"); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if err := reporter.Report(1, 4, "again"); !errors.Is(err, ErrProgressNotIncreasing) {
+		t.Fatalf("expected non-increasing progress to fail, got %v", err)
+	}
+
+	if len(sessionWriter.messages) != 0 || len(requestWriter.messages) != 1 {
+		t.Fatalf("expected progress on request stream, got %d session and %d request messages", len(sessionWriter.messages), len(requestWriter.messages))
+	}
+	notification := requestWriter.messages[0].(Notification)
+	params := notification.Params.(ProgressParams)
+	if notification.Method != NotificationProgress || params.ProgressToken != "token-1" || params.Progress != 1 || params.Total != 4 || params.Message != "first" {
+		t.Fatalf("unexpected progress notification: %+v", notification)
+	}
+
+	fallback := ProgressFromContext(WithProgressToken(WithSession(context.Background(), session), float64(9)))
+	if err := fallback.Report(1, 0, ""); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if len(sessionWriter.messages) != 1 {
+		t.Fatalf("expected progress on session stream without request stream, got %d", len(sessionWriter.messages))
+	}
+}
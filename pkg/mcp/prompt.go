@@ -3,8 +3,10 @@ package mcp
 // Prompt-related types
 type Prompt struct {
 	Name        string           `json:"name"`
+	Title       string           `json:"title,omitempty"`
 	Description string           `json:"description"`
 	Arguments   []PromptArgument `json:"arguments,omitempty"`
+	Icons       []Icon           `json:"icons,omitempty"`
 }
 
 type PromptArgument struct {
@@ -27,7 +29,6 @@ type PromptMessage struct {
 	Content MessageContent `json:"content"`
 }
 
-type MessageContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
-}
+// MessageContent is the content of a prompt message; it accepts the same
+// content types as tool results.
+type MessageContent = ContentItem
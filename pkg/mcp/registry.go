@@ -0,0 +1,53 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// NotificationFunc handles one client notification. No response is sent.
+type NotificationFunc func(ctx context.Context, req Request) error
+
+// DecodeParams decodes request params into a value of type P by way of JSON.
+// Missing params decode to the zero value. A mismatch is an invalid params
+// protocol error.
+func DecodeParams[P any](params any) (P, error) {
+	var decoded P
+	if params == nil {
+		return decoded, nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return decoded, NewError(ErrorCodeInvalidParams, "Invalid parameters", err.Error())
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return decoded, NewError(ErrorCodeInvalidParams, "Invalid parameters", err.Error())
+	}
+	return decoded, nil
+}
+
+// TypedHandler adapts fn to a Handler that decodes request params into P.
+func TypedHandler[P any, R any](fn func(ctx context.Context, params P) (R, error)) Handler {
+	return func(ctx context.Context, req Request) (any, error) {
+		params, err := DecodeParams[P](req.Params)
+		if err != nil {
+			return nil, err
+		}
+		result, err := fn(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+// TypedNotification adapts fn to a NotificationFunc that decodes params into P.
+func TypedNotification[P any](fn func(ctx context.Context, params P) error) NotificationFunc {
+	return func(ctx context.Context, req Request) error {
+		params, err := DecodeParams[P](req.Params)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, params)
+	}
+}
@@ -0,0 +1,45 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestTypedHandler(t *testing.T) {
+	type params struct {
+		Name  string `json:"name"`
+		Limit int    `json:"limit"`
+	}
+	h := TypedHandler(func(ctx context.Context, p params) (string, error) {
+		return fmt.Sprintf("%s:%d", p.Name, p.Limit), nil
+	})
+
+	result, err := h(context.Background(), Request{Params: map[string]any{"name": "a", "limit": float64(3)}})
+	if err != nil || result != "a:3" {
+		t.Fatalf("unexpected result %v (%v)", result, err)
+	}
+
+	result, err = h(context.Background(), Request{})
+	if err != nil || result != ":0" {
+		t.Fatalf("expected missing params to decode to zero value, got %v (%v)", result, err)
+	}
+
+	_, err = h(context.Background(), Request{Params: map[string]any{"limit": "three"}})
+	if protocolErr, ok := AsProtocolError(err); !ok || protocolErr.Code != ErrorCodeInvalidParams {
+		t.Fatalf("expected invalid params error, got %v", err)
+	}
+}
+
+func TestTypedNotification(t *testing.T) {
+	var got string
+	h := TypedNotification(func(ctx context.Context, p struct {
+		URI string `json:"uri"`
+	}) error {
+		got = p.URI
+		return nil
+	})
+	if err := h(context.Background(), Request{Params: map[string]any{"uri": "catalog://items"}}); err != nil || got != "catalog://items" {
+		t.Fatalf("unexpected decode %q (%v)", got, err)
+	}
+}
@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultClientRequestTimeout bounds server-to-client requests whose context has no deadline.
+const DefaultClientRequestTimeout = 60 * time.Second
+
+// ErrUnknownResponse is returned by HandleResponse when no request is waiting for the response.
+var ErrUnknownResponse = errors.New("response does not match a pending request")
+
+// ClientResponse is a JSON-RPC response sent by the client to a server-initiated request.
+type ClientResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      any             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *ErrorResponse  `json:"error,omitempty"`
+}
+
+// CancelledParams are the params of notifications/cancelled.
+type CancelledParams struct {
+	RequestID any    `json:"requestId"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Error makes a JSON-RPC error usable as a Go error.
+func (e *ErrorResponse) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// WithMessageWriter returns a copy of ctx whose server-to-client messages are
+// written to writer instead of the session's default stream. Transports use it
+// to keep requests made while handling a client request on that request's stream.
+func WithMessageWriter(ctx context.Context, writer MessageWriter) context.Context {
+	return context.WithValue(ctx, MessageWriterKey, writer)
+}
+
+// MessageWriterFromContext returns the request-scoped writer carried by ctx, or nil.
+func MessageWriterFromContext(ctx context.Context) MessageWriter {
+	writer, _ := ctx.Value(MessageWriterKey).(MessageWriter)
+	return writer
+}
+
+// Request sends a server-to-client request and waits for the client's result.
+// The call ends when the client responds, ctx is done or the session closes;
+// on cancellation or timeout the client is sent notifications/cancelled.
+func (s *Session) Request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultClientRequestTimeout)
+		defer cancel()
+	}
+
+	writer := MessageWriterFromContext(ctx)
+
+	s.mu.Lock()
+	if s.state == SessionClosed {
+		s.mu.Unlock()
+		return nil, ErrSessionClosed
+	}
+	if writer == nil {
+		writer = s.writer
+	}
+	if writer == nil {
+		s.mu.Unlock()
+		return nil, ErrNoMessageWriter
+	}
+	s.nextRequestID++
+	id := fmt.Sprintf("srv-%d", s.nextRequestID)
+	ch := make(chan ClientResponse, 1)
+	if s.pending == nil {
+		s.pending = make(map[string]chan ClientResponse)
+	}
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	if err := writer.WriteMessage(Request{JSONRPC: JSONRPCVersion, ID: id, Method: method, Params: params}); err != nil {
+		s.forgetRequest(id)
+		return nil, fmt.Errorf("send %s request: %w", method, err)
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, ErrSessionClosed
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-ctx.Done():
+		s.forgetRequest(id)
+		reason := "request cancelled"
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			reason = "request timed out"
+		}
+		_ = writer.WriteMessage(Notification{JSONRPC: JSONRPCVersion, Method: NotificationCancelled, Params: CancelledParams{RequestID: id, Reason: reason}})
+		return nil, fmt.Errorf("%s request: %w", method, ctx.Err())
+	}
+}
+
+// HandleResponse delivers a client response to the pending request with the same ID.
+func (s *Session) HandleResponse(resp ClientResponse) error {
+	id, ok := resp.ID.(string)
+	if !ok {
+		return ErrUnknownResponse
+	}
+
+	s.mu.Lock()
+	ch, ok := s.pending[id]
+	delete(s.pending, id)
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrUnknownResponse
+	}
+	ch <- resp
+	return nil
+}
+
+func (s *Session) forgetRequest(id string) {
+	s.mu.Lock()
+	delete(s.pending, id)
+	s.mu.Unlock()
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// respondingWriter answers every request it writes with respond.
+type respondingWriter struct {
+	session  *Session
+	respond  func(req Request) ClientResponse
+	messages chan any
+}
+
+func (w *respondingWriter) WriteMessage(msg any) error {
+	if w.messages != nil {
+		w.messages <- msg
+	}
+	if req, ok := msg.(Request); ok && w.respond != nil {
+		go w.session.HandleResponse(w.respond(req))
+	}
+	return nil
+}
+
+func TestSessionRequest(t *testing.T) {
+	t.Run("returns client result", func(t *testing.T) {
+		session := NewSession("session-1")
+		session.SetWriter(&respondingWriter{session: session, respond: func(req Request) ClientResponse {
+			return ClientResponse{JSONRPC: JSONRPCVersion, ID: req.ID, Result: json.RawMessage(`{"ok":true}`)}
+		}})
+
+		result, err := session.Request(context.Background(), "ping", nil)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if string(result) != `{"ok":true}` {
+			t.Fatalf("unexpected result %s", result)
+		}
+	})
+
+	t.Run("returns client error", func(t *testing.T) {
+		session := NewSession("session-1")
+		session.SetWriter(&respondingWriter{session: session, respond: func(req Request) ClientResponse {
+			return ClientResponse{JSONRPC: JSONRPCVersion, ID: req.ID, Error: &ErrorResponse{Code: -1, Message: "User rejected sampling request"}}
+		}})
+
+		_, err := session.Request(context.Background(), "sampling/createMessage", nil)
+		var rpcErr *ErrorResponse
+		if !errors.As(err, &rpcErr) || rpcErr.Code != -1 {
+			t.Fatalf("expected client error, got %v", err)
+		}
+	})
+
+	t.Run("timeout sends cancellation", func(t *testing.T) {
+		session := NewSession("session-1")
+		writer := &respondingWriter{session: session, messages: make(chan any, 2)}
+		session.SetWriter(writer)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := session.Request(ctx, "sampling/createMessage", nil); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded, got %v", err)
+		}
+
+		req := (<-writer.messages).(Request)
+		notification, ok := (<-writer.messages).(Notification)
+		if !ok || notification.Method != NotificationCancelled {
+			t.Fatalf("expected cancellation notification, got %#v", notification)
+		}
+		if params := notification.Params.(CancelledParams); params.RequestID != req.ID {
+			t.Fatalf("expected cancellation for %v, got %v", req.ID, params.RequestID)
+		}
+		if err := session.HandleResponse(ClientResponse{ID: req.ID}); !errors.Is(err, ErrUnknownResponse) {
+			t.Fatalf("expected late response to be unknown, got %v", err)
+		}
+	})
+
+	t.Run("close fails pending request", func(t *testing.T) {
+		session := NewSession("session-1")
+		writer := &respondingWriter{session: session, messages: make(chan any, 1)}
+		session.SetWriter(writer)
+
+		go func() {
+			<-writer.messages
+			session.Close()
+		}()
+		if _, err := session.Request(context.Background(), "ping", nil); !errors.Is(err, ErrSessionClosed) {
+			t.Fatalf("expected closed session error, got %v", err)
+		}
+	})
+
+	t.Run("request-scoped writer preferred", func(t *testing.T) {
+		session := NewSession("session-1")
+		session.SetWriter(&recordingWriter{})
+		scoped := &respondingWriter{session: session, respond: func(req Request) ClientResponse {
+			return ClientResponse{JSONRPC: JSONRPCVersion, ID: req.ID, Result: json.RawMessage(`{}`)}
+		}}
+
+		if _, err := session.Request(WithMessageWriter(context.Background(), scoped), "ping", nil); err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+	})
+}
+
+func TestCreateMessageRequiresSamplingCapability(t *testing.T) {
+	session := NewSession("session-1")
+	if _, err := CreateMessage(context.Background(), session, CreateMessageParams{MaxTokens: 10}); !errors.Is(err, ErrSamplingUnsupported) {
+		t.Fatalf("expected sampling unsupported, got %v", err)
+	}
+
+	session.SetClient(ProtocolVersion, Implementation{}, map[string]any{"sampling": map[string]any{}})
+	session.SetWriter(&respondingWriter{session: session, respond: func(req Request) ClientResponse {
+		if req.Method != "sampling/createMessage" {
+			t.Errorf("unexpected method %s", req.Method)
+		}
+		return ClientResponse{JSONRPC: JSONRPCVersion, ID: req.ID, Result: json.RawMessage(`{"role":"assistant","content":{"type":"text","text":"summary"},"model":"test-model"}`)}
+	}})
+
+	result, err := CreateMessage(context.Background(), session, CreateMessageParams{MaxTokens: 10})
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	if result.Content.Text != "summary" || result.Model != "test-model" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
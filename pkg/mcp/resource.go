@@ -1,14 +1,39 @@
 package mcp
 
+import "encoding/json"
+
 // Resource-related types
 type Resource struct {
-	URI  string `json:"uri"`
-	Name string `json:"name"`
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	// Size is the size of the resource contents in bytes, if known.
+	Size        int64        `json:"size,omitempty"`
+	Annotations *Annotations `json:"annotations,omitempty"`
+	Icons       []Icon       `json:"icons,omitempty"`
 }
 
+// ResourceContent holds the contents of a resource as either Text or,
+// for binary data, base64-encoded Blob.
 type ResourceContent struct {
-	URI  string `json:"uri"`
-	Text string `json:"text"`
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// MarshalJSON always includes text for text contents, even when it is empty.
+func (c ResourceContent) MarshalJSON() ([]byte, error) {
+	type content ResourceContent
+	if c.Blob != "" {
+		return json.Marshal(content(c))
+	}
+	return json.Marshal(struct {
+		content
+		Text string `json:"text"`
+	}{content(c), c.Text})
 }
 
 type ResourceResponse struct {
@@ -18,3 +43,19 @@ type ResourceResponse struct {
 type ResourceParams struct {
 	URI string `json:"uri"`
 }
+
+// ResourceTemplate describes a family of resources addressed by a URI template.
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Icons       []Icon `json:"icons,omitempty"`
+}
+
+// ListResourceTemplatesResult is the result of resources/templates/list.
+type ListResourceTemplatesResult struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+	NextCursor        string             `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrSamplingUnsupported is returned when the client did not declare the sampling capability.
+var ErrSamplingUnsupported = errors.New("client does not support sampling")
+
+// SamplingMessage is one message in a sampling/createMessage conversation.
+type SamplingMessage struct {
+	Role    string      `json:"role"`
+	Content ContentItem `json:"content"`
+}
+
+// ModelHint suggests a model name to the client.
+type ModelHint struct {
+	Name string `json:"name,omitempty"`
+}
+
+// ModelPreferences expresses the server's priorities for model selection.
+type ModelPreferences struct {
+	Hints                []ModelHint `json:"hints,omitempty"`
+	CostPriority         *float64    `json:"costPriority,omitempty"`
+	SpeedPriority        *float64    `json:"speedPriority,omitempty"`
+	IntelligencePriority *float64    `json:"intelligencePriority,omitempty"`
+}
+
+// CreateMessageParams are the params of a sampling/createMessage request.
+type CreateMessageParams struct {
+	Messages         []SamplingMessage `json:"messages"`
+	ModelPreferences *ModelPreferences `json:"modelPreferences,omitempty"`
+	SystemPrompt     string            `json:"systemPrompt,omitempty"`
+	MaxTokens        int               `json:"maxTokens"`
+	Temperature      *float64          `json:"temperature,omitempty"`
+	StopSequences    []string          `json:"stopSequences,omitempty"`
+}
+
+// CreateMessageResult is the client's reply to sampling/createMessage.
+type CreateMessageResult struct {
+	Role       string      `json:"role"`
+	Content    ContentItem `json:"content"`
+	Model      string      `json:"model"`
+	StopReason string      `json:"stopReason,omitempty"`
+}
+
+// CreateMessage asks the client's LLM to generate a message.
+func CreateMessage(ctx context.Context, session *Session, params CreateMessageParams) (*CreateMessageResult, error) {
+	if session == nil {
+		return nil, errors.New("no session in context")
+	}
+	if !session.HasClientCapability("sampling") {
+		return nil, ErrSamplingUnsupported
+	}
+
+	raw, err := session.Request(ctx, "sampling/createMessage", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result CreateMessageResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("decode sampling result: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,276 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// SessionState describes where a session is in the MCP lifecycle.
+type SessionState int
+
+const (
+	// SessionUninitialized is the state before an initialize request arrives.
+	SessionUninitialized SessionState = iota
+	// SessionInitializing is the state after initialize was answered but
+	// before the client sent notifications/initialized.
+	SessionInitializing
+	// SessionReady is the state once the client confirmed initialization.
+	SessionReady
+	// SessionClosed is the terminal state after the connection or session ends.
+	SessionClosed
+)
+
+func (s SessionState) String() string {
+	switch s {
+	case SessionUninitialized:
+		return "uninitialized"
+	case SessionInitializing:
+		return "initializing"
+	case SessionReady:
+		return "ready"
+	case SessionClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// Lifecycle errors returned by Session.
+var (
+	ErrSessionNotInitialized     = errors.New("session not initialized")
+	ErrSessionAlreadyInitialized = errors.New("session already initialized")
+	ErrSessionClosed             = errors.New("session closed")
+	ErrNoMessageWriter           = errors.New("session has no outbound message stream")
+)
+
+// Session tracks the per-connection MCP state shared by transports and the server.
+// Stdio creates one session per connection; HTTP creates one per MCP-Session-Id.
+type Session struct {
+	id    string
+	mu    sync.RWMutex
+	state SessionState
+
+	protocolVersion    string
+	clientInfo         Implementation
+	clientCapabilities map[string]any
+
+	writer MessageWriter
+
+	nextRequestID uint64
+	pending       map[string]chan ClientResponse
+
+	inflight map[string]context.CancelCauseFunc
+
+	logLevel LoggingLevel
+
+	subscriptions map[string]struct{}
+}
+
+// NewSession creates an uninitialized session with the given ID.
+func NewSession(id string) *Session {
+	return &Session{id: id}
+}
+
+// ID returns the session identifier.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// State returns the current lifecycle state.
+func (s *Session) State() SessionState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// Initialized reports whether the client sent notifications/initialized.
+func (s *Session) Initialized() bool {
+	return s.State() == SessionReady
+}
+
+// SetClient records the negotiated protocol version and the client's
+// self-description from the initialize request.
+func (s *Session) SetClient(protocolVersion string, info Implementation, capabilities map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocolVersion = protocolVersion
+	s.clientInfo = info
+	s.clientCapabilities = capabilities
+}
+
+// ProtocolVersion returns the negotiated protocol version, or "" before initialize.
+func (s *Session) ProtocolVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.protocolVersion
+}
+
+// ClientInfo returns the client implementation sent with initialize.
+func (s *Session) ClientInfo() Implementation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clientInfo
+}
+
+// HasClientCapability reports whether the client declared the named capability.
+func (s *Session) HasClientCapability(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.clientCapabilities[name]
+	return ok
+}
+
+// SupportsProtocol reports whether the negotiated version is at least minimum.
+// Sessions that have not negotiated a version are assumed to speak the newest one.
+func (s *Session) SupportsProtocol(minimum string) bool {
+	version := s.ProtocolVersion()
+	if version == "" {
+		return true
+	}
+	return ProtocolVersionAtLeast(version, minimum)
+}
+
+// SessionSnapshot is the negotiated part of a session's state, which transports
+// can persist to share a session between server replicas. Log levels,
+// subscriptions and in-flight requests stay with the process handling them.
+type SessionSnapshot struct {
+	State              SessionState   `json:"state"`
+	ProtocolVersion    string         `json:"protocolVersion,omitempty"`
+	ClientInfo         Implementation `json:"clientInfo"`
+	ClientCapabilities map[string]any `json:"clientCapabilities,omitempty"`
+}
+
+// Snapshot returns the session's lifecycle state and negotiated client details.
+func (s *Session) Snapshot() SessionSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return SessionSnapshot{
+		State:              s.state,
+		ProtocolVersion:    s.protocolVersion,
+		ClientInfo:         s.clientInfo,
+		ClientCapabilities: s.clientCapabilities,
+	}
+}
+
+// RestoreSession recreates a session from a snapshot taken by another process.
+func RestoreSession(id string, snapshot SessionSnapshot) *Session {
+	session := NewSession(id)
+	session.Restore(snapshot)
+	return session
+}
+
+// Restore replaces the session's lifecycle state and negotiated details with
+// those of snapshot, for example after another process advanced the session.
+func (s *Session) Restore(snapshot SessionSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = snapshot.State
+	s.protocolVersion = snapshot.ProtocolVersion
+	s.clientInfo = snapshot.ClientInfo
+	s.clientCapabilities = snapshot.ClientCapabilities
+}
+
+// SetWriter sets where server-initiated messages for this session are sent.
+func (s *Session) SetWriter(writer MessageWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writer = writer
+}
+
+// Notify sends a server-initiated notification to the client.
+func (s *Session) Notify(method string, params any) error {
+	s.mu.RLock()
+	writer, state := s.writer, s.state
+	s.mu.RUnlock()
+
+	if state == SessionClosed {
+		return ErrSessionClosed
+	}
+	if writer == nil {
+		return ErrNoMessageWriter
+	}
+	return writer.WriteMessage(Notification{JSONRPC: JSONRPCVersion, Method: method, Params: params})
+}
+
+// CheckRequest reports whether a request for method may be processed in the
+// current state. Only initialize and ping are accepted before initialization.
+func (s *Session) CheckRequest(method string) error {
+	state := s.State()
+	switch state {
+	case SessionClosed:
+		return ErrSessionClosed
+	case SessionUninitialized:
+		if method == "initialize" || method == "ping" {
+			return nil
+		}
+		return ErrSessionNotInitialized
+	default:
+		if method == "initialize" {
+			return ErrSessionAlreadyInitialized
+		}
+		return nil
+	}
+}
+
+// BeginInitialize moves the session from uninitialized to initializing.
+func (s *Session) BeginInitialize() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.state {
+	case SessionUninitialized:
+		s.state = SessionInitializing
+		return nil
+	case SessionClosed:
+		return ErrSessionClosed
+	default:
+		return ErrSessionAlreadyInitialized
+	}
+}
+
+// MarkInitialized records notifications/initialized and moves the session to ready.
+// Repeated notifications are ignored.
+func (s *Session) MarkInitialized() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.state {
+	case SessionInitializing:
+		s.state = SessionReady
+		return nil
+	case SessionReady:
+		return nil
+	case SessionClosed:
+		return ErrSessionClosed
+	default:
+		return ErrSessionNotInitialized
+	}
+}
+
+// Close moves the session to its terminal state and fails outstanding
+// server-to-client requests.
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = SessionClosed
+	for id, ch := range s.pending {
+		close(ch)
+		delete(s.pending, id)
+	}
+	for id, cancel := range s.inflight {
+		cancel(ErrSessionClosed)
+		delete(s.inflight, id)
+	}
+}
+
+// WithSession returns a copy of ctx carrying the session.
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, SessionKey, session)
+}
+
+// SessionFromContext returns the session carried by ctx, or nil.
+func SessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(SessionKey).(*Session)
+	return session
+}
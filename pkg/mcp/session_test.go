@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSessionLifecycle(t *testing.T) {
+	session := NewSession("session-1")
+	if session.ID() != "session-1" {
+		t.Fatalf("expected session id, got %q", session.ID())
+	}
+	if session.State() != SessionUninitialized {
+		t.Fatalf("expected uninitialized state, got %s", session.State())
+	}
+
+	if err := session.CheckRequest("tools/list"); !errors.Is(err, ErrSessionNotInitialized) {
+		t.Fatalf("expected not initialized error, got %v", err)
+	}
+	if err := session.CheckRequest("ping"); err != nil {
+		t.Fatalf("expected ping to be allowed before initialize, got %v", err)
+	}
+	if err := session.MarkInitialized(); !errors.Is(err, ErrSessionNotInitialized) {
+		t.Fatalf("expected initialized notification before initialize to fail, got %v", err)
+	}
+
+	if err := session.BeginInitialize(); err != nil {
+		t.Fatalf("BeginInitialize failed: %v", err)
+	}
+	if err := session.BeginInitialize(); !errors.Is(err, ErrSessionAlreadyInitialized) {
+		t.Fatalf("expected duplicate initialize to fail, got %v", err)
+	}
+	if err := session.CheckRequest("tools/list"); err != nil {
+		t.Fatalf("expected requests after initialize response, got %v", err)
+	}
+	if session.Initialized() {
+		t.Fatal("expected initialized notification to be pending")
+	}
+
+	if err := session.MarkInitialized(); err != nil {
+		t.Fatalf("MarkInitialized failed: %v", err)
+	}
+	if !session.Initialized() || session.State() != SessionReady {
+		t.Fatalf("expected ready state, got %s", session.State())
+	}
+	if err := session.MarkInitialized(); err != nil {
+		t.Fatalf("expected repeated initialized notification to be ignored, got %v", err)
+	}
+	if err := session.CheckRequest("initialize"); !errors.Is(err, ErrSessionAlreadyInitialized) {
+		t.Fatalf("expected initialize on ready session to fail, got %v", err)
+	}
+
+	session.Close()
+	if err := session.CheckRequest("ping"); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("expected closed session error, got %v", err)
+	}
+	if session.State().String() != "closed" {
+		t.Fatalf("expected closed state string, got %s", session.State())
+	}
+}
+
+func TestSessionContext(t *testing.T) {
+	if SessionFromContext(context.Background()) != nil {
+		t.Fatal("expected no session in empty context")
+	}
+
+	session := NewSession("session-1")
+	ctx := WithSession(context.Background(), session)
+	if SessionFromContext(ctx) != session {
+		t.Fatal("expected session from context")
+	}
+}
+
+func TestSessionSnapshotRestore(t *testing.T) {
+	session := NewSession("session-1")
+	if err := session.BeginInitialize(); err != nil {
+		t.Fatalf("BeginInitialize failed: %v", err)
+	}
+	session.SetClient(StructuredProtocolVersion, Implementation{Name: "client", Version: "1.0.0"}, map[string]any{"sampling": map[string]any{}})
+	if err := session.MarkInitialized(); err != nil {
+		t.Fatalf("MarkInitialized failed: %v", err)
+	}
+
+	restored := RestoreSession("session-1", session.Snapshot())
+	if restored.ID() != "session-1" || restored.State() != SessionReady {
+		t.Fatalf("expected ready session-1, got %s in state %s", restored.ID(), restored.State())
+	}
+	if restored.ProtocolVersion() != StructuredProtocolVersion || restored.ClientInfo().Name != "client" {
+		t.Fatalf("expected negotiated details to be restored, got %s %+v", restored.ProtocolVersion(), restored.ClientInfo())
+	}
+	if !restored.HasClientCapability("sampling") {
+		t.Fatal("expected client capabilities to be restored")
+	}
+	if err := restored.CheckRequest("tools/list"); err != nil {
+		t.Fatalf("expected restored session to accept requests, got %v", err)
+	}
+}
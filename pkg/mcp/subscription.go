@@ -0,0 +1,45 @@
+package mcp
+
+import "context"
+
+// NotificationResourcesUpdated tells subscribed clients that a resource's contents changed.
+const NotificationResourcesUpdated = "notifications/resources/updated"
+
+// ResourceUpdatedParams identifies the resource in notifications/resources/updated.
+type ResourceUpdatedParams struct {
+	URI string `json:"uri"`
+}
+
+// ResourceSubscriber is implemented by resource handlers that publish
+// notifications/resources/updated to their bound notifier. The server tracks
+// subscriptions per session and only advertises resources.subscribe for such handlers.
+type ResourceSubscriber interface {
+	NotifierBinder
+	// CheckSubscription reports whether uri names a resource that can be subscribed to.
+	CheckSubscription(ctx context.Context, uri string) error
+}
+
+// Subscribe records the client's interest in updates to uri.
+func (s *Session) Subscribe(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscriptions == nil {
+		s.subscriptions = make(map[string]struct{})
+	}
+	s.subscriptions[uri] = struct{}{}
+}
+
+// Unsubscribe removes a subscription; unknown URIs are ignored.
+func (s *Session) Unsubscribe(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscriptions, uri)
+}
+
+// Subscribed reports whether the client subscribed to uri.
+func (s *Session) Subscribed(uri string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.subscriptions[uri]
+	return ok
+}
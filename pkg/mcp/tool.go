@@ -3,26 +3,73 @@ package mcp
 // Tool-related types
 type Tool struct {
 	Name        string      `json:"name"`
+	Title       string      `json:"title,omitempty"`
 	Description string      `json:"description"`
 	InputSchema InputSchema `json:"inputSchema"`
+	// OutputSchema, when set, describes the StructuredContent of every result.
+	OutputSchema *InputSchema     `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations `json:"annotations,omitempty"`
+	Icons        []Icon           `json:"icons,omitempty"`
+}
+
+// ToolAnnotations are hints about a tool's behavior that clients may use to
+// decide, for example, whether a call needs user approval. They are not
+// guarantees; nil hints take the protocol defaults.
+type ToolAnnotations struct {
+	// Title is a display name for older clients that do not read Tool.Title.
+	Title string `json:"title,omitempty"`
+	// ReadOnlyHint means the tool does not modify its environment (default false).
+	ReadOnlyHint *bool `json:"readOnlyHint,omitempty"`
+	// DestructiveHint means a non read-only tool may destroy data rather than only add to it (default true).
+	DestructiveHint *bool `json:"destructiveHint,omitempty"`
+	// IdempotentHint means repeated calls with the same arguments have no additional effect (default false).
+	IdempotentHint *bool `json:"idempotentHint,omitempty"`
+	// OpenWorldHint means the tool interacts with external entities (default true).
+	OpenWorldHint *bool `json:"openWorldHint,omitempty"`
+}
+
+// Merge returns a copy of a with every hint set in override applied on top.
+func (a ToolAnnotations) Merge(override *ToolAnnotations) *ToolAnnotations {
+	if override != nil {
+		if override.Title != "" {
+			a.Title = override.Title
+		}
+		if override.ReadOnlyHint != nil {
+			a.ReadOnlyHint = override.ReadOnlyHint
+		}
+		if override.DestructiveHint != nil {
+			a.DestructiveHint = override.DestructiveHint
+		}
+		if override.IdempotentHint != nil {
+			a.IdempotentHint = override.IdempotentHint
+		}
+		if override.OpenWorldHint != nil {
+			a.OpenWorldHint = override.OpenWorldHint
+		}
+	}
+	return &a
 }
 
 type InputSchema struct {
 	Type       string         `json:"type"`
 	Properties map[string]any `json:"properties,omitempty"`
 	Required   []string       `json:"required,omitempty"`
+	// AdditionalProperties is false to reject unlisted properties, or a
+	// schema they must match. Nil allows any additional property.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
 }
 
 type ToolCallParams struct {
 	Name      string         `json:"name"`
 	Arguments map[string]any `json:"arguments"`
+	Meta      *RequestMeta   `json:"_meta,omitempty"`
 }
 
 type ToolResponse struct {
 	Content []ContentItem `json:"content"`
-}
-
-type ContentItem struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	// StructuredContent is the result as a JSON object. Handlers should also
+	// return it serialized in a text ContentItem for clients that predate it.
+	StructuredContent any `json:"structuredContent,omitempty"`
+	// IsError marks a tool execution failure whose Content explains it to the model.
+	IsError bool `json:"isError,omitempty"`
 }
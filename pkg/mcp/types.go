@@ -15,8 +15,40 @@ const (
 	SessionIDHeader       = "MCP-Session-Id"
 	ProtocolVersionHeader = "MCP-Protocol-Version"
 	LegacyProtocolVersion = "2025-03-26"
+	// StructuredProtocolVersion is the revision that made the MCP-Protocol-Version
+	// header mandatory and introduced structured tool output and elicitation.
+	StructuredProtocolVersion = "2025-06-18"
 )
 
+// SupportedProtocolVersions lists every protocol revision the server speaks, newest first.
+var SupportedProtocolVersions = []string{ProtocolVersion, StructuredProtocolVersion, LegacyProtocolVersion}
+
+// IsSupportedProtocolVersion reports whether version appears in SupportedProtocolVersions.
+func IsSupportedProtocolVersion(version string) bool {
+	for _, supported := range SupportedProtocolVersions {
+		if supported == version {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateProtocolVersion picks the protocol version for a client that requested
+// the given version. A supported request is honored; otherwise the newest
+// supported version is offered and the client decides whether to continue.
+func NegotiateProtocolVersion(requested string) string {
+	if IsSupportedProtocolVersion(requested) {
+		return requested
+	}
+	return SupportedProtocolVersions[0]
+}
+
+// ProtocolVersionAtLeast reports whether version is the same as or newer than minimum.
+// Protocol versions are ISO dates, so lexical order matches release order.
+func ProtocolVersionAtLeast(version, minimum string) bool {
+	return version >= minimum
+}
+
 // JSON-RPC 2.0 error codes
 const (
 	ErrorCodeParseError     = -32700
@@ -32,6 +64,18 @@ type ServerInfo struct {
 	Version string `json:"version"`
 }
 
+// Implementation describes an MCP client or server implementation.
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type InitializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
 type InitializeResponse struct {
 	ProtocolVersion string         `json:"protocolVersion"`
 	Capabilities    map[string]any `json:"capabilities"`
@@ -64,11 +108,18 @@ type ErrorResponse struct {
 // Server defines the core MCP server interface.
 type Server interface {
 	// Initialize handles the MCP initialization handshake.
-	Initialize(ctx context.Context) (*InitializeResponse, error)
+	Initialize(ctx context.Context, params InitializeParams) (*InitializeResponse, error)
 	// HandleRequest processes a JSON-RPC request.
 	HandleRequest(ctx context.Context, req Request) error
 }
 
+// NotificationHandler is implemented by servers that process client notifications.
+// Transports deliver notifications only when the server implements it.
+type NotificationHandler interface {
+	// HandleNotification processes a JSON-RPC notification. No response is sent.
+	HandleNotification(ctx context.Context, req Request) error
+}
+
 // ToolHandler defines the interface for handling MCP tool operations.
 type ToolHandler interface {
 	// ListTools returns all available tools.
@@ -85,6 +136,13 @@ type ResourceHandler interface {
 	ReadResource(ctx context.Context, params ResourceParams) (ResourceResponse, error)
 }
 
+// ResourceTemplateHandler is implemented by resource handlers that serve
+// parameterized resources. Without it resources/templates/list is empty.
+type ResourceTemplateHandler interface {
+	// ListResourceTemplates returns all available resource templates.
+	ListResourceTemplates(ctx context.Context) ([]ResourceTemplate, error)
+}
+
 // PromptHandler defines the interface for handling MCP prompt operations.
 type PromptHandler interface {
 	// ListPrompts returns all available prompts.
@@ -93,6 +151,13 @@ type PromptHandler interface {
 	GetPrompt(ctx context.Context, params PromptParams) (PromptResponse, error)
 }
 
+// CompletionHandler is implemented by handlers that suggest argument values.
+// The server advertises the completions capability only when one is registered.
+type CompletionHandler interface {
+	// Complete returns suggested values for a prompt argument or resource template variable.
+	Complete(ctx context.Context, params CompleteParams) (CompletionResult, error)
+}
+
 // ResponseSender defines the interface for sending responses back to clients.
 type ResponseSender interface {
 	// SendResponse sends a successful response.
@@ -106,3 +171,7 @@ type contextKey string
 
 const ResponseSenderKey contextKey = "responseSender"
 const SessionIDKey contextKey = "sessionID"
+const SessionKey contextKey = "session"
+const MessageWriterKey contextKey = "messageWriter"
+const ProgressReporterKey contextKey = "progressReporter"
+const ClientLogKey contextKey = "clientLog"
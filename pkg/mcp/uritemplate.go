@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var templateVariablePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// URITemplate is an RFC 6570 level 1 URI template such as catalog://items/{name}.
+// Only simple string expansion is supported: each {var} stands for one
+// percent-encoded value that cannot span a '/', '?' or '#'.
+type URITemplate struct {
+	raw       string
+	literals  []string
+	variables []string
+	pattern   *regexp.Regexp
+}
+
+// ParseURITemplate parses a level 1 URI template.
+func ParseURITemplate(template string) (*URITemplate, error) {
+	t := &URITemplate{raw: template}
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	rest := template
+	for {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			t.literals = append(t.literals, rest)
+			expr.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		if rest[start] == '}' {
+			return nil, fmt.Errorf("invalid uri template %q: unmatched '}'", template)
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid uri template %q: unterminated expression", template)
+		}
+		name := rest[start+1 : start+end]
+		if !templateVariablePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid uri template %q: unsupported expression {%s}", template, name)
+		}
+		// Adjacent variables cannot be split apart when matching.
+		if start == 0 && len(t.variables) > 0 {
+			return nil, fmt.Errorf("invalid uri template %q: adjacent expressions", template)
+		}
+
+		t.literals = append(t.literals, rest[:start])
+		t.variables = append(t.variables, name)
+		expr.WriteString(regexp.QuoteMeta(rest[:start]))
+		expr.WriteString(`([^/?#]+)`)
+		rest = rest[start+end+1:]
+	}
+
+	expr.WriteString("$")
+	t.pattern = regexp.MustCompile(expr.String())
+	return t, nil
+}
+
+// String returns the template as written.
+func (t *URITemplate) String() string {
+	return t.raw
+}
+
+// Variables returns the template's variable names in order.
+func (t *URITemplate) Variables() []string {
+	return append([]string(nil), t.variables...)
+}
+
+// Expand substitutes vars into the template, percent-encoding every character
+// outside the unreserved set. Missing variables expand to "".
+func (t *URITemplate) Expand(vars map[string]string) string {
+	var b strings.Builder
+	for i, literal := range t.literals {
+		b.WriteString(literal)
+		if i < len(t.variables) {
+			b.WriteString(escapeTemplateValue(vars[t.variables[i]]))
+		}
+	}
+	return b.String()
+}
+
+// Match reports whether uri is an expansion of the template and returns the
+// decoded variable values.
+func (t *URITemplate) Match(uri string) (map[string]string, bool) {
+	groups := t.pattern.FindStringSubmatch(uri)
+	if groups == nil {
+		return nil, false
+	}
+
+	vars := make(map[string]string, len(t.variables))
+	for i, name := range t.variables {
+		value, err := url.PathUnescape(groups[i+1])
+		if err != nil {
+			return nil, false
+		}
+		vars[name] = value
+	}
+	return vars, true
+}
+
+// escapeTemplateValue percent-encodes everything except RFC 3986 unreserved characters
+func escapeTemplateValue(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if isUnreserved(c) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
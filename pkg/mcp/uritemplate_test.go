@@ -0,0 +1,42 @@
+package mcp
+
+import "testing"
+
+func TestURITemplate(t *testing.T) {
+	template, err := ParseURITemplate("catalog://items/{name}")
+	if err != nil {
+		t.Fatalf("ParseURITemplate failed: %v", err)
+	}
+	if vars := template.Variables(); len(vars) != 1 || vars[0] != "name" {
+		t.Fatalf("unexpected variables: %v", vars)
+	}
+
+	uri := template.Expand(map[string]string{"name": "Incident Triage/Guide"})
+	if uri != "catalog://items/Incident%20Triage%2FGuide" {
+		t.Fatalf("unexpected expansion: %s", uri)
+	}
+	vars, ok := template.Match(uri)
+	if !ok || vars["name"] != "Incident Triage/Guide" {
+		t.Fatalf("expected expansion to round trip, got %v %v", vars, ok)
+	}
+
+	for _, uri := range []string{"catalog://items", "catalog://items/", "catalog://items/a/b", "other://items/a", "catalog://items/%zz"} {
+		if _, ok := template.Match(uri); ok {
+			t.Fatalf("expected %q not to match", uri)
+		}
+	}
+
+	multi, err := ParseURITemplate("repo://{owner}/{repo}/readme")
+	if err != nil {
+		t.Fatalf("ParseURITemplate failed: %v", err)
+	}
+	if vars, ok := multi.Match("repo://acme/widgets/readme"); !ok || vars["owner"] != "acme" || vars["repo"] != "widgets" {
+		t.Fatalf("unexpected multi-variable match: %v %v", vars, ok)
+	}
+
+	for _, invalid := range []string{"a/{name", "a/name}", "a/{}", "a/{+name}", "a/{x}{y}"} {
+		if _, err := ParseURITemplate(invalid); err == nil {
+			t.Fatalf("expected %q to be rejected", invalid)
+		}
+	}
+}
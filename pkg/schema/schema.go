@@ -0,0 +1,398 @@
+// Package schema validates JSON values against the JSON Schema subset used by MCP tool definitions.
+//
+// The supported draft 2020-12 keywords are type, enum, properties, required,
+// additionalProperties, items, pattern, minLength, maxLength, minimum,
+// maximum, exclusiveMinimum, exclusiveMaximum, minItems and maxItems. Other
+// keywords are ignored. Patterns use Go regexp (RE2) syntax and, as in JSON
+// Schema, match anywhere in the string unless anchored.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var typeNames = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// Schema is a checked schema with its patterns compiled, ready to validate
+// any number of values.
+type Schema struct {
+	root     map[string]any
+	patterns map[string]*regexp.Regexp
+}
+
+// ValidationError reports where and why a value does not conform to a schema.
+type ValidationError struct {
+	// Path locates the offending value, such as $.tags[1].
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+func invalidf(path string, format string, args ...any) error {
+	return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// Compile checks schema and prepares it for validation. schema may be any
+// value that encodes to JSON, such as mcp.InputSchema or a decoded map.
+func Compile(schema any) (*Schema, error) {
+	normalized, err := normalize(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	schemaMap, ok := normalized.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid schema: expected an object")
+	}
+	compiled := &Schema{root: schemaMap, patterns: make(map[string]*regexp.Regexp)}
+	if err := compiled.check(schemaMap, "$"); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// Validate reports whether value, which may be any value that encodes to
+// JSON, conforms to the schema. A nonconforming value yields a
+// *ValidationError naming the offending location as a path such as $.tags[1].
+func (s *Schema) Validate(value any) error {
+	if !isJSONValue(value) {
+		normalized, err := normalize(value)
+		if err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+		value = normalized
+	}
+	return s.validate(s.root, value, "$")
+}
+
+// Validate compiles schema and validates value against it. Use Compile to
+// validate several values against the same schema.
+func Validate(schema any, value any) error {
+	compiled, err := Compile(schema)
+	if err != nil {
+		return err
+	}
+	return compiled.Validate(value)
+}
+
+// Check reports whether schema is well formed: every supported keyword must
+// have the right kind of value and every pattern must compile.
+func Check(schema any) error {
+	_, err := Compile(schema)
+	return err
+}
+
+// normalize round-trips v through JSON so Go values compare like decoded JSON
+func normalize(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// isJSONValue reports whether v only holds the types encoding/json decodes
+// into, so it needs no normalizing
+func isJSONValue(v any) bool {
+	switch typed := v.(type) {
+	case nil, string, float64, bool:
+		return true
+	case map[string]any:
+		for _, item := range typed {
+			if !isJSONValue(item) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		for _, item := range typed {
+			if !isJSONValue(item) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// check walks a normalized schema, checks its keywords and compiles its patterns
+func (s *Schema) check(schema map[string]any, path string) error {
+	switch typed := schema["type"].(type) {
+	case nil:
+	case string:
+		if !slices.Contains(typeNames, typed) {
+			return fmt.Errorf("%s.type: unknown type %q", path, typed)
+		}
+	case []any:
+		for _, t := range typed {
+			if name, ok := t.(string); !ok || !slices.Contains(typeNames, name) {
+				return fmt.Errorf("%s.type: unknown type %v", path, t)
+			}
+		}
+	default:
+		return fmt.Errorf("%s.type: expected string or array", path)
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		if _, ok := enum.([]any); !ok {
+			return fmt.Errorf("%s.enum: expected array", path)
+		}
+	}
+	if required, ok := schema["required"]; ok && required != nil {
+		names, ok := required.([]any)
+		if !ok {
+			return fmt.Errorf("%s.required: expected array", path)
+		}
+		for _, name := range names {
+			if _, ok := name.(string); !ok {
+				return fmt.Errorf("%s.required: expected property names, got %v", path, name)
+			}
+		}
+	}
+	if properties, ok := schema["properties"]; ok && properties != nil {
+		propertyMap, ok := properties.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s.properties: expected object", path)
+		}
+		for key, property := range propertyMap {
+			if err := s.checkSubschema(property, path+".properties."+key); err != nil {
+				return err
+			}
+		}
+	}
+	if additional, ok := schema["additionalProperties"]; ok {
+		if _, isBool := additional.(bool); !isBool {
+			if err := s.checkSubschema(additional, path+".additionalProperties"); err != nil {
+				return err
+			}
+		}
+	}
+	if items, ok := schema["items"]; ok {
+		if err := s.checkSubschema(items, path+".items"); err != nil {
+			return err
+		}
+	}
+	if pattern, ok := schema["pattern"]; ok {
+		source, ok := pattern.(string)
+		if !ok {
+			return fmt.Errorf("%s.pattern: expected string", path)
+		}
+		re, err := regexp.Compile(source)
+		if err != nil {
+			return fmt.Errorf("%s.pattern: %w", path, err)
+		}
+		s.patterns[source] = re
+	}
+	for _, keyword := range []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum"} {
+		if bound, ok := schema[keyword]; ok {
+			if _, ok := bound.(float64); !ok {
+				return fmt.Errorf("%s.%s: expected number", path, keyword)
+			}
+		}
+	}
+	for _, keyword := range []string{"minLength", "maxLength", "minItems", "maxItems"} {
+		if bound, ok := schema[keyword]; ok {
+			if count, ok := bound.(float64); !ok || count < 0 || count != math.Trunc(count) {
+				return fmt.Errorf("%s.%s: expected non-negative integer", path, keyword)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) checkSubschema(schema any, path string) error {
+	schemaMap, ok := schema.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s: expected schema object", path)
+	}
+	return s.check(schemaMap, path)
+}
+
+func (s *Schema) validate(schema map[string]any, value any, path string) error {
+	if err := validateType(schema["type"], value, path); err != nil {
+		return err
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		if !slices.ContainsFunc(enum, func(allowed any) bool { return reflect.DeepEqual(allowed, value) }) {
+			return invalidf(path, "value %v is not one of %v", value, enum)
+		}
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				key, _ := name.(string)
+				if _, ok := typed[key]; !ok {
+					return invalidf(path, "missing required property %q", key)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		// Sorted keys report the same error first on every run.
+		for _, key := range slices.Sorted(maps.Keys(typed)) {
+			propertyPath := path + "." + key
+			if propertySchema, ok := properties[key].(map[string]any); ok {
+				if err := s.validate(propertySchema, typed[key], propertyPath); err != nil {
+					return err
+				}
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return invalidf(path, "additional property %q is not allowed", key)
+				}
+			case map[string]any:
+				if err := s.validate(additional, typed[key], propertyPath); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if err := validateCount(schema, "minItems", "maxItems", len(typed), "items", path); err != nil {
+			return err
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range typed {
+				if err := s.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		if err := validateCount(schema, "minLength", "maxLength", utf8.RuneCountInString(typed), "characters", path); err != nil {
+			return err
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if !s.patterns[pattern].MatchString(typed) {
+				return invalidf(path, "value %q does not match pattern %q", typed, pattern)
+			}
+		}
+	case float64:
+		if err := validateRange(schema, typed, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateCount checks a length against a pair of minimum and maximum count keywords
+func validateCount(schema map[string]any, minKeyword string, maxKeyword string, count int, unit string, path string) error {
+	if limit, ok := schema[minKeyword].(float64); ok && float64(count) < limit {
+		return invalidf(path, "expected at least %v %s, got %d", limit, unit, count)
+	}
+	if limit, ok := schema[maxKeyword].(float64); ok && float64(count) > limit {
+		return invalidf(path, "expected at most %v %s, got %d", limit, unit, count)
+	}
+	return nil
+}
+
+// validateRange checks a number against the minimum and maximum keywords
+func validateRange(schema map[string]any, number float64, path string) error {
+	if limit, ok := schema["minimum"].(float64); ok && number < limit {
+		return invalidf(path, "value %v is less than minimum %v", number, limit)
+	}
+	if limit, ok := schema["exclusiveMinimum"].(float64); ok && number <= limit {
+		return invalidf(path, "value %v must be greater than %v", number, limit)
+	}
+	if limit, ok := schema["maximum"].(float64); ok && number > limit {
+		return invalidf(path, "value %v is greater than maximum %v", number, limit)
+	}
+	if limit, ok := schema["exclusiveMaximum"].(float64); ok && number >= limit {
+		return invalidf(path, "value %v must be less than %v", number, limit)
+	}
+	return nil
+}
+
+// validateType checks value against a type keyword, which may be a single type or a list
+func validateType(typeKeyword any, value any, path string) error {
+	var types []string
+	switch typed := typeKeyword.(type) {
+	case nil:
+		return nil
+	case string:
+		types = []string{typed}
+	case []any:
+		for _, t := range typed {
+			if name, ok := t.(string); ok {
+				types = append(types, name)
+			}
+		}
+	default:
+		return invalidf(path, "invalid type keyword %v", typeKeyword)
+	}
+
+	for _, name := range types {
+		if hasType(name, value) {
+			return nil
+		}
+	}
+	return invalidf(path, "expected %s, got %s", strings.Join(types, " or "), typeName(value))
+}
+
+func hasType(name string, value any) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return false
+	}
+}
+
+func typeName(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if typed == math.Trunc(typed) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
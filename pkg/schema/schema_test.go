@@ -0,0 +1,165 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	itemSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string"},
+			"cost":  map[string]any{"type": "integer"},
+			"tier":  map[string]any{"type": "string", "enum": []any{"starter", "pro"}},
+			"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"owner": map[string]any{"type": []any{"string", "null"}},
+		},
+		"required": []any{"name"},
+	}
+
+	tests := []struct {
+		name    string
+		value   any
+		wantErr string
+	}{
+		{name: "valid", value: map[string]any{"name": "A", "cost": 5, "tier": "pro", "tags": []string{"x"}, "owner": nil}},
+		{name: "extra properties allowed", value: map[string]any{"name": "A", "other": true}},
+		{name: "not an object", value: []string{"A"}, wantErr: "$: expected object, got array"},
+		{name: "missing required", value: map[string]any{"cost": 5}, wantErr: `$: missing required property "name"`},
+		{name: "wrong property type", value: map[string]any{"name": 5}, wantErr: "$.name: expected string, got integer"},
+		{name: "integer rejects fractions", value: map[string]any{"name": "A", "cost": 1.5}, wantErr: "$.cost: expected integer, got number"},
+		{name: "enum", value: map[string]any{"name": "A", "tier": "enterprise"}, wantErr: "$.tier: value enterprise is not one of"},
+		{name: "array items", value: map[string]any{"name": "A", "tags": []any{"x", 1}}, wantErr: "$.tags[1]: expected string"},
+		{name: "type union", value: map[string]any{"name": "A", "owner": 1}, wantErr: "$.owner: expected string or null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(itemSchema, tt.value)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsNonObjectSchema(t *testing.T) {
+	if err := Validate("string", "value"); err == nil {
+		t.Fatal("expected non-object schema to be rejected")
+	}
+}
+
+func TestValidateKeywords(t *testing.T) {
+	argsSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query":  map[string]any{"type": "string", "minLength": 2, "maxLength": 5, "pattern": "^[a-z]+$"},
+			"limit":  map[string]any{"type": "integer", "minimum": 1, "maximum": 10},
+			"ratio":  map[string]any{"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+			"labels": map[string]any{"type": "array", "minItems": 1, "maxItems": 2},
+		},
+		"additionalProperties": false,
+	}
+	taggedSchema := map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}}
+
+	tests := []struct {
+		name    string
+		schema  any
+		value   any
+		wantErr string
+	}{
+		{name: "valid", schema: argsSchema, value: map[string]any{"query": "abc", "limit": 10, "ratio": 0.5, "labels": []any{"x"}}},
+		{name: "too short", schema: argsSchema, value: map[string]any{"query": "a"}, wantErr: "$.query: expected at least 2 characters, got 1"},
+		{name: "too long counts runes", schema: argsSchema, value: map[string]any{"query": "ééééééé"}, wantErr: "$.query: expected at most 5 characters, got 7"},
+		{name: "pattern", schema: argsSchema, value: map[string]any{"query": "ab1"}, wantErr: `$.query: value "ab1" does not match pattern "^[a-z]+$"`},
+		{name: "minimum", schema: argsSchema, value: map[string]any{"limit": 0}, wantErr: "$.limit: value 0 is less than minimum 1"},
+		{name: "maximum", schema: argsSchema, value: map[string]any{"limit": 11}, wantErr: "$.limit: value 11 is greater than maximum 10"},
+		{name: "exclusive minimum", schema: argsSchema, value: map[string]any{"ratio": 0}, wantErr: "$.ratio: value 0 must be greater than 0"},
+		{name: "exclusive maximum", schema: argsSchema, value: map[string]any{"ratio": 1}, wantErr: "$.ratio: value 1 must be less than 1"},
+		{name: "min items", schema: argsSchema, value: map[string]any{"labels": []any{}}, wantErr: "$.labels: expected at least 1 items, got 0"},
+		{name: "max items", schema: argsSchema, value: map[string]any{"labels": []any{"a", "b", "c"}}, wantErr: "$.labels: expected at most 2 items, got 3"},
+		{name: "additional properties false", schema: argsSchema, value: map[string]any{"extra": 1}, wantErr: `$: additional property "extra" is not allowed`},
+		{name: "additional properties schema", schema: taggedSchema, value: map[string]any{"team": "core", "size": 3}, wantErr: "$.size: expected string, got integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.schema, tt.value)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  any
+		wantErr string
+	}{
+		{name: "valid", schema: map[string]any{"type": "object", "properties": map[string]any{"q": map[string]any{"type": "string", "pattern": "^a", "maxLength": 3}}, "additionalProperties": false}},
+		{name: "unknown type", schema: map[string]any{"type": "strng"}, wantErr: `$.type: unknown type "strng"`},
+		{name: "bad pattern", schema: map[string]any{"properties": map[string]any{"q": map[string]any{"pattern": "("}}}, wantErr: "$.properties.q.pattern: error parsing regexp"},
+		{name: "negative length", schema: map[string]any{"minLength": -1}, wantErr: "$.minLength: expected non-negative integer"},
+		{name: "non-numeric bound", schema: map[string]any{"maximum": "10"}, wantErr: "$.maximum: expected number"},
+		{name: "required names", schema: map[string]any{"required": []any{1}}, wantErr: "$.required: expected property names"},
+		{name: "items schema", schema: map[string]any{"items": "string"}, wantErr: "$.items: expected schema object"},
+		{name: "additional properties schema", schema: map[string]any{"additionalProperties": map[string]any{"type": 5}}, wantErr: "$.additionalProperties.type: expected string or array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(tt.schema)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCompiledSchemaValidatesRepeatedly(t *testing.T) {
+	compiled, err := Compile(map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"code": map[string]any{"type": "string", "pattern": "^[A-Z]{3}$"}},
+	})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if len(compiled.patterns) != 1 {
+		t.Fatalf("expected the pattern to be compiled once, got %d", len(compiled.patterns))
+	}
+
+	if err := compiled.Validate(map[string]any{"code": "ABC"}); err != nil {
+		t.Fatalf("expected decoded JSON to validate, got %v", err)
+	}
+	err = compiled.Validate(map[string]string{"code": "abc"})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Path != "$.code" || !strings.Contains(validationErr.Message, "does not match pattern") {
+		t.Fatalf("expected Go values to be normalized and rejected at $.code, got %v", err)
+	}
+
+	if _, err := Compile(map[string]any{"type": "string", "pattern": "("}); err == nil {
+		t.Fatal("expected an invalid pattern to fail compilation")
+	}
+}
@@ -0,0 +1,124 @@
+package spec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileRef is an item field value that points at a local file, written in the
+// spec as {"file": "diagrams/api.png"} with optional "mimeType" and "name".
+// Paths are relative to the directory of the spec file.
+type FileRef struct {
+	Path     string
+	MimeType string
+	Name     string
+}
+
+// ParseFileRef reports whether an item field value is a file reference.
+func ParseFileRef(value any) (FileRef, bool) {
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return FileRef{}, false
+	}
+	path, ok := fields["file"].(string)
+	if !ok {
+		return FileRef{}, false
+	}
+
+	ref := FileRef{Path: path}
+	for key, field := range fields {
+		switch key {
+		case "file":
+		case "mimeType":
+			if ref.MimeType, ok = field.(string); !ok {
+				return FileRef{}, false
+			}
+		case "name":
+			if ref.Name, ok = field.(string); !ok {
+				return FileRef{}, false
+			}
+		default:
+			return FileRef{}, false
+		}
+	}
+	return ref, true
+}
+
+// ResolveFile returns the location of a file reference on disk with symlinks
+// followed. Paths must be relative, the file must exist, and it must stay
+// inside the spec directory once symlinks are resolved.
+func (s *Spec) ResolveFile(ref FileRef) (string, error) {
+	path, err := localFilePath(ref)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := resolvedPath(s.dir)
+	if err != nil {
+		return "", fmt.Errorf("resolve spec directory: %w", err)
+	}
+	resolved, err := resolvedPath(filepath.Join(s.dir, path))
+	if err != nil {
+		return "", fmt.Errorf("resolve file %q: %w", ref.Path, err)
+	}
+	if rel, err := filepath.Rel(dir, resolved); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("file path %q resolves outside the spec directory", ref.Path)
+	}
+	return resolved, nil
+}
+
+// localFilePath checks that a file reference is a relative path that does not
+// leave the spec directory lexically.
+func localFilePath(ref FileRef) (string, error) {
+	path := filepath.FromSlash(strings.TrimSpace(ref.Path))
+	if path == "" {
+		return "", fmt.Errorf("file path cannot be empty")
+	}
+	if filepath.IsAbs(path) || !filepath.IsLocal(path) {
+		return "", fmt.Errorf("file path %q must be relative to the spec directory", ref.Path)
+	}
+	return path, nil
+}
+
+// resolvedPath returns the absolute form of path with every symlink followed
+func resolvedPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// validateItemFiles checks the shape of every file reference and, when
+// checkExists is set, that the referenced files are regular files inside the
+// spec directory.
+func (s *Spec) validateItemFiles(checkExists bool) error {
+	for i, item := range s.Items {
+		for field, value := range item {
+			ref, ok := ParseFileRef(value)
+			if !ok {
+				continue
+			}
+			if !checkExists {
+				if _, err := localFilePath(ref); err != nil {
+					return fmt.Errorf("item at index %d field %q: %w", i, field, err)
+				}
+				continue
+			}
+			path, err := s.ResolveFile(ref)
+			if err != nil {
+				return fmt.Errorf("item at index %d field %q: %w", i, field, err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				return fmt.Errorf("item at index %d field %q: %w", i, field, err)
+			}
+			if !info.Mode().IsRegular() {
+				return fmt.Errorf("item at index %d field %q: %s is not a regular file", i, field, ref.Path)
+			}
+		}
+	}
+	return nil
+}
@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"mime"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
+	"github.com/BearHuddleston/mcp-server-template/pkg/schema"
 )
 
 var toolNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
@@ -23,6 +27,9 @@ type Spec struct {
 	Tools         []ToolSpec     `json:"tools"`
 	Resources     []ResourceSpec `json:"resources"`
 	Prompts       []PromptSpec   `json:"prompts"`
+
+	// dir is the directory item file references are resolved against.
+	dir string
 }
 
 type ServerSpec struct {
@@ -30,11 +37,25 @@ type ServerSpec struct {
 	Version string `json:"version"`
 }
 
+// RuntimeSpec configures the running server. Numeric settings are pointers so
+// an explicit zero can be told apart from an omitted field.
 type RuntimeSpec struct {
 	TransportType  string   `json:"transportType"`
-	HTTPPort       int      `json:"httpPort"`
+	HTTPPort       *int     `json:"httpPort"`
 	RequestTimeout string   `json:"requestTimeout"`
 	AllowedOrigins []string `json:"allowedOrigins"`
+	PageSize       *int     `json:"pageSize"`
+	EventRetention *int     `json:"eventRetention"`
+	EventMaxAge    string   `json:"eventMaxAge"`
+	EventStoreDir  string   `json:"eventStoreDir"`
+	// Session limits for the HTTP transport; durations use time.ParseDuration
+	// syntax and zero disables a limit.
+	SessionIdleTimeout string `json:"sessionIdleTimeout"`
+	SessionMaxLifetime string `json:"sessionMaxLifetime"`
+	MaxSessions        *int   `json:"maxSessions"`
+	SessionStoreDir    string `json:"sessionStoreDir"`
+	// Stateless serves HTTP requests without sessions.
+	Stateless *bool `json:"stateless"`
 }
 
 type ItemSpec map[string]any
@@ -42,22 +63,38 @@ type ItemSpec map[string]any
 type ToolSpec struct {
 	Mode        string          `json:"mode"`
 	Name        string          `json:"name"`
+	Title       string          `json:"title"`
 	Description string          `json:"description"`
 	InputSchema mcp.InputSchema `json:"inputSchema"`
+	// OutputSchema optionally describes items returned by get_item_details.
+	OutputSchema *mcp.InputSchema `json:"outputSchema"`
+	// Annotations override the built-in behavior hints of the tool mode.
+	Annotations *mcp.ToolAnnotations `json:"annotations"`
+	Icons       []mcp.Icon           `json:"icons"`
 }
 
 type ResourceSpec struct {
-	Mode string `json:"mode"`
-	URI  string `json:"uri"`
-	Name string `json:"name"`
+	Mode        string `json:"mode"`
+	URI         string `json:"uri"`
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// MimeType overrides the default "application/json" of catalog resources.
+	MimeType string     `json:"mimeType"`
+	Icons    []mcp.Icon `json:"icons"`
+	// ListItems also lists one concrete resource per item for a catalog_item template.
+	ListItems bool `json:"listItems"`
 }
 
 type PromptSpec struct {
 	Mode        string               `json:"mode"`
 	Name        string               `json:"name"`
+	Title       string               `json:"title"`
 	Description string               `json:"description"`
 	Arguments   []mcp.PromptArgument `json:"arguments"`
 	Template    string               `json:"template"`
+	Icons       []mcp.Icon           `json:"icons"`
 }
 
 func LoadFile(path string) (*Spec, error) {
@@ -74,9 +111,13 @@ func LoadFile(path string) (*Spec, error) {
 		return nil, fmt.Errorf("parse spec file: %w", err)
 	}
 
+	sp.dir = filepath.Dir(path)
 	if err := sp.Validate(); err != nil {
 		return nil, err
 	}
+	if err := sp.validateItemFiles(true); err != nil {
+		return nil, err
+	}
 
 	return &sp, nil
 }
@@ -93,7 +134,13 @@ func (s *Spec) Validate() error {
 	if err := validateItems(s.Items, lookupKey); err != nil {
 		return err
 	}
-	if err := validateResources(s.Resources); err != nil {
+	if err := validateItemOutput(s.Tools, s.Items); err != nil {
+		return err
+	}
+	if err := s.validateItemFiles(false); err != nil {
+		return err
+	}
+	if err := validateResources(s.Resources, lookupKey); err != nil {
 		return err
 	}
 	if err := validatePrompts(s.Prompts); err != nil {
@@ -111,7 +158,8 @@ func validateTools(tools []ToolSpec) (string, error) {
 		return "", fmt.Errorf("spec must include tool definitions")
 	}
 
-	validModes := []string{"list_items", "get_item_details"}
+	requiredModes := []string{"list_items", "get_item_details"}
+	validModes := append(slices.Clone(requiredModes), "summarize_item")
 	modeSeen := make(map[string]struct{}, len(validModes))
 	nameSeen := make(map[string]struct{}, len(tools))
 	lookupKey := ""
@@ -139,25 +187,42 @@ func validateTools(tools []ToolSpec) (string, error) {
 		if strings.TrimSpace(tool.InputSchema.Type) == "" {
 			return "", fmt.Errorf("tool %q inputSchema.type cannot be empty", tool.Name)
 		}
-		if tool.Mode == "get_item_details" {
-			if len(tool.InputSchema.Required) != 1 || strings.TrimSpace(tool.InputSchema.Required[0]) == "" {
-				return "", fmt.Errorf("tool %q must define exactly one required lookup field", tool.Name)
+		if err := schema.Check(tool.InputSchema); err != nil {
+			return "", fmt.Errorf("tool %q inputSchema: %w", tool.Name, err)
+		}
+		if annotations := tool.Annotations; annotations != nil && annotations.ReadOnlyHint != nil && *annotations.ReadOnlyHint &&
+			annotations.DestructiveHint != nil && *annotations.DestructiveHint {
+			return "", fmt.Errorf("tool %q annotations cannot be both readOnlyHint and destructiveHint", tool.Name)
+		}
+		if err := validateIcons(tool.Icons, "tool", tool.Name); err != nil {
+			return "", err
+		}
+		if tool.OutputSchema != nil {
+			if tool.Mode != "get_item_details" {
+				return "", fmt.Errorf("tool %q outputSchema is only supported for mode \"get_item_details\"", tool.Name)
 			}
-			lookupKey = strings.TrimSpace(tool.InputSchema.Required[0])
-
-			prop, ok := tool.InputSchema.Properties[lookupKey]
-			if !ok {
-				return "", fmt.Errorf("tool %q required lookup field %q must exist in inputSchema.properties", tool.Name, lookupKey)
+			if tool.OutputSchema.Type != "object" {
+				return "", fmt.Errorf("tool %q outputSchema.type must be object", tool.Name)
 			}
-
-			propType, ok := schemaType(prop)
-			if !ok || strings.TrimSpace(propType) != "string" {
-				return "", fmt.Errorf("tool %q lookup field %q schema type must be string", tool.Name, lookupKey)
+			if err := schema.Check(tool.OutputSchema); err != nil {
+				return "", fmt.Errorf("tool %q outputSchema: %w", tool.Name, err)
+			}
+		}
+		switch tool.Mode {
+		case "get_item_details":
+			field, err := validateLookupField(tool)
+			if err != nil {
+				return "", err
+			}
+			lookupKey = field
+		case "summarize_item":
+			if _, err := validateLookupField(tool); err != nil {
+				return "", err
 			}
 		}
 	}
 
-	if err := ensureRequiredModes(modeSeen, validModes, "tool"); err != nil {
+	if err := ensureRequiredModes(modeSeen, requiredModes, "tool"); err != nil {
 		return "", err
 	}
 	if lookupKey == "" {
@@ -167,6 +232,27 @@ func validateTools(tools []ToolSpec) (string, error) {
 	return lookupKey, nil
 }
 
+// validateLookupField checks that tool takes exactly one required string argument
+// naming an item and returns that argument's name.
+func validateLookupField(tool ToolSpec) (string, error) {
+	if len(tool.InputSchema.Required) != 1 || strings.TrimSpace(tool.InputSchema.Required[0]) == "" {
+		return "", fmt.Errorf("tool %q must define exactly one required lookup field", tool.Name)
+	}
+	field := strings.TrimSpace(tool.InputSchema.Required[0])
+
+	prop, ok := tool.InputSchema.Properties[field]
+	if !ok {
+		return "", fmt.Errorf("tool %q required lookup field %q must exist in inputSchema.properties", tool.Name, field)
+	}
+
+	propType, ok := schemaType(prop)
+	if !ok || strings.TrimSpace(propType) != "string" {
+		return "", fmt.Errorf("tool %q lookup field %q schema type must be string", tool.Name, field)
+	}
+
+	return field, nil
+}
+
 func validateItems(items []ItemSpec, lookupKey string) error {
 	if len(items) == 0 {
 		return fmt.Errorf("spec must include at least one item")
@@ -197,6 +283,22 @@ func validateItems(items []ItemSpec, lookupKey string) error {
 	return nil
 }
 
+// validateItemOutput checks every item against the detail tool's output schema, if declared,
+// so the server never returns structured content that breaks it.
+func validateItemOutput(tools []ToolSpec, items []ItemSpec) error {
+	for _, tool := range tools {
+		if tool.Mode != "get_item_details" || tool.OutputSchema == nil {
+			continue
+		}
+		for i, item := range items {
+			if err := schema.Validate(tool.OutputSchema, item); err != nil {
+				return fmt.Errorf("item at index %d does not match tool %q outputSchema: %w", i, tool.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
 func schemaType(schema any) (string, bool) {
 	switch typed := schema.(type) {
 	case map[string]any:
@@ -216,12 +318,13 @@ func schemaType(schema any) (string, bool) {
 	}
 }
 
-func validateResources(resources []ResourceSpec) error {
+func validateResources(resources []ResourceSpec, lookupKey string) error {
 	if len(resources) == 0 {
 		return fmt.Errorf("spec must include resource definitions")
 	}
 
-	validModes := []string{"catalog_items"}
+	requiredModes := []string{"catalog_items"}
+	validModes := append(slices.Clone(requiredModes), "catalog_item")
 	modeSeen := make(map[string]struct{}, len(validModes))
 	uriSeen := make(map[string]struct{}, len(resources))
 
@@ -234,26 +337,72 @@ func validateResources(resources []ResourceSpec) error {
 		}
 		modeSeen[resource.Mode] = struct{}{}
 
-		if strings.TrimSpace(resource.URI) == "" {
+		uri := resource.URI
+		if resource.Mode == "catalog_item" {
+			if err := validateItemTemplate(resource, lookupKey); err != nil {
+				return err
+			}
+			uri = resource.URITemplate
+		} else if strings.TrimSpace(resource.URI) == "" {
 			return fmt.Errorf("resource uri cannot be empty")
+		} else if resource.ListItems {
+			return fmt.Errorf("resource %q listItems requires mode \"catalog_item\"", resource.Name)
 		}
-		if _, ok := uriSeen[resource.URI]; ok {
-			return fmt.Errorf("duplicate resource uri %q", resource.URI)
+		if _, ok := uriSeen[uri]; ok {
+			return fmt.Errorf("duplicate resource uri %q", uri)
 		}
-		uriSeen[resource.URI] = struct{}{}
+		uriSeen[uri] = struct{}{}
 
 		if strings.TrimSpace(resource.Name) == "" {
 			return fmt.Errorf("resource name cannot be empty")
 		}
+		if resource.MimeType != "" {
+			if _, _, err := mime.ParseMediaType(resource.MimeType); err != nil {
+				return fmt.Errorf("resource %q mimeType %q is invalid: %w", resource.Name, resource.MimeType, err)
+			}
+		}
+		if err := validateIcons(resource.Icons, "resource", resource.Name); err != nil {
+			return err
+		}
 	}
 
-	if err := ensureRequiredModes(modeSeen, validModes, "resource"); err != nil {
+	if err := ensureRequiredModes(modeSeen, requiredModes, "resource"); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// validateItemTemplate checks that a catalog_item resource addresses items
+// through a URI template whose only variable is the lookup field.
+func validateItemTemplate(resource ResourceSpec, lookupKey string) error {
+	if strings.TrimSpace(resource.URI) != "" {
+		return fmt.Errorf("resource %q must use uriTemplate instead of uri", resource.Name)
+	}
+	if strings.TrimSpace(resource.URITemplate) == "" {
+		return fmt.Errorf("resource %q uriTemplate cannot be empty", resource.Name)
+	}
+	template, err := mcp.ParseURITemplate(resource.URITemplate)
+	if err != nil {
+		return fmt.Errorf("resource %q: %w", resource.Name, err)
+	}
+	if variables := template.Variables(); len(variables) != 1 || variables[0] != lookupKey {
+		return fmt.Errorf("resource %q uriTemplate must contain exactly one variable {%s}", resource.Name, lookupKey)
+	}
+	return nil
+}
+
+// validateIcons checks that every icon has an http(s) or data: source.
+func validateIcons(icons []mcp.Icon, kind string, name string) error {
+	for i, icon := range icons {
+		src, err := url.Parse(icon.Src)
+		if err != nil || !slices.Contains([]string{"https", "http", "data"}, src.Scheme) {
+			return fmt.Errorf("%s %q icon at index %d src must be an http(s) URL or data: URI", kind, name, i)
+		}
+	}
+	return nil
+}
+
 func validatePrompts(prompts []PromptSpec) error {
 	if len(prompts) == 0 {
 		return fmt.Errorf("spec must include prompt definitions")
@@ -286,6 +435,9 @@ func validatePrompts(prompts []PromptSpec) error {
 		if strings.TrimSpace(prompt.Template) == "" {
 			return fmt.Errorf("prompt %q template cannot be empty", prompt.Name)
 		}
+		if err := validateIcons(prompt.Icons, "prompt", prompt.Name); err != nil {
+			return err
+		}
 	}
 
 	if err := ensureRequiredModes(modeSeen, validModes, "prompt"); err != nil {
@@ -312,8 +464,42 @@ func validateRuntime(runtime RuntimeSpec) error {
 			return fmt.Errorf("invalid runtime transportType %q", runtime.TransportType)
 		}
 	}
-	if runtime.HTTPPort != 0 && (runtime.HTTPPort < 1 || runtime.HTTPPort > 65535) {
-		return fmt.Errorf("invalid runtime httpPort %d", runtime.HTTPPort)
+	if runtime.HTTPPort != nil && (*runtime.HTTPPort < 1 || *runtime.HTTPPort > 65535) {
+		return fmt.Errorf("invalid runtime httpPort %d", *runtime.HTTPPort)
+	}
+	if runtime.PageSize != nil && *runtime.PageSize < 0 {
+		return fmt.Errorf("invalid runtime pageSize %d", *runtime.PageSize)
+	}
+	if runtime.EventRetention != nil && *runtime.EventRetention < 0 {
+		return fmt.Errorf("invalid runtime eventRetention %d", *runtime.EventRetention)
+	}
+	if strings.TrimSpace(runtime.EventMaxAge) != "" {
+		duration, err := time.ParseDuration(runtime.EventMaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid runtime eventMaxAge: %w", err)
+		}
+		if duration < 0 {
+			return fmt.Errorf("runtime eventMaxAge must not be negative")
+		}
+	}
+	if runtime.MaxSessions != nil && *runtime.MaxSessions < 0 {
+		return fmt.Errorf("invalid runtime maxSessions %d", *runtime.MaxSessions)
+	}
+	sessionLimits := []struct{ name, value string }{
+		{"sessionIdleTimeout", runtime.SessionIdleTimeout},
+		{"sessionMaxLifetime", runtime.SessionMaxLifetime},
+	}
+	for _, limit := range sessionLimits {
+		if strings.TrimSpace(limit.value) == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(limit.value)
+		if err != nil {
+			return fmt.Errorf("invalid runtime %s: %w", limit.name, err)
+		}
+		if duration < 0 {
+			return fmt.Errorf("runtime %s must not be negative", limit.name)
+		}
 	}
 	if strings.TrimSpace(runtime.RequestTimeout) != "" {
 		duration, err := time.ParseDuration(runtime.RequestTimeout)
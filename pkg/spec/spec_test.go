@@ -101,6 +101,15 @@ func TestSpecValidate(t *testing.T) {
 		}
 	})
 
+	t.Run("invalid runtime event max age", func(t *testing.T) {
+		sp := validSpecForValidate()
+		sp.Runtime.EventMaxAge = "-1m"
+		err := sp.Validate()
+		if err == nil || !strings.Contains(err.Error(), "eventMaxAge") {
+			t.Fatalf("expected eventMaxAge error, got %v", err)
+		}
+	})
+
 	t.Run("missing lookup property in tool schema", func(t *testing.T) {
 		sp := validSpecForValidate()
 		sp.Tools[1].InputSchema.Properties = map[string]any{}
@@ -118,6 +127,109 @@ func TestSpecValidate(t *testing.T) {
 			t.Fatalf("expected lookup type error, got %v", err)
 		}
 	})
+
+	t.Run("optional summarize tool", func(t *testing.T) {
+		sp := validSpecForValidate()
+		sp.Tools = append(sp.Tools, ToolSpec{Mode: "summarize_item", Name: "summarizeItem", Description: "Summarize item", InputSchema: mcp.InputSchema{Type: "object", Properties: map[string]any{"item_key": map[string]any{"type": "string"}}, Required: []string{"item_key"}}})
+		if err := sp.Validate(); err != nil {
+			t.Fatalf("expected summarize tool to be accepted, got %v", err)
+		}
+
+		sp.Tools[2].InputSchema.Required = nil
+		err := sp.Validate()
+		if err == nil || !strings.Contains(err.Error(), "exactly one required lookup field") {
+			t.Fatalf("expected summarize lookup field error, got %v", err)
+		}
+	})
+
+	t.Run("detail output schema", func(t *testing.T) {
+		sp := validSpecForValidate()
+		sp.Tools[1].OutputSchema = &mcp.InputSchema{Type: "object", Properties: map[string]any{"tier": map[string]any{"type": "string", "enum": []any{"starter", "pro"}}}, Required: []string{"tier"}}
+		if err := sp.Validate(); err != nil {
+			t.Fatalf("expected output schema to be accepted, got %v", err)
+		}
+
+		sp.Items[0]["tier"] = "enterprise"
+		err := sp.Validate()
+		if err == nil || !strings.Contains(err.Error(), "does not match tool") {
+			t.Fatalf("expected item output error, got %v", err)
+		}
+
+		sp.Tools[0].OutputSchema = &mcp.InputSchema{Type: "object"}
+		err = sp.Validate()
+		if err == nil || !strings.Contains(err.Error(), "only supported for mode") {
+			t.Fatalf("expected output schema mode error, got %v", err)
+		}
+	})
+
+	t.Run("tool schemas are checked", func(t *testing.T) {
+		sp := validSpecForValidate()
+		sp.Tools[1].InputSchema.Properties["item_key"] = map[string]any{"type": "string", "pattern": "[a-"}
+		if err := sp.Validate(); err == nil || !strings.Contains(err.Error(), `tool "getItemDetails" inputSchema: invalid schema: $.properties.item_key.pattern`) {
+			t.Fatalf("expected pattern error, got %v", err)
+		}
+
+		sp = validSpecForValidate()
+		sp.Tools[1].OutputSchema = &mcp.InputSchema{Type: "object", Properties: map[string]any{"tier": map[string]any{"type": "text"}}}
+		if err := sp.Validate(); err == nil || !strings.Contains(err.Error(), `tool "getItemDetails" outputSchema: invalid schema: $.properties.tier.type`) {
+			t.Fatalf("expected output schema type error, got %v", err)
+		}
+	})
+
+	t.Run("display metadata", func(t *testing.T) {
+		sp := validSpecForValidate()
+		yes := true
+		sp.Tools[0].Title = "List Items"
+		sp.Tools[0].Annotations = &mcp.ToolAnnotations{ReadOnlyHint: &yes}
+		sp.Tools[0].Icons = []mcp.Icon{{Src: "https://example.com/list.png", MimeType: "image/png", Sizes: []string{"48x48"}}}
+		sp.Resources[0].MimeType = "application/json"
+		sp.Prompts[0].Icons = []mcp.Icon{{Src: "data:image/svg+xml;base64,PHN2Zy8+"}}
+		if err := sp.Validate(); err != nil {
+			t.Fatalf("expected display metadata to be accepted, got %v", err)
+		}
+
+		sp.Tools[0].Annotations.DestructiveHint = &yes
+		if err := sp.Validate(); err == nil || !strings.Contains(err.Error(), "both readOnlyHint and destructiveHint") {
+			t.Fatalf("expected conflicting hints error, got %v", err)
+		}
+		sp.Tools[0].Annotations = nil
+
+		sp.Prompts[0].Icons[0].Src = "file:///icon.png"
+		if err := sp.Validate(); err == nil || !strings.Contains(err.Error(), "icon at index 0") {
+			t.Fatalf("expected icon src error, got %v", err)
+		}
+		sp.Prompts[0].Icons = nil
+
+		sp.Resources[0].MimeType = "not a type"
+		if err := sp.Validate(); err == nil || !strings.Contains(err.Error(), "mimeType") {
+			t.Fatalf("expected mimeType error, got %v", err)
+		}
+	})
+
+	t.Run("optional item resource template", func(t *testing.T) {
+		sp := validSpecForValidate()
+		sp.Resources = append(sp.Resources, ResourceSpec{Mode: "catalog_item", URITemplate: "catalog://items/{item_key}", Name: "item", ListItems: true})
+		if err := sp.Validate(); err != nil {
+			t.Fatalf("expected item resource template to be accepted, got %v", err)
+		}
+
+		sp.Resources[1].URITemplate = "catalog://items/{name}"
+		err := sp.Validate()
+		if err == nil || !strings.Contains(err.Error(), "exactly one variable {item_key}") {
+			t.Fatalf("expected template variable error, got %v", err)
+		}
+
+		sp.Resources[1].URITemplate = "catalog://items/{item_key"
+		if err := sp.Validate(); err == nil || !strings.Contains(err.Error(), "unterminated expression") {
+			t.Fatalf("expected template syntax error, got %v", err)
+		}
+
+		sp.Resources = sp.Resources[:1]
+		sp.Resources[0].ListItems = true
+		if err := sp.Validate(); err == nil || !strings.Contains(err.Error(), "listItems requires mode") {
+			t.Fatalf("expected listItems mode error, got %v", err)
+		}
+	})
 }
 
 func validSpecForValidate() *Spec {
@@ -139,3 +251,97 @@ func validSpecForValidate() *Spec {
 		},
 	}
 }
+
+func TestItemFileReferences(t *testing.T) {
+	ref, ok := ParseFileRef(map[string]any{"file": "diagrams/api.png", "mimeType": "image/png"})
+	if !ok || ref.Path != "diagrams/api.png" || ref.MimeType != "image/png" {
+		t.Fatalf("unexpected file ref: %+v (%v)", ref, ok)
+	}
+	if _, ok := ParseFileRef(map[string]any{"file": "a.png", "owner": "platform"}); ok {
+		t.Fatal("expected objects with other keys not to be file refs")
+	}
+	if _, ok := ParseFileRef("a.png"); ok {
+		t.Fatal("expected plain strings not to be file refs")
+	}
+
+	for _, path := range []string{"", "../secret.txt", "/etc/passwd"} {
+		sp := validSpecForValidate()
+		sp.Items[0]["diagram"] = map[string]any{"file": path}
+		if err := sp.Validate(); err == nil || !strings.Contains(err.Error(), `field "diagram"`) {
+			t.Fatalf("expected path %q to be rejected, got %v", path, err)
+		}
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mcp-spec.json")
+	content := `{
+	  "schemaVersion": "v1",
+	  "server": {"name": "Template MCP", "version": "1.0.0"},
+	  "runtime": {},
+	  "items": [{"item_key": "Item A", "diagram": {"file": "diagrams/a.png"}}],
+	  "tools": [
+	    {"mode": "list_items", "name": "listItems", "description": "List items", "inputSchema": {"type": "object", "properties": {}, "required": []}},
+	    {"mode": "get_item_details", "name": "getItemDetails", "description": "Get item details", "inputSchema": {"type": "object", "properties": {"item_key": {"type": "string"}}, "required": ["item_key"]}}
+	  ],
+	  "resources": [{"mode": "catalog_items", "uri": "catalog://items", "name": "catalog"}],
+	  "prompts": [
+	    {"mode": "plan_recommendation", "name": "planRecommendation", "description": "Plan prompt", "arguments": [], "template": "Plan for team%s%s"},
+	    {"mode": "item_brief", "name": "itemBrief", "description": "Brief prompt", "arguments": [{"name": "item_name", "description": "Item", "required": true}], "template": "Brief for %s"}
+	  ]
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write spec file: %v", err)
+	}
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "no such file") {
+		t.Fatalf("expected missing file error, got %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "diagrams"), 0o755); err != nil {
+		t.Fatalf("failed to create diagrams dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "diagrams", "a.png"), []byte{0x89, 'P', 'N', 'G'}, 0o644); err != nil {
+		t.Fatalf("failed to write diagram: %v", err)
+	}
+	sp, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	want, err := filepath.EvalSymlinks(filepath.Join(dir, "diagrams", "a.png"))
+	if err != nil {
+		t.Fatalf("failed to resolve diagram path: %v", err)
+	}
+	resolved, err := sp.ResolveFile(FileRef{Path: "diagrams/a.png"})
+	if err != nil || resolved != want {
+		t.Fatalf("unexpected resolved path %q (%v)", resolved, err)
+	}
+
+	if err := os.Symlink("a.png", filepath.Join(dir, "diagrams", "alias.png")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	if resolved, err := sp.ResolveFile(FileRef{Path: "diagrams/alias.png"}); err != nil || resolved != want {
+		t.Fatalf("expected a symlink inside the spec directory to resolve, got %q (%v)", resolved, err)
+	}
+}
+
+func TestResolveFileRejectsEscapingSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0o600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.Symlink(secret, filepath.Join(dir, "notes.txt")); err != nil {
+		t.Fatalf("failed to create file symlink: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "shared")); err != nil {
+		t.Fatalf("failed to create directory symlink: %v", err)
+	}
+
+	sp := &Spec{dir: dir}
+	for _, path := range []string{"notes.txt", "shared/secret.txt"} {
+		if _, err := sp.ResolveFile(FileRef{Path: path}); err == nil || !strings.Contains(err.Error(), "outside the spec directory") {
+			t.Fatalf("expected symlink %q escaping the spec directory to be rejected, got %v", path, err)
+		}
+	}
+}
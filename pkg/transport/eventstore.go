@@ -0,0 +1,373 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Default event retention used when a store is created without limits.
+const (
+	DefaultEventRetention = 1000
+	DefaultEventMaxAge    = 10 * time.Minute
+)
+
+// ErrEventEvicted is returned when a Last-Event-ID refers to an event the store
+// no longer retains.
+var ErrEventEvicted = errors.New("event is no longer retained")
+
+// Event is a server-sent event retained for replay.
+type Event struct {
+	// ID is the sequence number following the stream ID in the SSE event ID.
+	ID uint64 `json:"id"`
+	// Conn identifies the SSE connection that carried the event: the ID of the
+	// connection's first event. A resumed connection keeps the ID it resumes.
+	Conn uint64          `json:"conn"`
+	Type string          `json:"type,omitempty"`
+	Data json.RawMessage `json:"data"`
+	Time time.Time       `json:"time"`
+}
+
+// EventStore retains the events sent on SSE streams so a client reconnecting
+// with Last-Event-ID can resume where it left off. Stream IDs are the prefix of
+// the SSE event IDs issued by the transport.
+type EventStore interface {
+	// Append records an event sent on streamID.
+	Append(streamID string, event Event) error
+	// Replay returns the events sent after lastID on the connection that carried
+	// lastID, along with that connection's ID. It returns ErrEventEvicted when
+	// lastID is no longer retained.
+	Replay(streamID string, lastID uint64) (conn uint64, events []Event, err error)
+	// Remove drops every event retained for streamID.
+	Remove(streamID string) error
+}
+
+// eventsAfter locates lastID in events and returns its connection and the
+// later events sent on that connection.
+func eventsAfter(events []Event, lastID uint64) (uint64, []Event, error) {
+	for i, event := range events {
+		if event.ID != lastID {
+			continue
+		}
+		var after []Event
+		for _, later := range events[i+1:] {
+			if later.Conn == event.Conn {
+				after = append(after, later)
+			}
+		}
+		return event.Conn, after, nil
+	}
+	return 0, nil, ErrEventEvicted
+}
+
+// MemoryEventStore keeps a bounded ring buffer of recent events per stream.
+type MemoryEventStore struct {
+	limit  int
+	maxAge time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	streams map[string]*eventRing
+}
+
+// NewMemoryEventStore creates an in-memory store retaining at most limit
+// events per stream, each for at most maxAge. Non-positive values use the
+// defaults.
+func NewMemoryEventStore(limit int, maxAge time.Duration) *MemoryEventStore {
+	limit, maxAge = eventRetention(limit, maxAge)
+	return &MemoryEventStore{
+		limit:   limit,
+		maxAge:  maxAge,
+		now:     time.Now,
+		streams: make(map[string]*eventRing),
+	}
+}
+
+func (s *MemoryEventStore) Append(streamID string, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ring, ok := s.streams[streamID]
+	if !ok {
+		ring = &eventRing{limit: s.limit}
+		s.streams[streamID] = ring
+	}
+	ring.expire(s.now().Add(-s.maxAge))
+	ring.push(event)
+	return nil
+}
+
+func (s *MemoryEventStore) Replay(streamID string, lastID uint64) (uint64, []Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ring, ok := s.streams[streamID]
+	if !ok {
+		return 0, nil, ErrEventEvicted
+	}
+	ring.expire(s.now().Add(-s.maxAge))
+	return eventsAfter(ring.events(), lastID)
+}
+
+func (s *MemoryEventStore) Remove(streamID string) error {
+	s.mu.Lock()
+	delete(s.streams, streamID)
+	s.mu.Unlock()
+	return nil
+}
+
+// eventRing is a fixed-capacity FIFO that overwrites its oldest event when
+// full. The buffer grows on demand up to limit.
+type eventRing struct {
+	limit int
+	buf   []Event
+	head  int
+	size  int
+}
+
+func (r *eventRing) push(event Event) {
+	if r.size == len(r.buf) && len(r.buf) < r.limit {
+		r.grow()
+	}
+	if r.size == len(r.buf) {
+		r.buf[r.head] = event
+		r.head = (r.head + 1) % len(r.buf)
+		return
+	}
+	r.buf[(r.head+r.size)%len(r.buf)] = event
+	r.size++
+}
+
+func (r *eventRing) grow() {
+	capacity := min(max(2*len(r.buf), 16), r.limit)
+	buf := make([]Event, capacity)
+	copy(buf, r.events())
+	r.buf = buf
+	r.head = 0
+}
+
+// expire drops events recorded before cutoff, oldest first.
+func (r *eventRing) expire(cutoff time.Time) {
+	for r.size > 0 && r.buf[r.head].Time.Before(cutoff) {
+		r.buf[r.head] = Event{}
+		r.head = (r.head + 1) % len(r.buf)
+		r.size--
+	}
+}
+
+// events returns the retained events, oldest first.
+func (r *eventRing) events() []Event {
+	events := make([]Event, 0, r.size)
+	for i := range r.size {
+		events = append(events, r.buf[(r.head+i)%len(r.buf)])
+	}
+	return events
+}
+
+// FileEventStore appends events to one JSON Lines file per stream, so replay
+// survives a server restart. Files are compacted once they hold twice the
+// retention limit. Appends, compaction and removal hold a lock file in the
+// directory, so several processes on the same host can share it.
+type FileEventStore struct {
+	dir    string
+	limit  int
+	maxAge time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	logs map[string]eventLog
+}
+
+// eventLog is the event count of a stream's log as of the log size this store
+// last saw. A different size means another process changed the log.
+type eventLog struct {
+	count int
+	size  int64
+}
+
+// NewFileEventStore creates a file-backed store under dir, creating the
+// directory if needed. Non-positive limits use the defaults.
+func NewFileEventStore(dir string, limit int, maxAge time.Duration) (*FileEventStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create event store directory: %w", err)
+	}
+	limit, maxAge = eventRetention(limit, maxAge)
+	return &FileEventStore{
+		dir:    dir,
+		limit:  limit,
+		maxAge: maxAge,
+		now:    time.Now,
+		logs:   make(map[string]eventLog),
+	}, nil
+}
+
+func (s *FileEventStore) Append(streamID string, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(s.path(streamID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	log, err := s.log(streamID, file)
+	if err == nil {
+		_, err = file.Write(append(line, '\n'))
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+
+	log.count++
+	log.size += int64(len(line)) + 1
+	s.logs[streamID] = log
+	if log.count >= 2*s.limit {
+		return s.compact(streamID)
+	}
+	return nil
+}
+
+// log returns the event count of the open log of streamID, counting the
+// lines of the file when it was not seen before or another process changed it.
+func (s *FileEventStore) log(streamID string, file *os.File) (eventLog, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return eventLog{}, err
+	}
+	if log, ok := s.logs[streamID]; ok && log.size == info.Size() {
+		return log, nil
+	}
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return eventLog{}, err
+	}
+	return eventLog{count: bytes.Count(data, []byte{'\n'}), size: int64(len(data))}, nil
+}
+
+func (s *FileEventStore) Replay(streamID string, lastID uint64) (uint64, []Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, err := s.retained(streamID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return eventsAfter(events, lastID)
+}
+
+func (s *FileEventStore) Remove(streamID string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	delete(s.logs, streamID)
+	if err := os.Remove(s.path(streamID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove event log: %w", err)
+	}
+	return nil
+}
+
+// retained reads the events of streamID that are within the retention limits.
+func (s *FileEventStore) retained(streamID string) ([]Event, error) {
+	data, err := os.ReadFile(s.path(streamID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event log: %w", err)
+	}
+
+	cutoff := s.now().Add(-s.maxAge)
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("failed to decode event log: %w", err)
+		}
+		if event.Time.Before(cutoff) {
+			continue
+		}
+		events = append(events, event)
+	}
+	if len(events) > s.limit {
+		events = events[len(events)-s.limit:]
+	}
+	return events, nil
+}
+
+// compact rewrites the log of streamID with only its retained events.
+func (s *FileEventStore) compact(streamID string) error {
+	events, err := s.retained(streamID)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+
+	path := s.path(streamID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to compact event log: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to compact event log: %w", err)
+	}
+	s.logs[streamID] = eventLog{count: len(events), size: int64(buf.Len())}
+	return nil
+}
+
+// lock serializes writers within this process and, through the directory's
+// lock file, with other processes, returning the function releasing both.
+func (s *FileEventStore) lock() (func(), error) {
+	s.mu.Lock()
+	unlock, err := lockFile(filepath.Join(s.dir, ".lock"))
+	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to lock event store: %w", err)
+	}
+	return func() {
+		unlock()
+		s.mu.Unlock()
+	}, nil
+}
+
+func (s *FileEventStore) path(streamID string) string {
+	return filepath.Join(s.dir, url.PathEscape(streamID)+".jsonl")
+}
+
+func eventRetention(limit int, maxAge time.Duration) (int, time.Duration) {
+	if limit < 1 {
+		limit = DefaultEventRetention
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultEventMaxAge
+	}
+	return limit, maxAge
+}
@@ -0,0 +1,187 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func testEvent(id, conn uint64, at time.Time) Event {
+	return Event{ID: id, Conn: conn, Data: json.RawMessage(`{}`), Time: at}
+}
+
+func eventIDs(events []Event) []uint64 {
+	ids := make([]uint64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func equalIDs(got, want []uint64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEventStores(t *testing.T) {
+	stores := map[string]func(t *testing.T, limit int, maxAge time.Duration) EventStore{
+		"memory": func(t *testing.T, limit int, maxAge time.Duration) EventStore {
+			return NewMemoryEventStore(limit, maxAge)
+		},
+		"file": func(t *testing.T, limit int, maxAge time.Duration) EventStore {
+			store, err := NewFileEventStore(t.TempDir(), limit, maxAge)
+			if err != nil {
+				t.Fatalf("NewFileEventStore failed: %v", err)
+			}
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Run("replays later events on the same connection", func(t *testing.T) {
+				store := newStore(t, 10, time.Hour)
+				now := time.Now()
+				for _, event := range []Event{
+					testEvent(1, 1, now), testEvent(2, 2, now), testEvent(3, 1, now),
+					testEvent(4, 2, now), testEvent(5, 1, now),
+				} {
+					if err := store.Append("session-1", event); err != nil {
+						t.Fatalf("Append failed: %v", err)
+					}
+				}
+
+				conn, events, err := store.Replay("session-1", 1)
+				if err != nil {
+					t.Fatalf("Replay failed: %v", err)
+				}
+				if conn != 1 || !equalIDs(eventIDs(events), []uint64{3, 5}) {
+					t.Fatalf("expected events 3 and 5 on connection 1, got %v on %d", eventIDs(events), conn)
+				}
+
+				if _, events, _ := store.Replay("session-1", 5); len(events) != 0 {
+					t.Fatalf("expected nothing after the latest event, got %v", eventIDs(events))
+				}
+			})
+
+			t.Run("evicts beyond the retention count", func(t *testing.T) {
+				store := newStore(t, 3, time.Hour)
+				now := time.Now()
+				for id := uint64(1); id <= 7; id++ {
+					if err := store.Append("session-1", testEvent(id, 1, now)); err != nil {
+						t.Fatalf("Append failed: %v", err)
+					}
+				}
+
+				if _, _, err := store.Replay("session-1", 4); !errors.Is(err, ErrEventEvicted) {
+					t.Fatalf("expected evicted error, got %v", err)
+				}
+				_, events, err := store.Replay("session-1", 5)
+				if err != nil || !equalIDs(eventIDs(events), []uint64{6, 7}) {
+					t.Fatalf("expected events 6 and 7, got %v (%v)", eventIDs(events), err)
+				}
+			})
+
+			t.Run("evicts beyond the retention age", func(t *testing.T) {
+				store := newStore(t, 10, time.Minute)
+				now := time.Now()
+				store.Append("session-1", testEvent(1, 1, now.Add(-2*time.Minute)))
+				store.Append("session-1", testEvent(2, 1, now))
+
+				if _, _, err := store.Replay("session-1", 1); !errors.Is(err, ErrEventEvicted) {
+					t.Fatalf("expected expired event to be evicted, got %v", err)
+				}
+				if _, _, err := store.Replay("session-1", 2); err != nil {
+					t.Fatalf("expected recent event to be retained, got %v", err)
+				}
+			})
+
+			t.Run("remove drops the stream", func(t *testing.T) {
+				store := newStore(t, 10, time.Hour)
+				store.Append("session-1", testEvent(1, 1, time.Now()))
+				if err := store.Remove("session-1"); err != nil {
+					t.Fatalf("Remove failed: %v", err)
+				}
+				if _, _, err := store.Replay("session-1", 1); !errors.Is(err, ErrEventEvicted) {
+					t.Fatalf("expected removed stream to be evicted, got %v", err)
+				}
+				if err := store.Remove("unknown"); err != nil {
+					t.Fatalf("expected removing an unknown stream to succeed, got %v", err)
+				}
+			})
+		})
+	}
+}
+
+func TestFileEventStoreCompactsAndReopens(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileEventStore(dir, 2, time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileEventStore failed: %v", err)
+	}
+	now := time.Now()
+	for id := uint64(1); id <= 5; id++ {
+		if err := store.Append("a/../b", testEvent(id, 1, now)); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	if count := store.logs["a/../b"].count; count >= 4 {
+		t.Fatalf("expected log to be compacted, holds %d events", count)
+	}
+
+	reopened, err := NewFileEventStore(dir, 2, time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileEventStore failed: %v", err)
+	}
+	_, events, err := reopened.Replay("a/../b", 4)
+	if err != nil || !equalIDs(eventIDs(events), []uint64{5}) {
+		t.Fatalf("expected event 5 after reopening, got %v (%v)", eventIDs(events), err)
+	}
+}
+
+func TestFileEventStoreCountsExistingLogs(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	first, err := NewFileEventStore(dir, 2, time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileEventStore failed: %v", err)
+	}
+	second, err := NewFileEventStore(dir, 2, time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileEventStore failed: %v", err)
+	}
+
+	// Both stores append to the same log, as processes sharing the directory
+	// or a store reopened after a restart would.
+	for id := uint64(1); id <= 6; id++ {
+		store := first
+		if id%2 == 0 {
+			store = second
+		}
+		if err := store.Append("session-1", testEvent(id, 1, now)); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(first.path("session-1"))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if lines := bytes.Count(data, []byte{'\n'}); lines >= 4 {
+		t.Fatalf("expected the shared log to stay below twice the limit, holds %d events", lines)
+	}
+	_, events, err := first.Replay("session-1", 5)
+	if err != nil || !equalIDs(eventIDs(events), []uint64{6}) {
+		t.Fatalf("expected event 6 after event 5, got %v (%v)", eventIDs(events), err)
+	}
+}
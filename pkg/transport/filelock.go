@@ -0,0 +1,14 @@
+package transport
+
+import (
+	"errors"
+	"time"
+)
+
+// File locking shared by the file-backed stores.
+const (
+	fileLockRetry   = 5 * time.Millisecond
+	fileLockTimeout = 5 * time.Second
+)
+
+var errLockTimeout = errors.New("timed out waiting for lock")
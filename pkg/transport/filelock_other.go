@@ -0,0 +1,29 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package transport
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileLocks holds the in-process locks of platforms without flock.
+var fileLocks sync.Map
+
+// lockFile takes an exclusive lock on path and returns the function releasing
+// it. Without flock the lock only serializes callers within this process, so
+// the file-backed stores must not be shared between processes here.
+func lockFile(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	mu, _ := fileLocks.LoadOrStore(path, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock, nil
+}
@@ -29,10 +29,8 @@ import (
 type HTTPTransport struct {
 	port          int
 	server        *http.Server
-	sessions      map[string]*SSESession
-	knownSessions map[string]struct{}
-	eventCounters map[string]uint64
-	mu            sync.RWMutex
+	sessions      *sessionManager
+	events        EventStore
 	config        *config.Config
 	originRegexes []*regexp.Regexp
 }
@@ -96,19 +94,24 @@ type SSESession struct {
 	writer      http.ResponseWriter
 	flusher     http.Flusher
 	nextEventID func() string
-	mu          sync.Mutex
-	closed      bool
+	// record, when set, retains each event sent for Last-Event-ID replay
+	record func(eventID, eventType string, data []byte)
+	mu     sync.Mutex
+	closed bool
 }
 
 // NewHTTP creates a new HTTP transport
 func NewHTTP(cfg *config.Config) *HTTPTransport {
 	t := &HTTPTransport{
-		port:          cfg.HTTPPort,
-		sessions:      make(map[string]*SSESession),
-		knownSessions: make(map[string]struct{}),
-		eventCounters: make(map[string]uint64),
-		config:        cfg,
+		port:     cfg.HTTPPort,
+		sessions: newSessionManager(cfg, NewMemorySessionStore()),
+		events:   NewMemoryEventStore(cfg.EventRetention, cfg.EventMaxAge),
+		config:   cfg,
 	}
+	t.sessions.writer = func(sessionID string) mcp.MessageWriter {
+		return &sessionStreamWriter{transport: t, sessionID: sessionID}
+	}
+	t.sessions.onClose = t.removeEvents
 
 	// Pre-compile regex patterns for origin validation
 	for _, allowed := range cfg.AllowedOrigins {
@@ -129,7 +132,28 @@ func NewHTTP(cfg *config.Config) *HTTPTransport {
 	return t
 }
 
+// bindServer lets a server that implements mcp.SessionTracker broadcast to
+// sessions this transport restores from the session store.
+func (t *HTTPTransport) bindServer(server mcp.Server) {
+	if tracker, ok := server.(mcp.SessionTracker); ok {
+		t.sessions.onRestore = tracker.TrackSession
+	}
+}
+
+// SetEventStore replaces the store used to replay SSE events to reconnecting
+// clients. It must be called before Start.
+func (t *HTTPTransport) SetEventStore(store EventStore) {
+	t.events = store
+}
+
+// SetSessionStore replaces the store holding HTTP session state. Transports
+// sharing a store serve each other's sessions. It must be called before Start.
+func (t *HTTPTransport) SetSessionStore(store SessionStore) {
+	t.sessions.store = store
+}
+
 func (t *HTTPTransport) Start(ctx context.Context, server mcp.Server) error {
+	t.bindServer(server)
 	mux := http.NewServeMux()
 
 	// Add CORS and security middleware
@@ -166,6 +190,10 @@ func (t *HTTPTransport) Start(ctx context.Context, server mcp.Server) error {
 	slog.Info("starting HTTP transport", "port", t.port)
 	slog.Info("MCP endpoint", "url", fmt.Sprintf("http://localhost:%d/mcp", t.port))
 
+	if interval := t.sessions.reapInterval(); interval > 0 && !t.config.Stateless {
+		go t.sessions.reap(ctx, interval)
+	}
+
 	errCh := make(chan error, 1)
 
 	// Start server in goroutine
@@ -189,15 +217,8 @@ func (t *HTTPTransport) Start(ctx context.Context, server mcp.Server) error {
 }
 
 func (t *HTTPTransport) Stop() error {
-	// Close all SSE sessions
-	t.mu.Lock()
-	for _, session := range t.sessions {
-		session.close()
-	}
-	t.sessions = make(map[string]*SSESession)
-	t.knownSessions = make(map[string]struct{})
-	t.eventCounters = make(map[string]uint64)
-	t.mu.Unlock()
+	// Close all SSE streams; sessions stay in the store
+	t.sessions.closeAll()
 
 	if t.server != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), t.config.ShutdownTimeout)
@@ -259,7 +280,14 @@ func (t *HTTPTransport) handlePost(ctx context.Context, server mcp.Server, w htt
 	}
 
 	if kind == messageKindResponse {
-		if err := t.validateExistingSession(r); err != nil {
+		if t.config.Stateless {
+			// Without sessions the server sends no requests, so nothing awaits a response.
+			slog.Warn("dropping client response", "id", messageID, "error", "stateless mode has no sessions")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		entry, err := t.validateExistingSession(r)
+		if err != nil {
 			status := http.StatusBadRequest
 			if errors.Is(err, errUnknownSession) {
 				status = http.StatusNotFound
@@ -267,6 +295,8 @@ func (t *HTTPTransport) handlePost(ctx context.Context, server mcp.Server, w htt
 			t.sendErrorWithStatus(w, messageID, mcp.ErrorCodeInvalidRequest, err.Error(), nil, status)
 			return
 		}
+		t.sessions.touch(entry.id)
+		t.handleClientResponse(raw, entry)
 		w.WriteHeader(http.StatusAccepted)
 		return
 	}
@@ -276,32 +306,48 @@ func (t *HTTPTransport) handlePost(ctx context.Context, server mcp.Server, w htt
 		return
 	}
 
-	sessionID, err := t.resolveSessionForRequest(r, req)
+	entry, err := t.resolveSessionForRequest(r, req)
 	if err != nil {
 		status := http.StatusBadRequest
-		if errors.Is(err, errUnknownSession) {
+		switch {
+		case errors.Is(err, errUnknownSession):
 			status = http.StatusNotFound
+		case errors.Is(err, ErrSessionLimit):
+			status = http.StatusServiceUnavailable
 		}
 		t.sendErrorWithStatus(w, messageID, mcp.ErrorCodeInvalidRequest, err.Error(), nil, status)
 		return
 	}
+	if entry != nil {
+		t.sessions.touch(entry.id)
+		defer t.sessions.touch(entry.id)
+		if req.Method == "initialize" || req.Method == "notifications/initialized" {
+			// Share the negotiated state with transports using the same store.
+			defer t.sessions.save(entry.id)
+		}
+	}
 
 	if kind == messageKindNotification {
-		slog.Info("received notification", "method", req.Method)
+		t.handleNotification(ctx, server, req, entry)
 		w.WriteHeader(http.StatusAccepted)
 		return
 	}
 
 	if wantsSSE {
-		t.handleSSERequest(ctx, server, w, r, req, sessionID)
+		t.handleSSERequest(ctx, server, w, r, req, entry)
 		return
 	}
 
-	t.handleJSONRequest(ctx, server, w, req, sessionID)
+	t.handleJSONRequest(ctx, server, w, req, entry)
 }
 
 func (t *HTTPTransport) handleGet(ctx context.Context, server mcp.Server, w http.ResponseWriter, r *http.Request) {
 	_ = server
+	if t.config.Stateless {
+		// There is no session to carry server-initiated messages for.
+		statelessMethodNotAllowed(w)
+		return
+	}
 	if !hasAcceptType(r.Header.Get("Accept"), "text/event-stream") {
 		http.Error(w, "Not acceptable", http.StatusNotAcceptable)
 		return
@@ -313,46 +359,51 @@ func (t *HTTPTransport) handleGet(ctx context.Context, server mcp.Server, w http
 		return
 	}
 
-	if err := t.ensureProtocolVersion(r.Header.Get(mcp.ProtocolVersionHeader)); err != nil {
+	entry := t.sessions.get(sessionID)
+	if err := t.ensureSessionProtocolVersion(r, entry); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	session := t.startSSEStream(w, r, sessionID)
+	session := t.startSSEStream(w, r, sessionID, entry)
 	if session == nil {
 		return
 	}
+	defer session.close()
 
-	<-ctx.Done()
+	box := entry.outbox
+	t.sessions.touch(sessionID)
+	defer t.sessions.touch(sessionID)
 
-	t.mu.Lock()
-	delete(t.sessions, session.ID)
-	t.mu.Unlock()
+	streamCtx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	// The GET stream carries server-initiated messages for the session. A
+	// session may hold several GET streams; each message goes to only one.
+	detach := box.attach(true)
+	defer detach()
+	box.deliver(streamCtx, session, true)
 }
 
 func (t *HTTPTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if t.config.Stateless {
+		statelessMethodNotAllowed(w)
+		return
+	}
 	sessionID := r.Header.Get(mcp.SessionIDHeader)
 	if sessionID == "" {
 		http.Error(w, "Missing MCP session header", http.StatusBadRequest)
 		return
 	}
 
-	if err := t.ensureProtocolVersion(r.Header.Get(mcp.ProtocolVersionHeader)); err != nil {
+	if err := t.ensureSessionProtocolVersion(r, t.sessions.get(sessionID)); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	t.mu.Lock()
-	_, known := t.knownSessions[sessionID]
-	delete(t.knownSessions, sessionID)
-	delete(t.eventCounters, sessionID)
-	if session, ok := t.sessions[sessionID]; ok {
-		session.close()
-		delete(t.sessions, sessionID)
-	}
-	t.mu.Unlock()
-
-	if !known {
+	if !t.sessions.remove(sessionID) {
 		http.Error(w, "Unknown session", http.StatusNotFound)
 		return
 	}
@@ -362,79 +413,10 @@ func (t *HTTPTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
 
 var errUnknownSession = errors.New("unknown session")
 
-type messageKind string
-
-const (
-	messageKindInvalid      messageKind = "invalid"
-	messageKindRequest      messageKind = "request"
-	messageKindNotification messageKind = "notification"
-	messageKindResponse     messageKind = "response"
-)
-
-func classifyJSONRPCMessage(raw json.RawMessage) (messageKind, mcp.Request, any, error) {
-	type envelope struct {
-		JSONRPC string           `json:"jsonrpc"`
-		ID      *json.RawMessage `json:"id"`
-		Method  *string          `json:"method"`
-		Params  any              `json:"params"`
-		Result  *json.RawMessage `json:"result"`
-		Error   *json.RawMessage `json:"error"`
-	}
-
-	var env envelope
-	if err := json.Unmarshal(raw, &env); err != nil {
-		return messageKindInvalid, mcp.Request{}, nil, err
-	}
-
-	hasMethod := env.Method != nil && strings.TrimSpace(*env.Method) != ""
-	hasResult := rawMessagePtrPresent(env.Result)
-	hasError := rawMessagePtrPresent(env.Error)
-
-	id, hasID := extractID(env.ID)
-
-	if hasMethod {
-		req := mcp.Request{
-			JSONRPC: env.JSONRPC,
-			ID:      id,
-			Method:  strings.TrimSpace(*env.Method),
-			Params:  env.Params,
-		}
-		if req.ID == nil {
-			return messageKindNotification, req, id, nil
-		}
-		return messageKindRequest, req, req.ID, nil
-	}
-
-	if (hasResult || hasError) && hasID && id != nil {
-		return messageKindResponse, mcp.Request{JSONRPC: strings.TrimSpace(env.JSONRPC)}, id, nil
-	}
-
-	return messageKindInvalid, mcp.Request{JSONRPC: strings.TrimSpace(env.JSONRPC)}, id, nil
-}
-
-func extractID(idRaw *json.RawMessage) (any, bool) {
-	if idRaw == nil {
-		return nil, false
-	}
-
-	var id any
-	if err := json.Unmarshal(*idRaw, &id); err != nil {
-		return nil, true
-	}
-
-	return id, true
-}
-
-func rawMessagePresent(raw json.RawMessage) bool {
-	raw = bytes.TrimSpace(raw)
-	return len(raw) != 0
-}
-
-func rawMessagePtrPresent(raw *json.RawMessage) bool {
-	if raw == nil {
-		return false
-	}
-	return rawMessagePresent(*raw)
+// statelessMethodNotAllowed rejects the session-only GET and DELETE methods
+func statelessMethodNotAllowed(w http.ResponseWriter) {
+	w.Header().Set("Allow", "POST, OPTIONS")
+	http.Error(w, "Method not allowed in stateless mode", http.StatusMethodNotAllowed)
 }
 
 func parseAcceptTypes(accept string) (bool, bool) {
@@ -488,49 +470,51 @@ func isJSONContentType(contentType string) bool {
 	return strings.TrimSpace(parts[0]) == "application/json"
 }
 
-func (t *HTTPTransport) validateExistingSession(r *http.Request) error {
-	if err := t.ensureProtocolVersion(r.Header.Get(mcp.ProtocolVersionHeader)); err != nil {
-		return err
-	}
+// validateExistingSession looks up the session a client response is sent on
+func (t *HTTPTransport) validateExistingSession(r *http.Request) (*httpSession, error) {
 	sessionID := r.Header.Get(mcp.SessionIDHeader)
+	var entry *httpSession
+	if sessionID != "" {
+		entry = t.sessions.get(sessionID)
+	}
+	if err := t.ensureSessionProtocolVersion(r, entry); err != nil {
+		return nil, err
+	}
 	if sessionID == "" {
-		return errors.New("missing MCP session header")
+		return nil, errors.New("missing MCP session header")
 	}
-	if !t.sessionExists(sessionID) {
-		return errUnknownSession
+	if entry == nil {
+		return nil, errUnknownSession
 	}
-	return nil
+	return entry, nil
 }
 
-func (t *HTTPTransport) resolveSessionForRequest(r *http.Request, req mcp.Request) (string, error) {
+// resolveSessionForRequest returns the session a request belongs to, creating
+// one for initialize. It returns nil in stateless mode.
+func (t *HTTPTransport) resolveSessionForRequest(r *http.Request, req mcp.Request) (*httpSession, error) {
 	isInitialize := req.Method == "initialize"
 
-	if !isInitialize {
-		if err := t.ensureProtocolVersion(r.Header.Get(mcp.ProtocolVersionHeader)); err != nil {
-			return "", err
+	if t.config.Stateless {
+		// Every request stands alone: initialize mints no session and a session
+		// header sent by the client is ignored.
+		if !isInitialize {
+			return nil, t.ensureProtocolVersion(r.Header.Get(mcp.ProtocolVersionHeader))
 		}
+		return nil, nil
 	}
 
 	sessionID := r.Header.Get(mcp.SessionIDHeader)
 	if isInitialize {
 		if sessionID != "" {
-			return "", errors.New("initialize must not include MCP session header")
+			return nil, errors.New("initialize must not include MCP session header")
 		}
 		created, err := generateSessionID()
 		if err != nil {
-			return "", fmt.Errorf("failed to generate session id: %w", err)
+			return nil, fmt.Errorf("failed to generate session id: %w", err)
 		}
-		t.registerSession(created)
-		return created, nil
-	}
-
-	if sessionID == "" {
-		return "", errors.New("missing MCP session header")
+		return t.sessions.create(created)
 	}
-	if !t.sessionExists(sessionID) {
-		return "", errUnknownSession
-	}
-	return sessionID, nil
+	return t.validateExistingSession(r)
 }
 
 func (t *HTTPTransport) ensureProtocolVersion(version string) error {
@@ -538,23 +522,42 @@ func (t *HTTPTransport) ensureProtocolVersion(version string) error {
 	if version == "" {
 		return errors.New("missing MCP protocol version header")
 	}
-	if version == mcp.LegacyProtocolVersion || version == mcp.ProtocolVersion {
+	if mcp.IsSupportedProtocolVersion(version) {
 		return nil
 	}
 	return fmt.Errorf("unsupported MCP protocol version: %s", version)
 }
 
-func (t *HTTPTransport) registerSession(sessionID string) {
-	t.mu.Lock()
-	t.knownSessions[sessionID] = struct{}{}
-	t.mu.Unlock()
+// ensureSessionProtocolVersion checks the MCP-Protocol-Version header against the
+// version negotiated for the request's session, which is nil when unknown.
+// Sessions negotiated before the header became mandatory may omit it.
+func (t *HTTPTransport) ensureSessionProtocolVersion(r *http.Request, entry *httpSession) error {
+	header := strings.TrimSpace(r.Header.Get(mcp.ProtocolVersionHeader))
+
+	var negotiated string
+	if entry != nil {
+		negotiated = entry.session.ProtocolVersion()
+	}
+
+	if header == "" && negotiated != "" && !mcp.ProtocolVersionAtLeast(negotiated, mcp.StructuredProtocolVersion) {
+		return nil
+	}
+	if err := t.ensureProtocolVersion(header); err != nil {
+		return err
+	}
+	if negotiated != "" && header != negotiated {
+		return fmt.Errorf("MCP protocol version %s does not match negotiated version %s", header, negotiated)
+	}
+	return nil
 }
 
-func (t *HTTPTransport) sessionExists(sessionID string) bool {
-	t.mu.RLock()
-	_, ok := t.knownSessions[sessionID]
-	t.mu.RUnlock()
-	return ok
+// withSession attaches the request's session, if any, to ctx
+func (t *HTTPTransport) withSession(ctx context.Context, entry *httpSession) context.Context {
+	if entry == nil {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, mcp.SessionIDKey, entry.id)
+	return mcp.WithSession(ctx, entry.session)
 }
 
 func generateSessionID() (string, error) {
@@ -565,15 +568,16 @@ func generateSessionID() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
-func (t *HTTPTransport) handleJSONRequest(ctx context.Context, server mcp.Server, w http.ResponseWriter, req mcp.Request, sessionID string) {
+func (t *HTTPTransport) handleJSONRequest(ctx context.Context, server mcp.Server, w http.ResponseWriter, req mcp.Request, entry *httpSession) {
 	reqCtx, cancel := context.WithTimeout(ctx, t.config.RequestTimeout)
 	defer cancel()
 
-	httpSender := &HTTPResponseSender{writer: w, sessionID: sessionID}
-	reqCtx = context.WithValue(reqCtx, mcp.ResponseSenderKey, httpSender)
-	if sessionID != "" {
-		reqCtx = context.WithValue(reqCtx, mcp.SessionIDKey, sessionID)
+	httpSender := &HTTPResponseSender{writer: w}
+	if entry != nil {
+		httpSender.sessionID = entry.id
 	}
+	reqCtx = context.WithValue(reqCtx, mcp.ResponseSenderKey, httpSender)
+	reqCtx = t.withSession(reqCtx, entry)
 
 	if err := server.HandleRequest(reqCtx, req); err != nil {
 		slog.Error("error handling request", "error", err)
@@ -588,18 +592,41 @@ func (t *HTTPTransport) handleJSONRequest(ctx context.Context, server mcp.Server
 	}
 }
 
-func (t *HTTPTransport) handleSSERequest(ctx context.Context, server mcp.Server, w http.ResponseWriter, r *http.Request, req mcp.Request, sessionID string) {
-	session := t.startSSEStream(w, r, sessionID)
+func (t *HTTPTransport) handleSSERequest(ctx context.Context, server mcp.Server, w http.ResponseWriter, r *http.Request, req mcp.Request, entry *httpSession) {
+	var sessionID string
+	if entry != nil {
+		sessionID = entry.id
+	}
+	session := t.startSSEStream(w, r, sessionID, entry)
 	if session == nil {
 		return
 	}
+	defer session.close()
 
 	reqCtx, cancel := context.WithTimeout(ctx, t.config.RequestTimeout)
 	defer cancel()
 
 	sseSender := &SSEResponseSender{session: session}
 	reqCtx = context.WithValue(reqCtx, mcp.ResponseSenderKey, sseSender)
-	reqCtx = context.WithValue(reqCtx, mcp.SessionIDKey, session.ID)
+	reqCtx = t.withSession(reqCtx, entry)
+	reqCtx = mcp.WithMessageWriter(reqCtx, session)
+
+	// Session messages fall back to this stream while no GET stream is open.
+	if entry != nil {
+		box := entry.outbox
+		detach := box.attach(false)
+		deliverCtx, stopDelivery := context.WithCancel(reqCtx)
+		delivered := make(chan struct{})
+		go func() {
+			defer close(delivered)
+			box.deliver(deliverCtx, session, false)
+		}()
+		defer func() {
+			stopDelivery()
+			<-delivered
+			detach()
+		}()
+	}
 
 	if err := server.HandleRequest(reqCtx, req); err != nil {
 		slog.Error("error handling SSE request", "error", err)
@@ -607,7 +634,35 @@ func (t *HTTPTransport) handleSSERequest(ctx context.Context, server mcp.Server,
 	}
 }
 
-func (t *HTTPTransport) startSSEStream(w http.ResponseWriter, r *http.Request, sessionID string) *SSESession {
+// handleClientResponse routes a client response to the session request waiting for it
+func (t *HTTPTransport) handleClientResponse(raw json.RawMessage, entry *httpSession) {
+	resp, err := decodeClientResponse(raw)
+	if err == nil {
+		err = entry.session.HandleResponse(resp)
+	}
+	if err != nil {
+		slog.Warn("dropping client response", "session", entry.id, "id", resp.ID, "error", err)
+	}
+}
+
+func (t *HTTPTransport) handleNotification(ctx context.Context, server mcp.Server, req mcp.Request, entry *httpSession) {
+	handler, ok := server.(mcp.NotificationHandler)
+	if !ok {
+		slog.Info("received notification", "method", req.Method)
+		return
+	}
+
+	notifyCtx, cancel := context.WithTimeout(ctx, t.config.RequestTimeout)
+	defer cancel()
+
+	if err := handler.HandleNotification(t.withSession(notifyCtx, entry), req); err != nil {
+		slog.Error("error handling notification", "method", req.Method, "error", err)
+	}
+}
+
+// startSSEStream opens an SSE stream for the session looked up for sessionID,
+// which is nil when the session is unknown or the transport is stateless.
+func (t *HTTPTransport) startSSEStream(w http.ResponseWriter, r *http.Request, sessionID string, entry *httpSession) *SSESession {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
@@ -617,52 +672,112 @@ func (t *HTTPTransport) startSSEStream(w http.ResponseWriter, r *http.Request, s
 	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
+
+	if t.config.Stateless {
+		// The stream carries only this request's messages and cannot be
+		// resumed, so its events have no IDs and are not retained.
+		return &SSESession{
+			writer:      w,
+			flusher:     flusher,
+			nextEventID: func() string { return "" },
+		}
+	}
 	w.Header().Set(mcp.SessionIDHeader, sessionID)
 
+	var (
+		conn   uint64
+		replay []Event
+	)
 	lastEventID := r.Header.Get("Last-Event-ID")
 	if lastEventID != "" {
-		resumedSessionID, counter, ok := parseLastEventID(lastEventID)
-		if !ok || resumedSessionID != sessionID {
+		resumedSessionID, lastID, ok := parseLastEventID(lastEventID)
+		if !ok || resumedSessionID != sessionID || entry == nil || lastID > entry.reservedEvents() {
 			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
 			return nil
 		}
-		t.setEventCounter(sessionID, counter)
+		var err error
+		conn, replay, err = t.events.Replay(sessionID, lastID)
+		if errors.Is(err, ErrEventEvicted) {
+			http.Error(w, "Last-Event-ID is no longer available", http.StatusGone)
+			return nil
+		}
+		if err != nil {
+			slog.Error("failed to replay SSE events", "session", sessionID, "error", err)
+			http.Error(w, "Failed to replay events", http.StatusInternalServerError)
+			return nil
+		}
 	}
 
 	session := &SSESession{
 		ID:          sessionID,
 		writer:      w,
 		flusher:     flusher,
-		nextEventID: t.nextEventIDGenerator(sessionID),
+		nextEventID: t.nextEventIDGenerator(entry),
+	}
+	// A resumed stream keeps the connection ID of the stream it continues, so a
+	// later reconnect replays events from both.
+	session.record = func(eventID, eventType string, data []byte) {
+		_, seq, ok := parseLastEventID(eventID)
+		if !ok {
+			return
+		}
+		if conn == 0 {
+			conn = seq
+		}
+		t.recordEvent(sessionID, Event{ID: seq, Conn: conn, Type: eventType, Data: data, Time: time.Now()})
 	}
 
-	t.mu.Lock()
-	if _, ok := t.knownSessions[sessionID]; !ok {
-		t.mu.Unlock()
+	if entry == nil {
 		http.Error(w, "Unknown session", http.StatusNotFound)
 		return nil
 	}
-	t.sessions[sessionID] = session
-	t.mu.Unlock()
+
+	if lastEventID != "" {
+		// A resumed stream only replays what the client missed. A connected
+		// event would take a newer ID than the replayed events, so a client
+		// dropped right after it would skip them on the next reconnect.
+		for _, event := range replay {
+			session.writeEvent(formatEventID(sessionID, event.ID), event.Type, event.Data)
+		}
+		flusher.Flush()
+		return session
+	}
 
 	session.sendEvent("connected", map[string]string{
 		"sessionId": sessionID,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 	})
-
 	return session
 }
 
-func (t *HTTPTransport) nextEventIDGenerator(streamID string) func() string {
+func (t *HTTPTransport) recordEvent(streamID string, event Event) {
+	if err := t.events.Append(streamID, event); err != nil {
+		slog.Warn("failed to retain SSE event", "stream", streamID, "id", event.ID, "error", err)
+	}
+}
+
+func (t *HTTPTransport) removeEvents(streamID string) {
+	if err := t.events.Remove(streamID); err != nil {
+		slog.Warn("failed to remove retained SSE events", "stream", streamID, "error", err)
+	}
+}
+
+func (t *HTTPTransport) nextEventIDGenerator(entry *httpSession) func() string {
 	return func() string {
-		t.mu.Lock()
-		next := t.eventCounters[streamID] + 1
-		t.eventCounters[streamID] = next
-		t.mu.Unlock()
-		return fmt.Sprintf("%s:%d", streamID, next)
+		next, err := t.sessions.nextEventID(entry)
+		if err != nil {
+			// The session is gone; send the event without a resumable ID.
+			slog.Warn("failed to issue SSE event ID", "stream", entry.id, "error", err)
+			return ""
+		}
+		return formatEventID(entry.id, next)
 	}
 }
 
+func formatEventID(streamID string, seq uint64) string {
+	return fmt.Sprintf("%s:%d", streamID, seq)
+}
+
 func parseLastEventID(lastEventID string) (string, uint64, bool) {
 	parts := strings.Split(lastEventID, ":")
 	if len(parts) != 2 {
@@ -678,14 +793,6 @@ func parseLastEventID(lastEventID string) (string, uint64, bool) {
 	return parts[0], value, true
 }
 
-func (t *HTTPTransport) setEventCounter(streamID string, value uint64) {
-	t.mu.Lock()
-	if t.eventCounters[streamID] < value {
-		t.eventCounters[streamID] = value
-	}
-	t.mu.Unlock()
-}
-
 func (t *HTTPTransport) sendError(w http.ResponseWriter, id any, code int, message string, data any) {
 	t.sendErrorWithStatus(w, id, code, message, data, http.StatusBadRequest)
 }
@@ -706,6 +813,28 @@ func (t *HTTPTransport) sendErrorWithStatus(w http.ResponseWriter, id any, code
 	json.NewEncoder(w).Encode(errorResp)
 }
 
+// sessionStreamWriter queues server-initiated messages on the session's outbox,
+// which delivers them on a GET stream or, failing that, an open POST stream.
+type sessionStreamWriter struct {
+	transport *HTTPTransport
+	sessionID string
+}
+
+func (w *sessionStreamWriter) WriteMessage(msg any) error {
+	// The session's local state is released when it is removed from the store,
+	// so queuing needs no store lookup.
+	entry, ok := w.transport.sessions.localSession(w.sessionID)
+	if !ok {
+		return mcp.ErrNoMessageWriter
+	}
+	return entry.outbox.push(msg)
+}
+
+// WriteMessage sends a server-initiated message on the stream.
+func (s *SSESession) WriteMessage(msg any) error {
+	return s.sendEvent("", msg)
+}
+
 func (s *SSESession) sendEvent(eventType string, data any) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -719,8 +848,32 @@ func (s *SSESession) sendEvent(eventType string, data any) error {
 		return err
 	}
 
+	eventID := s.nextEventID()
+	if s.record != nil {
+		s.record(eventID, eventType, dataBytes)
+	}
+	s.write(eventID, eventType, dataBytes)
+	return nil
+}
+
+// writeEvent resends a previously recorded event with its original ID.
+func (s *SSESession) writeEvent(eventID, eventType string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("session closed")
+	}
+	s.write(eventID, eventType, data)
+	return nil
+}
+
+// write emits one SSE event. Callers must hold s.mu.
+func (s *SSESession) write(eventID, eventType string, dataBytes []byte) {
 	// Write SSE event - ensure UTF-8 encoding
-	fmt.Fprintf(s.writer, "id: %s\n", s.nextEventID())
+	if eventID != "" {
+		fmt.Fprintf(s.writer, "id: %s\n", eventID)
+	}
 	if eventType != "" {
 		fmt.Fprintf(s.writer, "event: %s\n", eventType)
 	}
@@ -733,8 +886,6 @@ func (s *SSESession) sendEvent(eventType string, data any) error {
 	fmt.Fprintf(s.writer, "\n")
 
 	s.flusher.Flush()
-
-	return nil
 }
 
 func (s *SSESession) sendError(id any, code int, message string, data any) error {
@@ -771,7 +922,11 @@ func (t *HTTPTransport) corsMiddleware(next http.Handler) http.Handler {
 			// This avoids browser warnings about wildcard with credentials
 		}
 
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		if t.config.Stateless {
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		} else {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		}
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Last-Event-ID, MCP-Session-Id, MCP-Protocol-Version")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 
@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -23,7 +24,7 @@ type httpMockServerNoResponse struct{}
 
 type httpMockServerError struct{}
 
-func (m *httpMockServer) Initialize(ctx context.Context) (*mcp.InitializeResponse, error) {
+func (m *httpMockServer) Initialize(ctx context.Context, params mcp.InitializeParams) (*mcp.InitializeResponse, error) {
 	return &mcp.InitializeResponse{
 		ProtocolVersion: mcp.ProtocolVersion,
 		Capabilities:    map[string]any{},
@@ -46,16 +47,16 @@ func (m *httpMockServer) HandleRequest(ctx context.Context, req mcp.Request) err
 	})
 }
 
-func (m *httpMockServerNoResponse) Initialize(ctx context.Context) (*mcp.InitializeResponse, error) {
-	return (&httpMockServer{}).Initialize(ctx)
+func (m *httpMockServerNoResponse) Initialize(ctx context.Context, params mcp.InitializeParams) (*mcp.InitializeResponse, error) {
+	return (&httpMockServer{}).Initialize(ctx, params)
 }
 
 func (m *httpMockServerNoResponse) HandleRequest(ctx context.Context, req mcp.Request) error {
 	return nil
 }
 
-func (m *httpMockServerError) Initialize(ctx context.Context) (*mcp.InitializeResponse, error) {
-	return (&httpMockServer{}).Initialize(ctx)
+func (m *httpMockServerError) Initialize(ctx context.Context, params mcp.InitializeParams) (*mcp.InitializeResponse, error) {
+	return (&httpMockServer{}).Initialize(ctx, params)
 }
 
 func (m *httpMockServerError) HandleRequest(ctx context.Context, req mcp.Request) error {
@@ -252,7 +253,7 @@ func TestHandlePostRejectsMultipleMessages(t *testing.T) {
 
 func TestHandlePostRejectsInitializeWithSessionHeader(t *testing.T) {
 	tx := newHTTPTransportForTest()
-	tx.registerSession("existing-session")
+	registerTestSession(t, tx, "existing-session")
 	reqBody := mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "initialize", ID: 1}
 	body, err := json.Marshal(reqBody)
 	if err != nil {
@@ -357,7 +358,7 @@ func TestHandlePostRejectsInitializeNotification(t *testing.T) {
 
 func TestHandlePostNotificationAccepted(t *testing.T) {
 	tx := newHTTPTransportForTest()
-	tx.registerSession("session-1")
+	registerTestSession(t, tx, "session-1")
 	body := []byte(`{"jsonrpc":"2.0","method":"tools/list"}`)
 	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -375,7 +376,7 @@ func TestHandlePostNotificationAccepted(t *testing.T) {
 
 func TestHandlePostResponseAcceptedForKnownSession(t *testing.T) {
 	tx := newHTTPTransportForTest()
-	tx.registerSession("session-1")
+	registerTestSession(t, tx, "session-1")
 	body := []byte(`{"jsonrpc":"2.0","id":1,"result":{"ok":true}}`)
 	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -420,8 +421,8 @@ func TestOriginPatternEscapesRegexMetacharacters(t *testing.T) {
 func TestHandleDeleteCleansEventCounter(t *testing.T) {
 	tx := newHTTPTransportForTest()
 	sessionID := "session-1"
-	tx.registerSession(sessionID)
-	tx.setEventCounter(sessionID, 10)
+	registerTestSession(t, tx, sessionID)
+	tx.nextEventIDGenerator(tx.sessions.get(sessionID))()
 
 	req := httptest.NewRequest(http.MethodDelete, "/mcp", nil)
 	req.Header.Set(mcp.SessionIDHeader, sessionID)
@@ -434,10 +435,7 @@ func TestHandleDeleteCleansEventCounter(t *testing.T) {
 		t.Fatalf("expected status 204, got %d", rr.Code)
 	}
 
-	tx.mu.RLock()
-	_, exists := tx.eventCounters[sessionID]
-	tx.mu.RUnlock()
-	if exists {
+	if storedEventCounter(tx, sessionID) != 0 {
 		t.Fatal("expected event counter to be removed when session is deleted")
 	}
 }
@@ -486,19 +484,19 @@ func TestValidateExistingSession(t *testing.T) {
 	tx := newHTTPTransportForTest()
 	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
 
-	if err := tx.validateExistingSession(req); err == nil {
+	if _, err := tx.validateExistingSession(req); err == nil {
 		t.Fatal("expected validation error when headers are missing")
 	}
 
 	req.Header.Set(mcp.ProtocolVersionHeader, mcp.ProtocolVersion)
 	req.Header.Set(mcp.SessionIDHeader, "missing-session")
-	if err := tx.validateExistingSession(req); err == nil {
+	if _, err := tx.validateExistingSession(req); err == nil {
 		t.Fatal("expected unknown session validation error")
 	}
 
-	tx.registerSession("known-session")
+	registerTestSession(t, tx, "known-session")
 	req.Header.Set(mcp.SessionIDHeader, "known-session")
-	if err := tx.validateExistingSession(req); err != nil {
+	if _, err := tx.validateExistingSession(req); err != nil {
 		t.Fatalf("expected known session validation success: %v", err)
 	}
 }
@@ -592,24 +590,24 @@ func TestSSESessionSendEventAndError(t *testing.T) {
 
 func TestStartSSEStreamBranches(t *testing.T) {
 	tx := newHTTPTransportForTest()
-	tx.registerSession("session-1")
+	registerTestSession(t, tx, "session-1")
 
 	nonFlusher := &nonFlusherResponseWriter{}
 	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
-	if session := tx.startSSEStream(nonFlusher, req, "session-1"); session != nil {
+	if session := startTestStream(tx, nonFlusher, req, "session-1"); session != nil {
 		t.Fatal("expected nil session when writer is not a flusher")
 	}
 
 	req = httptest.NewRequest(http.MethodGet, "/mcp", nil)
 	req.Header.Set("Last-Event-ID", "bad-value")
 	rr := httptest.NewRecorder()
-	if session := tx.startSSEStream(rr, req, "session-1"); session != nil {
+	if session := startTestStream(tx, rr, req, "session-1"); session != nil {
 		t.Fatal("expected nil session for invalid last-event-id")
 	}
 
 	req = httptest.NewRequest(http.MethodGet, "/mcp", nil)
 	rr = httptest.NewRecorder()
-	if session := tx.startSSEStream(rr, req, "unknown"); session != nil {
+	if session := startTestStream(tx, rr, req, "unknown"); session != nil {
 		t.Fatal("expected nil session for unknown session")
 	}
 }
@@ -642,7 +640,7 @@ func TestHandleGetGuardsAndCleanup(t *testing.T) {
 		t.Fatalf("expected 400 for missing protocol header, got %d", rr.Code)
 	}
 
-	tx.registerSession("session-1")
+	registerTestSession(t, tx, "session-1")
 	req = httptest.NewRequest(http.MethodGet, "/mcp", nil)
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set(mcp.SessionIDHeader, "session-1")
@@ -652,11 +650,8 @@ func TestHandleGetGuardsAndCleanup(t *testing.T) {
 	cancel()
 	tx.handleGet(cancelledCtx, &httpMockServer{}, rr, req)
 
-	tx.mu.RLock()
-	_, exists := tx.sessions["session-1"]
-	tx.mu.RUnlock()
-	if exists {
-		t.Fatal("expected session to be cleaned up after context cancellation")
+	if get, _ := outboxStreams(tx, "session-1"); get != 0 {
+		t.Fatal("expected GET stream to be detached after context cancellation")
 	}
 }
 
@@ -685,13 +680,13 @@ func TestHandleJSONRequestBranches(t *testing.T) {
 	req := mcp.Request{JSONRPC: mcp.JSONRPCVersion, Method: "tools/list", ID: 1}
 
 	rr := httptest.NewRecorder()
-	tx.handleJSONRequest(context.Background(), &httpMockServerError{}, rr, req, "")
+	tx.handleJSONRequest(context.Background(), &httpMockServerError{}, rr, req, nil)
 	if rr.Code != http.StatusInternalServerError {
 		t.Fatalf("expected 500 on server error, got %d", rr.Code)
 	}
 
 	rr = httptest.NewRecorder()
-	tx.handleJSONRequest(context.Background(), &httpMockServerNoResponse{}, rr, req, "")
+	tx.handleJSONRequest(context.Background(), &httpMockServerNoResponse{}, rr, req, nil)
 	if rr.Code != http.StatusInternalServerError {
 		t.Fatalf("expected 500 when no response is generated, got %d", rr.Code)
 	}
@@ -766,3 +761,583 @@ func TestSSEResponseSenderSendError(t *testing.T) {
 		t.Fatalf("expected SSE error payload in output, got %q", rr.Body.String())
 	}
 }
+
+type httpNotificationServer struct {
+	httpMockServer
+	method  string
+	session *mcp.Session
+}
+
+func (m *httpNotificationServer) HandleNotification(ctx context.Context, req mcp.Request) error {
+	m.method = req.Method
+	m.session = mcp.SessionFromContext(ctx)
+	return nil
+}
+
+func TestHandlePostNotificationDeliveredWithSession(t *testing.T) {
+	tx := newHTTPTransportForTest()
+	session := registerTestSession(t, tx, "session-1")
+	body := []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(mcp.SessionIDHeader, "session-1")
+	req.Header.Set(mcp.ProtocolVersionHeader, mcp.ProtocolVersion)
+
+	srv := &httpNotificationServer{}
+	rr := httptest.NewRecorder()
+	tx.handlePost(context.Background(), srv, rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", rr.Code)
+	}
+	if srv.method != "notifications/initialized" {
+		t.Fatalf("expected notification to reach server, got %q", srv.method)
+	}
+	if srv.session != session {
+		t.Fatal("expected notification context to carry the registered session")
+	}
+}
+
+func TestHandleDeleteClosesSession(t *testing.T) {
+	tx := newHTTPTransportForTest()
+	session := registerTestSession(t, tx, "session-1")
+
+	req := httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	req.Header.Set(mcp.SessionIDHeader, "session-1")
+	req.Header.Set(mcp.ProtocolVersionHeader, mcp.ProtocolVersion)
+	rr := httptest.NewRecorder()
+	tx.handleDelete(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", rr.Code)
+	}
+	if session.State() != mcp.SessionClosed {
+		t.Fatalf("expected deleted session to be closed, got %s", session.State())
+	}
+}
+
+func TestEnsureSessionProtocolVersion(t *testing.T) {
+	tx := newHTTPTransportForTest()
+	current := registerTestSession(t, tx, "current-session")
+	current.SetClient(mcp.StructuredProtocolVersion, mcp.Implementation{}, nil)
+	legacy := registerTestSession(t, tx, "legacy-session")
+	legacy.SetClient(mcp.LegacyProtocolVersion, mcp.Implementation{}, nil)
+
+	tests := []struct {
+		name      string
+		sessionID string
+		header    string
+		wantErr   bool
+	}{
+		{name: "matches negotiated version", sessionID: "current-session", header: mcp.StructuredProtocolVersion},
+		{name: "differs from negotiated version", sessionID: "current-session", header: mcp.ProtocolVersion, wantErr: true},
+		{name: "missing header after header became mandatory", sessionID: "current-session", wantErr: true},
+		{name: "legacy session may omit header", sessionID: "legacy-session"},
+		{name: "legacy session with mismatched header", sessionID: "legacy-session", header: mcp.ProtocolVersion, wantErr: true},
+		{name: "unknown session still requires header", sessionID: "unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			req.Header.Set(mcp.SessionIDHeader, tt.sessionID)
+			if tt.header != "" {
+				req.Header.Set(mcp.ProtocolVersionHeader, tt.header)
+			}
+			err := tx.ensureSessionProtocolVersion(req, tx.sessions.get(tt.sessionID))
+			if tt.wantErr && err == nil {
+				t.Fatal("expected protocol version error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected protocol version error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSessionNotificationsUseGetStream(t *testing.T) {
+	tx := newHTTPTransportForTest()
+	session := registerTestSession(t, tx, "session-1")
+
+	if err := session.Notify(mcp.NotificationToolsListChanged, nil); !errors.Is(err, mcp.ErrNoMessageWriter) {
+		t.Fatalf("expected no stream error before GET, got %v", err)
+	}
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil).WithContext(reqCtx)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(mcp.SessionIDHeader, "session-1")
+	req.Header.Set(mcp.ProtocolVersionHeader, mcp.ProtocolVersion)
+	rr := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tx.handleGet(context.Background(), &httpMockServer{}, rr, req)
+	}()
+
+	waitForOutbox(t, tx, "session-1", 1, 0)
+
+	if err := session.Notify(mcp.NotificationToolsListChanged, nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	cancel()
+	<-done
+
+	if !strings.Contains(rr.Body.String(), mcp.NotificationToolsListChanged) {
+		t.Fatalf("expected notification on GET stream, got %q", rr.Body.String())
+	}
+	if get, _ := outboxStreams(tx, "session-1"); get != 0 {
+		t.Fatal("expected GET stream to be removed after disconnect")
+	}
+}
+
+func TestHandlePostResponseCompletesServerRequest(t *testing.T) {
+	tx := newHTTPTransportForTest()
+	session := registerTestSession(t, tx, "session-1")
+
+	requests := make(chan mcp.Request, 1)
+	session.SetWriter(writerFunc(func(msg any) error {
+		requests <- msg.(mcp.Request)
+		return nil
+	}))
+
+	type outcome struct {
+		result json.RawMessage
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := session.Request(context.Background(), "sampling/createMessage", nil)
+		done <- outcome{result, err}
+	}()
+
+	outbound := <-requests
+	body := fmt.Appendf(nil, `{"jsonrpc":"2.0","id":%q,"result":{"model":"test-model"}}`, outbound.ID)
+	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(mcp.SessionIDHeader, "session-1")
+	req.Header.Set(mcp.ProtocolVersionHeader, mcp.ProtocolVersion)
+	rr := httptest.NewRecorder()
+	tx.handlePost(context.Background(), &httpMockServer{}, rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", rr.Code)
+	}
+	got := <-done
+	if got.err != nil {
+		t.Fatalf("Request failed: %v", got.err)
+	}
+	if string(got.result) != `{"model":"test-model"}` {
+		t.Fatalf("unexpected result %s", got.result)
+	}
+}
+
+type writerFunc func(msg any) error
+
+func (f writerFunc) WriteMessage(msg any) error {
+	return f(msg)
+}
+
+// outboxStreams reports how many GET and POST streams are attached to a session's outbox.
+func outboxStreams(tx *HTTPTransport, sessionID string) (int, int) {
+	entry := tx.sessions.get(sessionID)
+	if entry == nil {
+		return 0, 0
+	}
+	box := entry.outbox
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	return box.getStreams, box.postStreams
+}
+
+func waitForOutbox(t *testing.T, tx *HTTPTransport, sessionID string, wantGet, wantPost int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		get, post := outboxStreams(tx, sessionID)
+		if get == wantGet && post == wantPost {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d GET and %d POST streams, got %d and %d", wantGet, wantPost, get, post)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func openGetStream(tx *HTTPTransport, sessionID string) (*httptest.ResponseRecorder, context.CancelFunc, <-chan struct{}) {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil).WithContext(reqCtx)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(mcp.SessionIDHeader, sessionID)
+	req.Header.Set(mcp.ProtocolVersionHeader, mcp.ProtocolVersion)
+	rr := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tx.handleGet(context.Background(), &httpMockServer{}, rr, req)
+	}()
+	return rr, cancel, done
+}
+
+func TestConcurrentGetStreamsShareSessionMessages(t *testing.T) {
+	tx := newHTTPTransportForTest()
+	session := registerTestSession(t, tx, "session-1")
+
+	first, cancelFirst, firstDone := openGetStream(tx, "session-1")
+	second, cancelSecond, secondDone := openGetStream(tx, "session-1")
+	waitForOutbox(t, tx, "session-1", 2, 0)
+
+	// Closing one stream must leave the other attached.
+	cancelFirst()
+	<-firstDone
+	waitForOutbox(t, tx, "session-1", 1, 0)
+
+	if err := session.Notify(mcp.NotificationToolsListChanged, nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	waitForPending(t, tx.sessions.get("session-1").outbox)
+
+	cancelSecond()
+	<-secondDone
+
+	if strings.Contains(first.Body.String(), mcp.NotificationToolsListChanged) {
+		t.Fatalf("expected closed stream to receive nothing, got %q", first.Body.String())
+	}
+	if !strings.Contains(second.Body.String(), mcp.NotificationToolsListChanged) {
+		t.Fatalf("expected notification on remaining GET stream, got %q", second.Body.String())
+	}
+}
+
+type httpFuncServer struct {
+	httpMockServer
+	handle func(ctx context.Context, req mcp.Request) error
+}
+
+func (m *httpFuncServer) HandleRequest(ctx context.Context, req mcp.Request) error {
+	if err := m.handle(ctx, req); err != nil {
+		return err
+	}
+	return m.httpMockServer.HandleRequest(ctx, req)
+}
+
+func waitForPending(t *testing.T, box *outbox) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		box.mu.Lock()
+		pending := len(box.pending)
+		box.mu.Unlock()
+		if pending == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected outbox to drain, %d messages pending", pending)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSessionNotificationsFallBackToPostStream(t *testing.T) {
+	tx := newHTTPTransportForTest()
+	registerTestSession(t, tx, "session-1")
+
+	srv := &httpFuncServer{handle: func(ctx context.Context, req mcp.Request) error {
+		if err := mcp.SessionFromContext(ctx).Notify(mcp.NotificationResourcesListChanged, nil); err != nil {
+			return err
+		}
+		waitForPending(t, tx.sessions.get("session-1").outbox)
+		return nil
+	}}
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(mcp.SessionIDHeader, "session-1")
+	req.Header.Set(mcp.ProtocolVersionHeader, mcp.ProtocolVersion)
+	rr := httptest.NewRecorder()
+	tx.handlePost(context.Background(), srv, rr, req)
+
+	out := rr.Body.String()
+	if !strings.Contains(out, mcp.NotificationResourcesListChanged) {
+		t.Fatalf("expected notification on POST stream, got %q", out)
+	}
+	if !strings.Contains(out, `"ok":true`) {
+		t.Fatalf("expected response on POST stream, got %q", out)
+	}
+	if get, post := outboxStreams(tx, "session-1"); get != 0 || post != 0 {
+		t.Fatalf("expected POST stream to detach, got %d GET and %d POST", get, post)
+	}
+}
+
+func TestOutboxPrefersGetStreams(t *testing.T) {
+	box := newOutbox()
+	if err := box.push("dropped"); !errors.Is(err, mcp.ErrNoMessageWriter) {
+		t.Fatalf("expected no stream error without streams, got %v", err)
+	}
+
+	detachPost := box.attach(false)
+	detachGet := box.attach(true)
+	if err := box.push("queued"); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, ok := box.next(ctx, false); ok {
+		t.Fatal("expected POST stream to wait while a GET stream is open")
+	}
+
+	// Once the GET stream goes away the POST stream picks the message up.
+	detachGet()
+	msg, ok := box.next(context.Background(), false)
+	if !ok || msg != "queued" {
+		t.Fatalf("expected queued message on POST stream, got %v (%v)", msg, ok)
+	}
+
+	for i := range maxOutboundMessages {
+		if err := box.push(i); err != nil {
+			t.Fatalf("push %d failed: %v", i, err)
+		}
+	}
+	if err := box.push("overflow"); !errors.Is(err, errOutboundQueueFull) {
+		t.Fatalf("expected full queue error, got %v", err)
+	}
+
+	box.close()
+	if _, ok := box.next(context.Background(), false); ok {
+		t.Fatal("expected closed outbox to stop delivery")
+	}
+	detachPost()
+}
+
+func resumeRequest(lastEventID string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(mcp.SessionIDHeader, "session-1")
+	req.Header.Set(mcp.ProtocolVersionHeader, mcp.ProtocolVersion)
+	req.Header.Set("Last-Event-ID", lastEventID)
+	return req
+}
+
+func TestStartSSEStreamReplaysAfterLastEventID(t *testing.T) {
+	tx := newHTTPTransportForTest()
+	registerTestSession(t, tx, "session-1")
+
+	first := startTestStream(tx, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/mcp", nil), "session-1")
+	other := startTestStream(tx, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/mcp", nil), "session-1")
+	first.WriteMessage(map[string]string{"message": "seen"})
+	first.WriteMessage(map[string]string{"message": "missed-1"})
+	other.WriteMessage(map[string]string{"message": "other-stream"})
+	first.WriteMessage(map[string]string{"message": "missed-2"})
+	first.close()
+
+	// Events 1 and 2 are the connected events; 3 is the last one the client saw.
+	rr := httptest.NewRecorder()
+	resumed := startTestStream(tx, rr, resumeRequest("session-1:3"), "session-1")
+	if resumed == nil {
+		t.Fatalf("expected stream to resume, got %d: %s", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	for _, want := range []string{"id: session-1:4\n", "missed-1", "id: session-1:6\n", "missed-2"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected replay to include %q, got %q", want, body)
+		}
+	}
+	if strings.Contains(body, "other-stream") || strings.Contains(body, `"seen"`) {
+		t.Fatalf("expected replay to skip other streams and seen events, got %q", body)
+	}
+
+	// Events on the resumed stream continue the original connection.
+	resumed.WriteMessage(map[string]string{"message": "after-resume"})
+	resumed.close()
+	rr = httptest.NewRecorder()
+	if startTestStream(tx, rr, resumeRequest("session-1:6"), "session-1") == nil {
+		t.Fatalf("expected second resume to succeed, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "after-resume") {
+		t.Fatalf("expected events from the resumed stream to replay, got %q", rr.Body.String())
+	}
+}
+
+func TestStartSSEStreamResumesWithoutConnectedEvent(t *testing.T) {
+	tx := newHTTPTransportForTest()
+	registerTestSession(t, tx, "session-1")
+
+	// Event 1 is the connected event.
+	stream := startTestStream(tx, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/mcp", nil), "session-1")
+	for _, message := range []string{"seen", "missed-1", "missed-2", "missed-3"} {
+		stream.WriteMessage(map[string]string{"message": message})
+	}
+	stream.close()
+
+	// Each reconnect drops right after its first event and resumes from it.
+	lastEventID := "session-1:2"
+	for _, want := range [][]string{
+		{"session-1:3", "session-1:4", "session-1:5"},
+		{"session-1:4", "session-1:5"},
+		{"session-1:5"},
+	} {
+		rr := httptest.NewRecorder()
+		if startTestStream(tx, rr, resumeRequest(lastEventID), "session-1") == nil {
+			t.Fatalf("expected resume from %s to succeed, got %d", lastEventID, rr.Code)
+		}
+		if strings.Contains(rr.Body.String(), "event: connected") {
+			t.Fatalf("expected no connected event on a resumed stream, got %q", rr.Body.String())
+		}
+		if got := sseEventIDs(rr.Body.String()); !slices.Equal(got, want) {
+			t.Fatalf("resume from %s: expected events %v, got %v", lastEventID, want, got)
+		}
+		lastEventID = want[0]
+	}
+}
+
+// sseEventIDs returns the IDs of the events in an SSE body, in order
+func sseEventIDs(body string) []string {
+	var ids []string
+	for _, line := range strings.Split(body, "\n") {
+		if id, ok := strings.CutPrefix(line, "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func TestStartSSEStreamRejectsUnavailableLastEventID(t *testing.T) {
+	tx := newHTTPTransportForTest(func(cfg *config.Config) {
+		cfg.EventRetention = 2
+	})
+	registerTestSession(t, tx, "session-1")
+
+	stream := startTestStream(tx, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/mcp", nil), "session-1")
+	for range 3 {
+		stream.WriteMessage(map[string]string{"message": "event"})
+	}
+
+	rr := httptest.NewRecorder()
+	if startTestStream(tx, rr, resumeRequest("session-1:1"), "session-1") != nil || rr.Code != http.StatusGone {
+		t.Fatalf("expected 410 for an evicted event, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	if startTestStream(tx, rr, resumeRequest("session-1:99"), "session-1") != nil || rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an event that was never sent, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	if startTestStream(tx, rr, resumeRequest("other-session:1"), "session-1") != nil || rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for another session's event, got %d", rr.Code)
+	}
+}
+
+func registerTestSession(t testing.TB, tx *HTTPTransport, sessionID string) *mcp.Session {
+	t.Helper()
+	entry, err := tx.sessions.create(sessionID)
+	if err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+	return entry.session
+}
+
+// startTestStream opens an SSE stream for sessionID as a GET request would
+func startTestStream(tx *HTTPTransport, w http.ResponseWriter, r *http.Request, sessionID string) *SSESession {
+	return tx.startSSEStream(w, r, sessionID, tx.sessions.get(sessionID))
+}
+
+// storedEventCounter returns the event counter the session store holds for
+// sessionID, or zero once the session is gone.
+func storedEventCounter(tx *HTTPTransport, sessionID string) uint64 {
+	record, err := tx.sessions.store.Lookup(sessionID)
+	if err != nil {
+		return 0
+	}
+	return record.EventCounter
+}
+
+func statelessPost(tx *HTTPTransport, server mcp.Server, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	rr := httptest.NewRecorder()
+	tx.handlePost(context.Background(), server, rr, req)
+	return rr
+}
+
+func TestStatelessModeHandlesEachPostOnItsOwn(t *testing.T) {
+	tx := newHTTPTransportForTest(func(cfg *config.Config) {
+		cfg.Stateless = true
+	})
+	var sawSession bool
+	server := &httpFuncServer{handle: func(ctx context.Context, req mcp.Request) error {
+		sawSession = sawSession || mcp.SessionFromContext(ctx) != nil
+		return mcp.NotifyContext(ctx, "notifications/progress", map[string]any{"progressToken": 1, "progress": 1})
+	}}
+	versioned := map[string]string{mcp.ProtocolVersionHeader: mcp.ProtocolVersion}
+
+	rr := statelessPost(tx, server, `{"jsonrpc":"2.0","id":1,"method":"initialize"}`, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected initialize to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get(mcp.SessionIDHeader); got != "" {
+		t.Fatalf("expected no session header, got %q", got)
+	}
+	body := rr.Body.String()
+	if strings.Contains(body, "id: ") || strings.Contains(body, "event: connected") {
+		t.Fatalf("expected a stream without event IDs or connected event, got %q", body)
+	}
+	if !strings.Contains(body, "notifications/progress") || !strings.Contains(body, `"ok":true`) {
+		t.Fatalf("expected request messages and result on the stream, got %q", body)
+	}
+
+	rr = statelessPost(tx, server, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, versioned)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected request without session header to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr = statelessPost(tx, server, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`, nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a protocol version, got %d", rr.Code)
+	}
+	if rr = statelessPost(tx, server, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, versioned); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected notification to be accepted, got %d", rr.Code)
+	}
+	if rr = statelessPost(tx, server, `{"jsonrpc":"2.0","id":"s-1","result":{}}`, versioned); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected client response to be accepted, got %d", rr.Code)
+	}
+
+	if sawSession {
+		t.Fatal("expected requests to be handled without a session")
+	}
+	if n := storedSessions(t, tx); n != 0 {
+		t.Fatalf("expected no sessions to be created, got %d", n)
+	}
+}
+
+func TestStatelessModeRejectsSessionMethods(t *testing.T) {
+	tx := newHTTPTransportForTest(func(cfg *config.Config) {
+		cfg.Stateless = true
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	rr := httptest.NewRecorder()
+	tx.handleGet(context.Background(), &httpMockServer{}, rr, req)
+	if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") != "POST, OPTIONS" {
+		t.Fatalf("expected GET to be rejected with 405, got %d (Allow %q)", rr.Code, rr.Header().Get("Allow"))
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	req.Header.Set(mcp.SessionIDHeader, "session-1")
+	rr = httptest.NewRecorder()
+	tx.handleDelete(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected DELETE to be rejected with 405, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
+)
+
+type messageKind string
+
+const (
+	messageKindInvalid      messageKind = "invalid"
+	messageKindRequest      messageKind = "request"
+	messageKindNotification messageKind = "notification"
+	messageKindResponse     messageKind = "response"
+)
+
+func classifyJSONRPCMessage(raw json.RawMessage) (messageKind, mcp.Request, any, error) {
+	type envelope struct {
+		JSONRPC string           `json:"jsonrpc"`
+		ID      *json.RawMessage `json:"id"`
+		Method  *string          `json:"method"`
+		Params  any              `json:"params"`
+		Result  *json.RawMessage `json:"result"`
+		Error   *json.RawMessage `json:"error"`
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return messageKindInvalid, mcp.Request{}, nil, err
+	}
+
+	hasMethod := env.Method != nil && strings.TrimSpace(*env.Method) != ""
+	hasResult := rawMessagePtrPresent(env.Result)
+	hasError := rawMessagePtrPresent(env.Error)
+
+	id, hasID := extractID(env.ID)
+
+	if hasMethod {
+		req := mcp.Request{
+			JSONRPC: env.JSONRPC,
+			ID:      id,
+			Method:  strings.TrimSpace(*env.Method),
+			Params:  env.Params,
+		}
+		if req.ID == nil {
+			return messageKindNotification, req, id, nil
+		}
+		return messageKindRequest, req, req.ID, nil
+	}
+
+	if (hasResult || hasError) && hasID && id != nil {
+		return messageKindResponse, mcp.Request{JSONRPC: strings.TrimSpace(env.JSONRPC)}, id, nil
+	}
+
+	return messageKindInvalid, mcp.Request{JSONRPC: strings.TrimSpace(env.JSONRPC)}, id, nil
+}
+
+func extractID(idRaw *json.RawMessage) (any, bool) {
+	if idRaw == nil {
+		return nil, false
+	}
+
+	var id any
+	if err := json.Unmarshal(*idRaw, &id); err != nil {
+		return nil, true
+	}
+
+	return id, true
+}
+
+func rawMessagePresent(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) != 0
+}
+
+func rawMessagePtrPresent(raw *json.RawMessage) bool {
+	if raw == nil {
+		return false
+	}
+	return rawMessagePresent(*raw)
+}
+
+// decodeClientResponse decodes a message classified as messageKindResponse
+func decodeClientResponse(raw []byte) (mcp.ClientResponse, error) {
+	var resp mcp.ClientResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return mcp.ClientResponse{}, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,157 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
+)
+
+// maxOutboundMessages bounds how many server-initiated messages may wait for
+// delivery on a single session.
+const maxOutboundMessages = 256
+
+var errOutboundQueueFull = errors.New("outbound message queue full")
+
+// outbox queues server-initiated messages for one HTTP session until an open
+// SSE stream delivers them. Standalone GET streams are preferred; POST
+// response streams only take messages while no GET stream is open. Every
+// message is written to exactly one stream.
+type outbox struct {
+	mu          sync.Mutex
+	pending     []any
+	getStreams  int
+	postStreams int
+	closed      bool
+	ready       chan struct{}
+}
+
+func newOutbox() *outbox {
+	return &outbox{ready: make(chan struct{})}
+}
+
+// push queues msg for delivery. It fails with mcp.ErrNoMessageWriter when the
+// session has no open stream to deliver it on.
+func (o *outbox) push(msg any) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed || o.getStreams+o.postStreams == 0 {
+		return mcp.ErrNoMessageWriter
+	}
+	if len(o.pending) >= maxOutboundMessages {
+		return errOutboundQueueFull
+	}
+	o.pending = append(o.pending, msg)
+	o.wake()
+	return nil
+}
+
+// requeue puts back a message a stream failed to write so another stream can
+// deliver it.
+func (o *outbox) requeue(msg any) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return
+	}
+	o.pending = append([]any{msg}, o.pending...)
+	o.wake()
+}
+
+// attach records an open stream and returns the function that removes it.
+func (o *outbox) attach(standalone bool) func() {
+	o.mu.Lock()
+	if standalone {
+		o.getStreams++
+	} else {
+		o.postStreams++
+	}
+	o.wake()
+	o.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			o.mu.Lock()
+			if standalone {
+				o.getStreams--
+			} else {
+				o.postStreams--
+			}
+			// POST streams waiting behind a GET stream may now deliver.
+			o.wake()
+			o.mu.Unlock()
+		})
+	}
+}
+
+// streaming reports whether any stream is attached.
+func (o *outbox) streaming() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.getStreams+o.postStreams > 0
+}
+
+// next waits for a message the stream may deliver. It returns false once ctx
+// is done or the outbox is closed.
+func (o *outbox) next(ctx context.Context, standalone bool) (any, bool) {
+	for {
+		o.mu.Lock()
+		if o.closed {
+			o.mu.Unlock()
+			return nil, false
+		}
+		if len(o.pending) > 0 && (standalone || o.getStreams == 0) {
+			msg := o.pending[0]
+			o.pending[0] = nil
+			o.pending = o.pending[1:]
+			o.mu.Unlock()
+			return msg, true
+		}
+		ready := o.ready
+		o.mu.Unlock()
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// deliver writes queued messages to stream until ctx is done or the outbox is
+// closed. The caller must have attached the stream.
+func (o *outbox) deliver(ctx context.Context, stream mcp.MessageWriter, standalone bool) {
+	for {
+		msg, ok := o.next(ctx, standalone)
+		if !ok {
+			return
+		}
+		if err := stream.WriteMessage(msg); err != nil {
+			o.requeue(msg)
+			return
+		}
+	}
+}
+
+// close drops pending messages and stops every stream delivering from the outbox.
+func (o *outbox) close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return
+	}
+	o.closed = true
+	o.pending = nil
+	o.wake()
+}
+
+// wake releases every goroutine blocked in next. Callers must hold o.mu.
+func (o *outbox) wake() {
+	close(o.ready)
+	o.ready = make(chan struct{})
+}
@@ -71,7 +71,7 @@ func BenchmarkIsOriginAllowed(b *testing.B) {
 func BenchmarkHandlePostToolsList(b *testing.B) {
 	b.ReportAllocs()
 	tx := newHTTPTransportForTest()
-	tx.registerSession("session-1")
+	registerTestSession(b, tx, "session-1")
 	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
 
 	b.ResetTimer()
@@ -0,0 +1,377 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/BearHuddleston/mcp-server-template/pkg/config"
+	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
+)
+
+// Reasons a session is closed, reported in logs.
+const (
+	closeReasonDeleted   = "deleted"
+	closeReasonIdle      = "idle"
+	closeReasonLifetime  = "lifetime"
+	closeReasonElsewhere = "closed elsewhere"
+)
+
+// eventIDBlock is how many SSE event IDs a transport reserves from the session
+// store at a time, so issuing an event rarely writes to the store.
+const eventIDBlock = 64
+
+// httpSession is the process-local state of one MCP HTTP session.
+type httpSession struct {
+	id      string
+	session *mcp.Session
+	outbox  *outbox
+
+	mu sync.Mutex
+	// eventCounter is the store's event counter as of the last lookup.
+	eventCounter uint64
+	// nextEvent and lastEvent bound the event IDs reserved but not yet issued.
+	nextEvent uint64
+	lastEvent uint64
+}
+
+// reservedEvents returns the highest event sequence number the session may
+// have issued, as of its last lookup.
+func (e *httpSession) reservedEvents() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.eventCounter
+}
+
+// sessionManager tracks HTTP sessions in a SessionStore and expires them after
+// an idle timeout or a maximum lifetime. A zero limit disables that check.
+// Sessions with an open SSE stream are never idle. Sessions created by another
+// transport sharing the store are restored locally on first use.
+type sessionManager struct {
+	store       SessionStore
+	idleTimeout time.Duration
+	maxLifetime time.Duration
+	maxSessions int
+	now         func() time.Time
+	// writer returns where server-initiated messages for a session are queued.
+	writer func(sessionID string) mcp.MessageWriter
+	// onClose releases per-session transport state after a session is removed.
+	onClose func(sessionID string)
+	// onRestore registers a session restored from the store with the server,
+	// so that its broadcasts reach the session's streams on this transport.
+	onRestore func(session *mcp.Session)
+
+	mu    sync.Mutex
+	local map[string]*httpSession
+}
+
+func newSessionManager(cfg *config.Config, store SessionStore) *sessionManager {
+	return &sessionManager{
+		store:       store,
+		idleTimeout: cfg.SessionIdleTimeout,
+		maxLifetime: cfg.SessionMaxLifetime,
+		maxSessions: cfg.MaxSessions,
+		now:         time.Now,
+		local:       make(map[string]*httpSession),
+	}
+}
+
+// create stores a new session under id, failing with ErrSessionLimit when the
+// maximum number of concurrent sessions is reached.
+func (m *sessionManager) create(id string) (*httpSession, error) {
+	now := m.now()
+	record := SessionRecord{ID: id, Created: now, LastSeen: now}
+	err := m.store.Create(record, m.maxSessions)
+	if errors.Is(err, ErrSessionLimit) && m.expire() > 0 {
+		err = m.store.Create(record, m.maxSessions)
+	}
+	if errors.Is(err, ErrSessionLimit) {
+		slog.Warn("HTTP session limit reached", "max", m.maxSessions)
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	entry := m.attach(id, mcp.NewSession(id))
+	slog.Info("HTTP session created", "session", id, "local", m.localCount())
+	return entry, nil
+}
+
+// get returns the live session for id, closing it first if it has expired.
+// It reads the store, so a request looks its session up once and passes the
+// result along.
+func (m *sessionManager) get(id string) *httpSession {
+	record, err := m.store.Lookup(id)
+	if err != nil {
+		if !errors.Is(err, ErrSessionNotFound) {
+			slog.Error("failed to look up HTTP session", "session", id, "error", err)
+		}
+		m.dropLocal(id)
+		return nil
+	}
+	if reason := m.expiry(record, m.now()); reason != "" {
+		m.expireSession(id, reason)
+		return nil
+	}
+
+	entry, ok := m.localSession(id)
+	if !ok {
+		entry = m.attach(id, mcp.RestoreSession(id, record.Session))
+		if m.onRestore != nil {
+			m.onRestore(entry.session)
+		}
+	} else if record.Session.State > entry.session.State() {
+		// Catch up when another transport moved the session further through
+		// its lifecycle, such as handling notifications/initialized.
+		entry.session.Restore(record.Session)
+	}
+	entry.mu.Lock()
+	entry.eventCounter = record.EventCounter
+	entry.mu.Unlock()
+	return entry
+}
+
+// localSession returns the session's local state without consulting the store.
+func (m *sessionManager) localSession(id string) (*httpSession, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.local[id]
+	return entry, ok
+}
+
+// touch records client activity on the session.
+func (m *sessionManager) touch(id string) {
+	if err := m.store.Touch(id, m.now()); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		slog.Warn("failed to record HTTP session activity", "session", id, "error", err)
+	}
+}
+
+// save stores the lifecycle state and negotiated details of the local session.
+func (m *sessionManager) save(id string) {
+	m.mu.Lock()
+	entry, ok := m.local[id]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	if err := m.store.SetSession(id, entry.session.Snapshot()); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		slog.Warn("failed to save HTTP session state", "session", id, "error", err)
+	}
+}
+
+// nextEventID issues the session's next SSE event sequence number from the
+// block reserved by this transport, reserving another block when it runs out.
+// Transports sharing a store issue disjoint IDs, increasing on each stream.
+func (m *sessionManager) nextEventID(entry *httpSession) (uint64, error) {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.nextEvent == 0 || entry.nextEvent > entry.lastEvent {
+		first, err := m.store.ReserveEventIDs(entry.id, eventIDBlock)
+		if err != nil {
+			return 0, err
+		}
+		entry.nextEvent, entry.lastEvent = first, first+eventIDBlock-1
+		entry.eventCounter = max(entry.eventCounter, entry.lastEvent)
+	}
+	next := entry.nextEvent
+	entry.nextEvent++
+	return next, nil
+}
+
+// remove deletes the session for id and reports whether it existed.
+func (m *sessionManager) remove(id string) bool {
+	if err := m.store.Delete(id); err != nil {
+		if !errors.Is(err, ErrSessionNotFound) {
+			slog.Error("failed to delete HTTP session", "session", id, "error", err)
+		}
+		m.dropLocal(id)
+		return false
+	}
+	m.release(id, closeReasonDeleted)
+	return true
+}
+
+// closeAll closes the local state of every session, leaving the store intact
+// for other transports sharing it.
+func (m *sessionManager) closeAll() {
+	m.mu.Lock()
+	local := m.local
+	m.local = make(map[string]*httpSession)
+	m.mu.Unlock()
+
+	for _, entry := range local {
+		entry.outbox.close()
+		entry.session.Close()
+	}
+}
+
+// expire closes every expired session and returns how many were closed.
+func (m *sessionManager) expire() int {
+	now := m.now()
+	// Sessions streaming here must not look idle to other transports.
+	for _, id := range m.streamingIDs() {
+		m.touch(id)
+	}
+
+	records, err := m.store.List()
+	if err != nil {
+		slog.Error("failed to list HTTP sessions", "error", err)
+		return 0
+	}
+	live := make(map[string]bool, len(records))
+	expired := 0
+	for _, record := range records {
+		if reason := m.expiry(record, now); reason != "" {
+			m.expireSession(record.ID, reason)
+			expired++
+			continue
+		}
+		live[record.ID] = true
+	}
+	for _, id := range m.localIDs() {
+		if !live[id] {
+			m.dropLocal(id)
+		}
+	}
+
+	if expired > 0 {
+		slog.Info("reaped expired HTTP sessions", "expired", expired, "active", len(live))
+	}
+	return expired
+}
+
+// reap expires sessions every interval until ctx is done.
+func (m *sessionManager) reap(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.expire()
+		}
+	}
+}
+
+// reapInterval returns how often the reaper runs, or zero when sessions never
+// expire.
+func (m *sessionManager) reapInterval() time.Duration {
+	if m.idleTimeout <= 0 && m.maxLifetime <= 0 {
+		return 0
+	}
+	interval := time.Minute
+	for _, limit := range []time.Duration{m.idleTimeout, m.maxLifetime} {
+		if limit > 0 {
+			interval = min(interval, limit/2)
+		}
+	}
+	return max(interval, time.Second)
+}
+
+// localCount returns the number of sessions attached to this transport. It
+// does not read the store, so it is cheap enough for every log line.
+func (m *sessionManager) localCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.local)
+}
+
+// expiry returns why record has expired at now, or "" while it is live.
+func (m *sessionManager) expiry(record SessionRecord, now time.Time) string {
+	if m.maxLifetime > 0 && now.Sub(record.Created) >= m.maxLifetime {
+		return closeReasonLifetime
+	}
+	if m.idleTimeout > 0 && now.Sub(record.LastSeen) >= m.idleTimeout && !m.streaming(record.ID) {
+		return closeReasonIdle
+	}
+	return ""
+}
+
+// attach registers the local state of a stored session, returning the
+// existing entry if another request attached it first.
+func (m *sessionManager) attach(id string, session *mcp.Session) *httpSession {
+	if m.writer != nil {
+		session.SetWriter(m.writer(id))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry, ok := m.local[id]; ok {
+		return entry
+	}
+	entry := &httpSession{id: id, session: session, outbox: newOutbox()}
+	m.local[id] = entry
+	return entry
+}
+
+func (m *sessionManager) expireSession(id, reason string) {
+	if err := m.store.Delete(id); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		slog.Error("failed to delete expired HTTP session", "session", id, "error", err)
+		return
+	}
+	m.release(id, reason)
+}
+
+// dropLocal releases the local state of a session another transport removed.
+func (m *sessionManager) dropLocal(id string) {
+	m.mu.Lock()
+	_, ok := m.local[id]
+	m.mu.Unlock()
+	if ok {
+		m.release(id, closeReasonElsewhere)
+	}
+}
+
+// release closes the local state of a session removed from the store. Closing
+// its outbox ends every SSE stream delivering for it.
+func (m *sessionManager) release(id, reason string) {
+	m.mu.Lock()
+	entry, ok := m.local[id]
+	delete(m.local, id)
+	m.mu.Unlock()
+
+	if ok {
+		entry.outbox.close()
+		entry.session.Close()
+	}
+	if m.onClose != nil {
+		m.onClose(id)
+	}
+	slog.Info("HTTP session closed", "session", id, "reason", reason, "local", m.localCount())
+}
+
+func (m *sessionManager) streaming(id string) bool {
+	m.mu.Lock()
+	entry, ok := m.local[id]
+	m.mu.Unlock()
+	return ok && entry.outbox.streaming()
+}
+
+func (m *sessionManager) streamingIDs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ids []string
+	for id, entry := range m.local {
+		if entry.outbox.streaming() {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (m *sessionManager) localIDs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, 0, len(m.local))
+	for id := range m.local {
+		ids = append(ids, id)
+	}
+	return ids
+}
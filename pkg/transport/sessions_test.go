@@ -0,0 +1,206 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BearHuddleston/mcp-server-template/pkg/config"
+	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
+)
+
+// fakeClock is a manually advanced time source for session expiry tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newExpiringTransport(t *testing.T, idle, lifetime time.Duration) (*HTTPTransport, *fakeClock) {
+	t.Helper()
+	tx := newHTTPTransportForTest(func(cfg *config.Config) {
+		cfg.SessionIdleTimeout = idle
+		cfg.SessionMaxLifetime = lifetime
+	})
+	clock := &fakeClock{now: time.Now()}
+	tx.sessions.now = clock.Now
+	return tx, clock
+}
+
+func TestSessionIdleExpiry(t *testing.T) {
+	tx, clock := newExpiringTransport(t, time.Minute, 0)
+	session := registerTestSession(t, tx, "session-1")
+	tx.nextEventIDGenerator(tx.sessions.get("session-1"))()
+
+	clock.Advance(50 * time.Second)
+	tx.sessions.touch("session-1")
+	clock.Advance(50 * time.Second)
+	if !(tx.sessions.get("session-1") != nil) {
+		t.Fatal("expected recent activity to keep the session alive")
+	}
+
+	clock.Advance(time.Minute)
+	if tx.sessions.get("session-1") != nil {
+		t.Fatal("expected idle session to expire")
+	}
+	if session.State() != mcp.SessionClosed {
+		t.Fatalf("expected expired session to be closed, got %s", session.State())
+	}
+	if storedEventCounter(tx, "session-1") != 0 {
+		t.Fatal("expected event counter to be released with the session")
+	}
+}
+
+func TestSessionWithOpenStreamIsNotIdle(t *testing.T) {
+	tx, clock := newExpiringTransport(t, time.Minute, time.Hour)
+	registerTestSession(t, tx, "session-1")
+
+	detach := tx.sessions.get("session-1").outbox.attach(true)
+	clock.Advance(10 * time.Minute)
+	if tx.sessions.expire() != 0 {
+		t.Fatal("expected streaming session to survive the idle timeout")
+	}
+
+	detach()
+	clock.Advance(time.Hour)
+	if tx.sessions.expire() != 1 {
+		t.Fatal("expected session to expire at its maximum lifetime")
+	}
+}
+
+func TestSessionReaperClosesExpiredStreams(t *testing.T) {
+	tx, clock := newExpiringTransport(t, 0, time.Minute)
+	registerTestSession(t, tx, "session-1")
+
+	rr, cancel, done := openGetStream(tx, "session-1")
+	defer cancel()
+	waitForOutbox(t, tx, "session-1", 1, 0)
+
+	clock.Advance(time.Minute)
+	tx.sessions.expire()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected expired session's GET stream to close")
+	}
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected stream to have started, got %d", rr.Code)
+	}
+	if n := storedSessions(t, tx); n != 0 {
+		t.Fatalf("expected no sessions after reaping, got %d", n)
+	}
+	if n := tx.sessions.localCount(); n != 0 {
+		t.Fatalf("expected no local sessions after reaping, got %d", n)
+	}
+}
+
+func storedSessions(t *testing.T, tx *HTTPTransport) int {
+	t.Helper()
+	records, err := tx.sessions.store.List()
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	return len(records)
+}
+
+func TestSessionLimitRejectsInitialize(t *testing.T) {
+	tx := newHTTPTransportForTest(func(cfg *config.Config) {
+		cfg.MaxSessions = 1
+	})
+	registerTestSession(t, tx, "session-1")
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-11-25","capabilities":{},"clientInfo":{"name":"test","version":"1.0.0"}}}`)
+	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	rr := httptest.NewRecorder()
+	tx.handlePost(context.Background(), &httpMockServer{}, rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 at the session limit, got %d", rr.Code)
+	}
+
+	// Deleting a session frees a slot.
+	tx.sessions.remove("session-1")
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	tx.handlePost(context.Background(), &httpMockServer{}, rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected initialize to succeed after a session closed, got %d", rr.Code)
+	}
+}
+
+func TestSessionReapInterval(t *testing.T) {
+	tests := []struct {
+		idle, lifetime time.Duration
+		want           time.Duration
+	}{
+		{0, 0, 0},
+		{30 * time.Minute, 24 * time.Hour, time.Minute},
+		{10 * time.Second, 0, 5 * time.Second},
+		{0, time.Second, time.Second},
+	}
+	for _, tt := range tests {
+		m := &sessionManager{idleTimeout: tt.idle, maxLifetime: tt.lifetime}
+		if got := m.reapInterval(); got != tt.want {
+			t.Errorf("reapInterval(%v, %v) = %v, want %v", tt.idle, tt.lifetime, got, tt.want)
+		}
+	}
+}
+
+// countingSessionStore counts the store reads and event ID reservations the
+// transport makes.
+type countingSessionStore struct {
+	SessionStore
+	lookups      int
+	reservations int
+}
+
+func (s *countingSessionStore) Lookup(id string) (SessionRecord, error) {
+	s.lookups++
+	return s.SessionStore.Lookup(id)
+}
+
+func (s *countingSessionStore) ReserveEventIDs(id string, n uint64) (uint64, error) {
+	s.reservations++
+	return s.SessionStore.ReserveEventIDs(id, n)
+}
+
+func TestSessionRequestsUseTheStoreSparingly(t *testing.T) {
+	store := &countingSessionStore{SessionStore: NewMemorySessionStore()}
+	tx := newHTTPTransportForTest()
+	tx.SetSessionStore(store)
+	registerTestSession(t, tx, "session-1")
+
+	for range eventIDBlock {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(mcp.SessionIDHeader, "session-1")
+		req.Header.Set(mcp.ProtocolVersionHeader, mcp.ProtocolVersion)
+
+		store.lookups = 0
+		rr := httptest.NewRecorder()
+		tx.handlePost(context.Background(), &httpMockServer{}, rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if store.lookups != 1 {
+			t.Fatalf("expected one session lookup per request, got %d", store.lookups)
+		}
+	}
+
+	// Each stream sent a connected event and a response, two blocks' worth.
+	if store.reservations != 2 {
+		t.Fatalf("expected event IDs to be reserved in 2 blocks, got %d reservations", store.reservations)
+	}
+}
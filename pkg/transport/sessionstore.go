@@ -0,0 +1,335 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
+)
+
+// Session store errors.
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionExists   = errors.New("session already exists")
+	ErrSessionLimit    = errors.New("too many active sessions")
+)
+
+// SessionRecord is the shared state of one HTTP session.
+type SessionRecord struct {
+	ID       string    `json:"id"`
+	Created  time.Time `json:"created"`
+	LastSeen time.Time `json:"lastSeen"`
+	// EventCounter is the last SSE event sequence number reserved for the session.
+	EventCounter uint64              `json:"eventCounter"`
+	Session      mcp.SessionSnapshot `json:"session"`
+}
+
+// SessionStore holds HTTP session state. Transports sharing a store can serve
+// each other's sessions, so replicas behind a load balancer agree on which
+// sessions exist, when they were last used and what they negotiated. A
+// transport registers sessions it restores with a server implementing
+// mcp.SessionTracker, so the server's list_changed broadcasts reach them.
+// Resource subscriptions and log levels are not shared: they stay with the
+// process that handled resources/subscribe or logging/setLevel.
+type SessionStore interface {
+	// Create stores a new session. It fails with ErrSessionLimit when limit is
+	// positive and that many sessions already exist.
+	Create(record SessionRecord, limit int) error
+	// Lookup returns the session with id, or ErrSessionNotFound.
+	Lookup(id string) (SessionRecord, error)
+	// List returns every stored session.
+	List() ([]SessionRecord, error)
+	// Touch records client activity on the session at the given time.
+	Touch(id string, at time.Time) error
+	// SetSession saves the session's lifecycle state and negotiated details.
+	SetSession(id string, snapshot mcp.SessionSnapshot) error
+	// ReserveEventIDs advances the session's SSE event counter by n and returns
+	// the first of the n sequence numbers reserved.
+	ReserveEventIDs(id string, n uint64) (uint64, error)
+	// Delete removes the session, or returns ErrSessionNotFound.
+	Delete(id string) error
+}
+
+// MemorySessionStore keeps sessions in process memory. It is the default store
+// and suits a single server process.
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]SessionRecord
+}
+
+// NewMemorySessionStore creates an empty in-memory session store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]SessionRecord)}
+}
+
+func (s *MemorySessionStore) Create(record SessionRecord, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[record.ID]; ok {
+		return ErrSessionExists
+	}
+	if limit > 0 && len(s.sessions) >= limit {
+		return ErrSessionLimit
+	}
+	s.sessions[record.ID] = record
+	return nil
+}
+
+func (s *MemorySessionStore) Lookup(id string) (SessionRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.sessions[id]
+	if !ok {
+		return SessionRecord{}, ErrSessionNotFound
+	}
+	return record, nil
+}
+
+func (s *MemorySessionStore) List() ([]SessionRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]SessionRecord, 0, len(s.sessions))
+	for _, record := range s.sessions {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (s *MemorySessionStore) Touch(id string, at time.Time) error {
+	return s.update(id, func(record *SessionRecord) {
+		record.LastSeen = at
+	})
+}
+
+func (s *MemorySessionStore) SetSession(id string, snapshot mcp.SessionSnapshot) error {
+	return s.update(id, func(record *SessionRecord) {
+		record.Session = snapshot
+	})
+}
+
+func (s *MemorySessionStore) ReserveEventIDs(id string, n uint64) (uint64, error) {
+	var first uint64
+	err := s.update(id, func(record *SessionRecord) {
+		first = record.EventCounter + 1
+		record.EventCounter += n
+	})
+	return first, err
+}
+
+func (s *MemorySessionStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[id]; !ok {
+		return ErrSessionNotFound
+	}
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *MemorySessionStore) update(id string, apply func(*SessionRecord)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.sessions[id]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	apply(&record)
+	s.sessions[id] = record
+	return nil
+}
+
+// FileSessionStore keeps one JSON file per session in a directory that several
+// server processes on the same host, or several transports in one process, can
+// share. Writes are serialized by an flock on a lock file, which the kernel
+// releases if its holder crashes, and replace session files atomically, so
+// reads need no lock.
+type FileSessionStore struct {
+	dir string
+}
+
+// NewFileSessionStore creates a file-backed session store under dir, creating
+// the directory if needed.
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create session store directory: %w", err)
+	}
+	return &FileSessionStore{dir: dir}, nil
+}
+
+func (s *FileSessionStore) Create(record SessionRecord, limit int) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := s.read(record.ID); err == nil {
+		return ErrSessionExists
+	} else if !errors.Is(err, ErrSessionNotFound) {
+		return err
+	}
+	if limit > 0 {
+		ids, err := s.ids()
+		if err != nil {
+			return err
+		}
+		if len(ids) >= limit {
+			return ErrSessionLimit
+		}
+	}
+	return s.write(record)
+}
+
+func (s *FileSessionStore) Lookup(id string) (SessionRecord, error) {
+	return s.read(id)
+}
+
+func (s *FileSessionStore) List() ([]SessionRecord, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]SessionRecord, 0, len(ids))
+	for _, id := range ids {
+		record, err := s.read(id)
+		if errors.Is(err, ErrSessionNotFound) {
+			// Deleted since the directory was listed.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (s *FileSessionStore) Touch(id string, at time.Time) error {
+	return s.update(id, func(record *SessionRecord) {
+		record.LastSeen = at
+	})
+}
+
+func (s *FileSessionStore) SetSession(id string, snapshot mcp.SessionSnapshot) error {
+	return s.update(id, func(record *SessionRecord) {
+		record.Session = snapshot
+	})
+}
+
+func (s *FileSessionStore) ReserveEventIDs(id string, n uint64) (uint64, error) {
+	var first uint64
+	err := s.update(id, func(record *SessionRecord) {
+		first = record.EventCounter + 1
+		record.EventCounter += n
+	})
+	return first, err
+}
+
+func (s *FileSessionStore) Delete(id string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+func (s *FileSessionStore) update(id string, apply func(*SessionRecord)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	record, err := s.read(id)
+	if err != nil {
+		return err
+	}
+	apply(&record)
+	return s.write(record)
+}
+
+func (s *FileSessionStore) read(id string) (SessionRecord, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return SessionRecord{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return SessionRecord{}, fmt.Errorf("failed to read session: %w", err)
+	}
+	var record SessionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return SessionRecord{}, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return record, nil
+}
+
+func (s *FileSessionStore) write(record SessionRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	path := s.path(record.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// ids returns the IDs of the stored sessions.
+func (s *FileSessionStore) ids() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		id, err := url.PathUnescape(name)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *FileSessionStore) path(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+".json")
+}
+
+// lock acquires the store's lock file and returns the function releasing it.
+func (s *FileSessionStore) lock() (func(), error) {
+	unlock, err := lockFile(filepath.Join(s.dir, ".lock"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock session store: %w", err)
+	}
+	return unlock, nil
+}
@@ -0,0 +1,256 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BearHuddleston/mcp-server-template/internal/server"
+	"github.com/BearHuddleston/mcp-server-template/pkg/config"
+	"github.com/BearHuddleston/mcp-server-template/pkg/handlers"
+	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
+)
+
+func TestSessionStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) SessionStore{
+		"memory": func(t *testing.T) SessionStore {
+			return NewMemorySessionStore()
+		},
+		"file": func(t *testing.T) SessionStore {
+			store, err := NewFileSessionStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewFileSessionStore failed: %v", err)
+			}
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			created := time.Now().Truncate(time.Second)
+			if err := store.Create(SessionRecord{ID: "a/b", Created: created, LastSeen: created}, 2); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			if err := store.Create(SessionRecord{ID: "a/b"}, 2); !errors.Is(err, ErrSessionExists) {
+				t.Fatalf("expected duplicate session error, got %v", err)
+			}
+			if err := store.Create(SessionRecord{ID: "second"}, 2); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			if err := store.Create(SessionRecord{ID: "third"}, 2); !errors.Is(err, ErrSessionLimit) {
+				t.Fatalf("expected session limit error, got %v", err)
+			}
+
+			touched := created.Add(time.Minute)
+			if err := store.Touch("a/b", touched); err != nil {
+				t.Fatalf("Touch failed: %v", err)
+			}
+			snapshot := mcp.SessionSnapshot{State: mcp.SessionReady, ProtocolVersion: mcp.ProtocolVersion}
+			if err := store.SetSession("a/b", snapshot); err != nil {
+				t.Fatalf("SetSession failed: %v", err)
+			}
+			for _, want := range []uint64{1, 3} {
+				if got, err := store.ReserveEventIDs("a/b", 2); err != nil || got != want {
+					t.Fatalf("expected reserved event IDs to start at %d, got %d (%v)", want, got, err)
+				}
+			}
+
+			record, err := store.Lookup("a/b")
+			if err != nil {
+				t.Fatalf("Lookup failed: %v", err)
+			}
+			if !record.Created.Equal(created) || !record.LastSeen.Equal(touched) {
+				t.Fatalf("unexpected timestamps: %+v", record)
+			}
+			if record.EventCounter != 4 || record.Session.State != mcp.SessionReady || record.Session.ProtocolVersion != mcp.ProtocolVersion {
+				t.Fatalf("unexpected session record: %+v", record)
+			}
+
+			records, err := store.List()
+			if err != nil || len(records) != 2 {
+				t.Fatalf("expected 2 sessions, got %d (%v)", len(records), err)
+			}
+
+			if err := store.Delete("a/b"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if err := store.Delete("a/b"); !errors.Is(err, ErrSessionNotFound) {
+				t.Fatalf("expected not found on second delete, got %v", err)
+			}
+			if _, err := store.Lookup("a/b"); !errors.Is(err, ErrSessionNotFound) {
+				t.Fatalf("expected not found after delete, got %v", err)
+			}
+			if err := store.Touch("a/b", touched); !errors.Is(err, ErrSessionNotFound) {
+				t.Fatalf("expected not found when touching a deleted session, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFileSessionStoreLockSerializesStores(t *testing.T) {
+	dir := t.TempDir()
+	// A lock file left behind by an earlier process does not hold the lock.
+	if err := os.WriteFile(filepath.Join(dir, ".lock"), nil, 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := range writers {
+		store, err := NewFileSessionStore(dir)
+		if err != nil {
+			t.Fatalf("NewFileSessionStore failed: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- store.Create(SessionRecord{ID: fmt.Sprintf("session-%d", i)}, writers/2)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrSessionLimit):
+			t.Fatalf("unexpected Create error: %v", err)
+		}
+	}
+	if created != writers/2 {
+		t.Fatalf("expected the session limit of %d to hold across stores, created %d", writers/2, created)
+	}
+}
+
+func TestTransportsShareFileSessionStore(t *testing.T) {
+	dir := t.TempDir()
+	newReplica := func() *HTTPTransport {
+		store, err := NewFileSessionStore(dir)
+		if err != nil {
+			t.Fatalf("NewFileSessionStore failed: %v", err)
+		}
+		tx := newHTTPTransportForTest()
+		tx.SetSessionStore(store)
+		return tx
+	}
+	replicaA, replicaB := newReplica(), newReplica()
+
+	session := registerTestSession(t, replicaA, "session-1")
+	session.BeginInitialize()
+	session.SetClient(mcp.StructuredProtocolVersion, mcp.Implementation{Name: "client"}, nil)
+	session.MarkInitialized()
+	replicaA.sessions.save("session-1")
+
+	// A request routed to the other replica finds the session.
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(mcp.SessionIDHeader, "session-1")
+	req.Header.Set(mcp.ProtocolVersionHeader, mcp.StructuredProtocolVersion)
+	rr := httptest.NewRecorder()
+	replicaB.handlePost(context.Background(), &httpMockServer{}, rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected replica B to serve the session, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	restored := replicaB.sessions.get("session-1").session
+	if restored == nil || restored.ProtocolVersion() != mcp.StructuredProtocolVersion || !restored.Initialized() {
+		t.Fatalf("expected replica B to restore the negotiated session, got %+v", restored)
+	}
+
+	// A replica catches up when the other one advances the lifecycle.
+	pending := registerTestSession(t, replicaB, "session-2")
+	pending.BeginInitialize()
+	replicaB.sessions.save("session-2")
+	if replicaA.sessions.get("session-2").session.State() != mcp.SessionInitializing {
+		t.Fatal("expected replica A to restore the initializing session")
+	}
+	pending.MarkInitialized()
+	replicaB.sessions.save("session-2")
+	if !replicaA.sessions.get("session-2").session.Initialized() {
+		t.Fatal("expected replica A to pick up the initialized state")
+	}
+
+	// Both replicas reserve disjoint blocks of event IDs from the shared
+	// counter, so the IDs they issue never collide.
+	issued := make(map[string]bool)
+	for _, replica := range []*HTTPTransport{replicaA, replicaB} {
+		next := replica.nextEventIDGenerator(replica.sessions.get("session-1"))
+		for range 2 * eventIDBlock {
+			id := next()
+			if id == "" || issued[id] {
+				t.Fatalf("expected a fresh event ID, got %q", id)
+			}
+			issued[id] = true
+		}
+	}
+	if got := storedEventCounter(replicaA, "session-1"); got < uint64(len(issued)) {
+		t.Fatalf("expected the shared counter to cover %d issued IDs, got %d", len(issued), got)
+	}
+
+	// Deleting on one replica ends the session on the other.
+	req = httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	req.Header.Set(mcp.SessionIDHeader, "session-1")
+	req.Header.Set(mcp.ProtocolVersionHeader, mcp.StructuredProtocolVersion)
+	rr = httptest.NewRecorder()
+	replicaB.handleDelete(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected delete to succeed, got %d", rr.Code)
+	}
+	if replicaA.sessions.get("session-1") != nil {
+		t.Fatal("expected session deleted on replica B to be gone on replica A")
+	}
+	if session.State() != mcp.SessionClosed {
+		t.Fatalf("expected replica A's session to be closed, got %s", session.State())
+	}
+}
+
+func TestRestoredSessionsReceiveBroadcasts(t *testing.T) {
+	store := NewMemorySessionStore()
+	newReplica := func() (*HTTPTransport, *server.Server) {
+		catalog := handlers.NewCatalog()
+		srv, err := server.New(config.New(), catalog, catalog, catalog)
+		if err != nil {
+			t.Fatalf("server.New failed: %v", err)
+		}
+		tx := newHTTPTransportForTest()
+		tx.SetSessionStore(store)
+		tx.bindServer(srv)
+		return tx, srv
+	}
+	replicaA, _ := newReplica()
+	replicaB, serverB := newReplica()
+
+	session := registerTestSession(t, replicaA, "session-1")
+	session.BeginInitialize()
+	session.SetClient(mcp.ProtocolVersion, mcp.Implementation{Name: "client"}, nil)
+	session.MarkInitialized()
+	replicaA.sessions.save("session-1")
+
+	// The client's GET stream lands on replica B, which never saw initialize.
+	rr, cancel, done := openGetStream(replicaB, "session-1")
+	waitForOutbox(t, replicaB, "session-1", 1, 0)
+
+	serverB.Notifications().Notify(mcp.NotificationToolsListChanged, nil)
+	waitForPending(t, replicaB.sessions.get("session-1").outbox)
+	cancel()
+	<-done
+
+	if !strings.Contains(rr.Body.String(), mcp.NotificationToolsListChanged) {
+		t.Fatalf("expected replica B's broadcast on the restored session's stream, got %q", rr.Body.String())
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"sync"
 
 	"github.com/BearHuddleston/mcp-server-template/pkg/config"
 	"github.com/BearHuddleston/mcp-server-template/pkg/mcp"
@@ -22,6 +23,8 @@ type Stdio struct {
 	input      io.Reader
 	output     io.Writer
 	newScanner func(io.Reader) *bufio.Scanner
+	session    *mcp.Session
+	writeMu    sync.Mutex
 }
 
 // NewStdio creates a new stdio transport
@@ -45,6 +48,16 @@ func NewStdio(cfg *config.Config) *Stdio {
 func (t *Stdio) Start(ctx context.Context, server mcp.Server) error {
 	slog.Info("starting stdio transport")
 
+	t.session = t.newSession()
+
+	// In-flight requests finish before the session closes, since closing it
+	// cancels them. Each is bounded by the request timeout.
+	var inflight sync.WaitGroup
+	defer func() {
+		inflight.Wait()
+		t.session.Close()
+	}()
+
 	scanner := t.newScanner(t.input)
 
 	// Create channels for message processing
@@ -94,6 +107,17 @@ func (t *Stdio) Start(ctx context.Context, server mcp.Server) error {
 				continue
 			}
 
+			if runsConcurrently(line) {
+				inflight.Add(1)
+				go func() {
+					defer inflight.Done()
+					if err := t.handleMessage(ctx, server, line); err != nil {
+						slog.Error("error handling message", "error", err)
+					}
+				}()
+				continue
+			}
+
 			if err := t.handleMessage(ctx, server, line); err != nil {
 				slog.Error("error handling message", "error", err)
 			}
@@ -107,8 +131,8 @@ func (t *Stdio) Stop() error {
 }
 
 func (t *Stdio) handleMessage(ctx context.Context, server mcp.Server, line string) error {
-	var req mcp.Request
-	if err := json.Unmarshal([]byte(line), &req); err != nil {
+	kind, req, messageID, err := classifyJSONRPCMessage(json.RawMessage(line))
+	if err != nil {
 		return t.sendParseError(line, err)
 	}
 
@@ -118,20 +142,83 @@ func (t *Stdio) handleMessage(ctx context.Context, server mcp.Server, line strin
 		return nil
 	}
 
+	if t.session == nil {
+		t.session = t.newSession()
+	}
+
+	switch kind {
+	case messageKindInvalid:
+		return t.sender().SendError(messageID, mcp.ErrorCodeInvalidRequest, "Invalid JSON-RPC message shape", nil)
+	case messageKindResponse:
+		return t.handleClientResponse(line)
+	}
+
+	ctx = mcp.WithSession(ctx, t.session)
+	ctx = context.WithValue(ctx, mcp.SessionIDKey, t.session.ID())
+
 	// Handle notifications (no response expected)
-	if req.ID == nil {
-		slog.Info("received notification", "method", req.Method)
-		return nil
+	if kind == messageKindNotification {
+		return t.handleNotification(ctx, server, req)
 	}
 
 	// Add stdout sender to context
-	reqCtx := context.WithValue(ctx, mcp.ResponseSenderKey, &StdoutSender{writer: t.output})
+	reqCtx := context.WithValue(ctx, mcp.ResponseSenderKey, t.sender())
 	reqCtx, cancel := context.WithTimeout(reqCtx, t.config.RequestTimeout)
 	defer cancel()
 
 	return server.HandleRequest(reqCtx, req)
 }
 
+// handleClientResponse routes a client response to the session request waiting for it
+func (t *Stdio) handleClientResponse(line string) error {
+	resp, err := decodeClientResponse([]byte(line))
+	if err == nil {
+		err = t.session.HandleResponse(resp)
+	}
+	if err != nil {
+		slog.Warn("dropping client response", "id", resp.ID, "error", err)
+	}
+	return nil
+}
+
+// runsConcurrently reports whether line is a request that may be handled
+// alongside later messages. Handlers can wait on client responses, so requests
+// must not block the reader; initialize stays ordered before everything else.
+func runsConcurrently(line string) bool {
+	kind, req, _, err := classifyJSONRPCMessage(json.RawMessage(line))
+	return err == nil && kind == messageKindRequest && req.Method != "initialize"
+}
+
+func (t *Stdio) handleNotification(ctx context.Context, server mcp.Server, req mcp.Request) error {
+	handler, ok := server.(mcp.NotificationHandler)
+	if !ok {
+		slog.Info("received notification", "method", req.Method)
+		return nil
+	}
+
+	notifyCtx, cancel := context.WithTimeout(ctx, t.config.RequestTimeout)
+	defer cancel()
+
+	return handler.HandleNotification(notifyCtx, req)
+}
+
+// newSession creates the session shared by every message on one stdio connection
+func (t *Stdio) newSession() *mcp.Session {
+	id, err := generateSessionID()
+	if err != nil {
+		id = "stdio"
+	}
+	session := mcp.NewSession(id)
+	session.SetWriter(t.sender())
+	return session
+}
+
+// sender returns a stdout sender that shares the transport's write lock, so
+// responses and server-initiated messages never interleave
+func (t *Stdio) sender() *StdoutSender {
+	return &StdoutSender{writer: t.output, mu: &t.writeMu}
+}
+
 func (t *Stdio) sendParseError(line string, err error) error {
 	// Try to extract ID from malformed JSON
 	var errorID any = -1
@@ -152,23 +239,16 @@ func (t *Stdio) sendParseError(line string, err error) error {
 		},
 	}
 
-	respBytes, marshErr := json.Marshal(errorResp)
-	if marshErr != nil {
-		return errors.Join(err, marshErr)
-	}
-
-	if _, writeErr := t.output.Write(respBytes); writeErr != nil {
-		return errors.Join(err, writeErr)
-	}
-	if _, writeErr := t.output.Write([]byte("\n")); writeErr != nil {
+	if writeErr := t.sender().writeLine(errorResp); writeErr != nil {
 		return errors.Join(err, writeErr)
 	}
 	return nil
 }
 
-// StdoutSender implements ResponseSender for stdio transport
+// StdoutSender implements ResponseSender and MessageWriter for stdio transport
 type StdoutSender struct {
 	writer io.Writer
+	mu     *sync.Mutex
 }
 
 func (s *StdoutSender) resolveWriter() io.Writer {
@@ -179,16 +259,32 @@ func (s *StdoutSender) resolveWriter() io.Writer {
 }
 
 func (s *StdoutSender) SendResponse(response mcp.Response) error {
-	jsonBytes, err := json.Marshal(response)
+	if err := s.writeLine(response); err != nil {
+		return fmt.Errorf("failed to send response: %w", err)
+	}
+	return nil
+}
+
+// WriteMessage writes a server-initiated message as a single line
+func (s *StdoutSender) WriteMessage(msg any) error {
+	if err := s.writeLine(msg); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+func (s *StdoutSender) writeLine(msg any) error {
+	jsonBytes, err := json.Marshal(msg)
 	if err != nil {
-		return fmt.Errorf("failed to marshal response: %w", err)
+		return fmt.Errorf("marshal: %w", err)
 	}
-	writer := s.resolveWriter()
-	if _, err := writer.Write(jsonBytes); err != nil {
-		return fmt.Errorf("failed to write response: %w", err)
+	if s.mu != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
 	}
-	if _, err := writer.Write([]byte("\n")); err != nil {
-		return fmt.Errorf("failed to write response: %w", err)
+	writer := s.resolveWriter()
+	if _, err := writer.Write(append(jsonBytes, '\n')); err != nil {
+		return fmt.Errorf("write: %w", err)
 	}
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
@@ -26,7 +27,7 @@ type failingReader struct {
 
 type failingWriter struct{}
 
-func (m *mockServer) Initialize(ctx context.Context) (*mcp.InitializeResponse, error) {
+func (m *mockServer) Initialize(ctx context.Context, params mcp.InitializeParams) (*mcp.InitializeResponse, error) {
 	return &mcp.InitializeResponse{
 		ProtocolVersion: mcp.ProtocolVersion,
 		Capabilities:    map[string]any{},
@@ -51,8 +52,8 @@ func (m *mockServer) HandleRequest(ctx context.Context, req mcp.Request) error {
 	return sender.SendResponse(response)
 }
 
-func (m *countingServer) Initialize(ctx context.Context) (*mcp.InitializeResponse, error) {
-	return (&mockServer{}).Initialize(ctx)
+func (m *countingServer) Initialize(ctx context.Context, params mcp.InitializeParams) (*mcp.InitializeResponse, error) {
+	return (&mockServer{}).Initialize(ctx, params)
 }
 
 func (m *countingServer) HandleRequest(ctx context.Context, req mcp.Request) error {
@@ -152,7 +153,7 @@ type slowMockServer struct {
 	delay time.Duration
 }
 
-func (m *slowMockServer) Initialize(ctx context.Context) (*mcp.InitializeResponse, error) {
+func (m *slowMockServer) Initialize(ctx context.Context, params mcp.InitializeParams) (*mcp.InitializeResponse, error) {
 	return &mcp.InitializeResponse{
 		ProtocolVersion: mcp.ProtocolVersion,
 		Capabilities:    map[string]any{},
@@ -178,6 +179,20 @@ func (m *slowMockServer) HandleRequest(ctx context.Context, req mcp.Request) err
 	}
 }
 
+// sessionBoundServer registers each request with its session, as the server
+// does for cancellation, and signals started before answering after delay.
+type sessionBoundServer struct {
+	slowMockServer
+	started chan struct{}
+}
+
+func (m *sessionBoundServer) HandleRequest(ctx context.Context, req mcp.Request) error {
+	ctx, done := mcp.SessionFromContext(ctx).BeginRequest(ctx, req.ID)
+	defer done()
+	close(m.started)
+	return m.slowMockServer.HandleRequest(ctx, req)
+}
+
 func TestStdio_TimeoutApplied(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -365,6 +380,30 @@ func TestStdio_Start(t *testing.T) {
 		}
 	})
 
+	t.Run("in-flight request answered after eof", func(t *testing.T) {
+		cfg := &config.Config{RequestTimeout: 30 * time.Second}
+		stdio := NewStdio(cfg)
+		input, inputWriter := io.Pipe()
+		stdio.input = input
+		var out bytes.Buffer
+		stdio.output = &out
+
+		srv := &sessionBoundServer{slowMockServer: slowMockServer{delay: 50 * time.Millisecond}, started: make(chan struct{})}
+		go func() {
+			_, _ = io.WriteString(inputWriter, "{\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"test\"}\n")
+			<-srv.started
+			inputWriter.Close()
+		}()
+
+		err := stdio.Start(context.Background(), srv)
+		if err != nil {
+			t.Fatalf("expected nil on EOF, got %v", err)
+		}
+		if !strings.Contains(out.String(), `"test":"response"`) {
+			t.Fatalf("expected the slow request to be answered after EOF, got %q", out.String())
+		}
+	})
+
 	t.Run("scanner read error is returned", func(t *testing.T) {
 		cfg := &config.Config{RequestTimeout: 30 * time.Second}
 		stdio := NewStdio(cfg)
@@ -456,3 +495,110 @@ func TestStdio_sendParseError(t *testing.T) {
 		})
 	}
 }
+
+type sessionRecordingServer struct {
+	mockServer
+	requestSessions []*mcp.Session
+	notifications   []string
+	notifySessions  []*mcp.Session
+}
+
+func (m *sessionRecordingServer) HandleRequest(ctx context.Context, req mcp.Request) error {
+	m.requestSessions = append(m.requestSessions, mcp.SessionFromContext(ctx))
+	return m.mockServer.HandleRequest(ctx, req)
+}
+
+func (m *sessionRecordingServer) HandleNotification(ctx context.Context, req mcp.Request) error {
+	m.notifications = append(m.notifications, req.Method)
+	m.notifySessions = append(m.notifySessions, mcp.SessionFromContext(ctx))
+	return nil
+}
+
+func TestStdio_SessionSharedAcrossMessages(t *testing.T) {
+	stdio := NewStdio(&config.Config{RequestTimeout: time.Second})
+	stdio.input = strings.NewReader(strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize"}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+	}, "\n") + "\n")
+	stdio.output = &bytes.Buffer{}
+
+	srv := &sessionRecordingServer{}
+	if err := stdio.Start(context.Background(), srv); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if len(srv.requestSessions) != 2 || len(srv.notifySessions) != 1 {
+		t.Fatalf("expected 2 requests and 1 notification, got %d and %d", len(srv.requestSessions), len(srv.notifySessions))
+	}
+	session := srv.requestSessions[0]
+	if session == nil {
+		t.Fatal("expected session in request context")
+	}
+	if srv.requestSessions[1] != session || srv.notifySessions[0] != session {
+		t.Fatal("expected every message on one connection to share a session")
+	}
+	if srv.notifications[0] != "notifications/initialized" {
+		t.Fatalf("expected initialized notification, got %v", srv.notifications)
+	}
+	if session.State() != mcp.SessionClosed {
+		t.Fatalf("expected session to be closed after input ends, got %s", session.State())
+	}
+}
+
+type samplingServer struct {
+	mockServer
+}
+
+func (m *samplingServer) HandleRequest(ctx context.Context, req mcp.Request) error {
+	sender := ctx.Value(mcp.ResponseSenderKey).(mcp.ResponseSender)
+	result, err := mcp.SessionFromContext(ctx).Request(ctx, "sampling/createMessage", map[string]any{"maxTokens": 10})
+	if err != nil {
+		return sender.SendError(req.ID, mcp.ErrorCodeInternalError, err.Error(), nil)
+	}
+	return sender.SendResponse(mcp.Response{JSONRPC: mcp.JSONRPCVersion, ID: req.ID, Result: result})
+}
+
+// lineWriter hands every written line to a channel
+type lineWriter struct {
+	lines chan string
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.lines <- strings.TrimSpace(string(p))
+	return len(p), nil
+}
+
+func TestStdio_ServerRequestRoundTrip(t *testing.T) {
+	input, inputWriter := io.Pipe()
+	output := &lineWriter{lines: make(chan string, 4)}
+
+	stdio := NewStdio(&config.Config{RequestTimeout: time.Second})
+	stdio.input = input
+	stdio.output = output
+
+	done := make(chan error, 1)
+	go func() { done <- stdio.Start(context.Background(), &samplingServer{}) }()
+
+	fmt.Fprintln(inputWriter, `{"jsonrpc":"2.0","id":1,"method":"tools/call"}`)
+
+	var outbound mcp.Request
+	if err := json.Unmarshal([]byte(<-output.lines), &outbound); err != nil {
+		t.Fatalf("failed to decode server request: %v", err)
+	}
+	if outbound.Method != "sampling/createMessage" || outbound.ID == nil {
+		t.Fatalf("unexpected server request: %+v", outbound)
+	}
+
+	fmt.Fprintf(inputWriter, `{"jsonrpc":"2.0","id":%q,"result":{"model":"test-model"}}`+"\n", outbound.ID)
+
+	response := <-output.lines
+	if !strings.Contains(response, `"id":1`) || !strings.Contains(response, `"model":"test-model"`) {
+		t.Fatalf("expected tool response carrying client result, got %q", response)
+	}
+
+	inputWriter.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+}